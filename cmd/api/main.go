@@ -12,10 +12,10 @@ import (
 	"time"
 
 	"github.com/HywlEch/Todo_list/internal/config"
-	"github.com/HywlEch/Todo_list/internal/handlers"
-	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/HywlEch/Todo_list/internal/jobs"
+	"github.com/HywlEch/Todo_list/internal/password"
+	"github.com/HywlEch/Todo_list/internal/router"
 	"github.com/HywlEch/Todo_list/internal/store"
-	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	"github.com/go-redsync/redsync/v4"
 	"github.com/go-redsync/redsync/v4/redis/goredis/v8"
@@ -56,41 +56,38 @@ func main() {
 	//创建CacheStore装饰器
 	cacheDbStore := store.NewCacheStore(dbStore, redisClient)
 
-	//初始化Redsync
+	//初始化Redsync，任务更新已有乐观锁保护，任务的分布式锁按配置开启
 	pool := goredis.NewPool(redisClient) //使用redisv8创建连接池
-	rs := redsync.New(pool)
+	lockSync := redsync.New(pool)
 	log.Println("redsync初始化成功")
+	var rs *redsync.Redsync
+	if cfg.Redis.TaskLock {
+		rs = lockSync
+	}
+
+	//启动后台任务，服务关闭时通过jobsCancel停止
+	jobsCtx, jobsCancel := context.WithCancel(context.Background())
+	defer jobsCancel()
+	go jobs.NewTrashPurger(cacheDbStore, cfg.Trash).Run(jobsCtx)
+	go jobs.NewRankRebalancer(cacheDbStore, cfg.Ranking).Run(jobsCtx)
 
-	//初始化Handler
-	taskHandler := handlers.NewTaskHandler(cacheDbStore, rs)
-	//初始化UserHandler，传入JWT配置
-	userHandler := handlers.NewUserHandler(cacheDbStore, cfg.JWT)
-
-	//设置路由
-
-	//router := gin.Default()
-	router := gin.New() //使用gin.New()创建一个干净的引擎
-	//全局应用中间件
-	router.Use(middleware.Logger()) //应用日志中间件
-	router.Use(gin.Recovery()) //使用gin默认的Recovery中间件,防止panic
-	router.Use(middleware.ErrorMiddleware())
-	router.Use(middleware.RateLimitMiddleware(redisClient))
-	router.Use(middleware.TimeoutMiddleware(10 * time.Second))//应用5秒钟超时中间件
-
-	authRouter := router.Group("/auth")
-	{
-		authRouter.POST("/regist", userHandler.Regiester)
-		authRouter.POST("/login", userHandler.Login)
+	passwordPolicy, err := password.NewPolicy(cfg.Password)
+	if err != nil {
+		log.Fatalf("加载密码策略失败：%s", err)
 	}
 
-	taskRouter := router.Group("/tasks")
-	{
-		taskRouter.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
-		taskRouter.POST("", taskHandler.CreateTask)
-		taskRouter.GET("", taskHandler.GetTasks)
-		taskRouter.GET("/:id", taskHandler.GetTaskByID)
-		taskRouter.PUT("/:id", taskHandler.UpdateTask)
-		taskRouter.DELETE("/:id", taskHandler.DeleteTask)
+	//设置路由，所有版本的路由都在 router 包中注册
+	router, err := router.New(router.Deps{
+		Store:          cacheDbStore,
+		Redis:          redisClient,
+		TaskLock:       rs,
+		Locker:         lockSync,
+		JWT:            cfg.JWT,
+		PasswordPolicy: passwordPolicy,
+		API:            cfg.API,
+	})
+	if err != nil {
+		log.Fatalf("初始化路由失败：%s", err)
 	}
 
 	// serverAddr := fmt.Sprintf(":%s", cfg.Server.Port)
@@ -121,6 +118,7 @@ func main() {
 	//阻塞主进程,直到quitchannel 收到一个信号
 	<-quit
 	log.Println("关闭服务中...")
+	jobsCancel()
 
 	//创造一个有超时的context， 用于通知我们有5秒的时间来处理请求
 	ctx, cancel := context.WithTimeout(context.Background(), 5 *time.Second)
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/filter"
+	"github.com/HywlEch/Todo_list/pkg/client"
+)
+
+// stringList 可以重复的标志，例如 --tag a --tag b
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func setupLogin(fs *flag.FlagSet) runFunc {
+	username := fs.String("username", "", "username (prompted when empty)")
+	fs.StringVar(username, "u", "", "shorthand for --username")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from standard input")
+	return func(ctx context.Context, a *app, args []string) error {
+		if len(args) > 0 {
+			return usagef("unexpected arguments %q", args)
+		}
+		creds, err := loadCredentials()
+		if err != nil {
+			return err
+		}
+		if *username == "" {
+			*username = creds.Username
+		}
+		if *username == "" {
+			fmt.Fprint(a.stderr, "Username: ")
+			if *username, err = readLine(a.stdin); err != nil {
+				return fmt.Errorf("read username: %w", err)
+			}
+		}
+		var password string
+		if *passwordStdin {
+			password, err = readLine(a.stdin)
+		} else {
+			password, err = a.readPassword("Password: ")
+		}
+		if err != nil {
+			return fmt.Errorf("read password: %w", err)
+		}
+
+		server := a.serverURL(creds)
+		c, err := a.newClient(server)
+		if err != nil {
+			return err
+		}
+		token, err := c.Login(ctx, *username, password)
+		if err != nil {
+			return err
+		}
+		if err := saveCredentials(&credentials{Server: server, Username: *username, Token: token}); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stderr, "Logged in to %s as %s\n", server, *username)
+		return nil
+	}
+}
+
+func setupLogout(fs *flag.FlagSet) runFunc {
+	return func(ctx context.Context, a *app, args []string) error {
+		creds, err := loadCredentials()
+		if err != nil {
+			return err
+		}
+		creds.Token = ""
+		return saveCredentials(creds)
+	}
+}
+
+func setupAdd(fs *flag.FlagSet) runFunc {
+	due := fs.String("due", "", "due date: today, tomorrow, today+3d, 2006-01-02 or RFC3339")
+	priority := fs.String("priority", "", "priority: none, low, medium, high, urgent or 0-4")
+	fs.StringVar(priority, "p", "", "shorthand for --priority")
+	content := fs.String("content", "", "task content")
+	var tags stringList
+	fs.Var(&tags, "tag", "tag, can be repeated")
+	fs.Var(&tags, "t", "shorthand for --tag")
+	return func(ctx context.Context, a *app, args []string) error {
+		title := strings.TrimSpace(strings.Join(args, " "))
+		if title == "" {
+			return usagef("missing title")
+		}
+		input := client.TaskInput{Title: title, Content: *content, Tags: tags}
+		var err error
+		if *due != "" {
+			if input.DueAt, err = a.parseDue(*due); err != nil {
+				return err
+			}
+		}
+		if *priority != "" {
+			if input.Priority, err = parsePriority(*priority); err != nil {
+				return err
+			}
+		}
+		c, err := a.client()
+		if err != nil {
+			return err
+		}
+		task, err := c.CreateTask(ctx, input)
+		if err != nil {
+			return err
+		}
+		return a.printTask(task)
+	}
+}
+
+func setupList(fs *flag.FlagSet) runFunc {
+	expr := fs.String("filter", "", `filter expression, e.g. "done:false AND tag:work"`)
+	fs.StringVar(expr, "f", "", "shorthand for --filter")
+	sortBy := fs.String("sort", "", "sort order, e.g. due_asc (default from your profile)")
+	return func(ctx context.Context, a *app, args []string) error {
+		//ls 之后的参数也作为过滤条件，例如 todo ls tag:work done:false
+		if len(args) > 0 {
+			*expr = strings.TrimSpace(*expr + " " + strings.Join(args, " "))
+		}
+		c, err := a.client()
+		if err != nil {
+			return err
+		}
+		tasks, err := c.ListTasks(ctx, &client.ListTasksOptions{Filter: *expr, Sort: *sortBy})
+		if err != nil {
+			return err
+		}
+		return a.printTasks(tasks)
+	}
+}
+
+func setupShow(fs *flag.FlagSet) runFunc {
+	return func(ctx context.Context, a *app, args []string) error {
+		ids, err := parseIDs(args, true)
+		if err != nil {
+			return err
+		}
+		c, err := a.client()
+		if err != nil {
+			return err
+		}
+		task, err := c.GetTask(ctx, ids[0])
+		if err != nil {
+			return err
+		}
+		return a.printTask(task)
+	}
+}
+
+func setupDone(fs *flag.FlagSet) runFunc {
+	undo := fs.Bool("undo", false, "mark the tasks as not done")
+	return func(ctx context.Context, a *app, args []string) error {
+		ids, err := parseIDs(args, false)
+		if err != nil {
+			return err
+		}
+		c, err := a.client()
+		if err != nil {
+			return err
+		}
+		tasks := make([]client.Task, 0, len(ids))
+		for _, id := range ids {
+			task, err := c.CompleteTask(ctx, id, !*undo, 0)
+			if err != nil {
+				return fmt.Errorf("task %d: %w", id, err)
+			}
+			tasks = append(tasks, *task)
+		}
+		return a.printTasks(tasks)
+	}
+}
+
+func setupEdit(fs *flag.FlagSet) runFunc {
+	title := fs.String("title", "", "new title, skips the editor")
+	due := fs.String("due", "", `new due date, "none" clears it; skips the editor`)
+	return func(ctx context.Context, a *app, args []string) error {
+		ids, err := parseIDs(args, true)
+		if err != nil {
+			return err
+		}
+		c, err := a.client()
+		if err != nil {
+			return err
+		}
+		task, err := c.GetTask(ctx, ids[0])
+		if err != nil {
+			return err
+		}
+
+		patch := map[string]any{}
+		if *title != "" {
+			patch["title"] = *title
+		}
+		switch {
+		case strings.EqualFold(*due, "none"):
+			patch["due_at"] = nil
+		case *due != "":
+			if patch["due_at"], err = a.parseDue(*due); err != nil {
+				return err
+			}
+		}
+		if len(patch) == 0 {
+			content, err := a.editContent(task)
+			if err != nil {
+				return err
+			}
+			if content == task.Content {
+				fmt.Fprintln(a.stderr, "Content unchanged, nothing to do.")
+				return nil
+			}
+			patch["content"] = content
+		}
+		//带上读取时的版本号，编辑期间任务被别人修改时返回冲突而不是覆盖
+		task, err = c.PatchTask(ctx, task.ID, patch, task.Version)
+		if err != nil {
+			return err
+		}
+		return a.printTask(task)
+	}
+}
+
+// editContent 把任务内容写入临时文件，用编辑器打开后读回
+func (a *app) editContent(task *client.Task) (string, error) {
+	dir, err := os.MkdirTemp("", "todo-edit-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, fmt.Sprintf("task-%d.md", task.ID))
+	if err := os.WriteFile(path, []byte(task.Content), 0o600); err != nil {
+		return "", err
+	}
+	if err := a.editFile(path); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	//编辑器通常会在文件末尾加换行，原内容没有时去掉
+	content := string(data)
+	if !strings.HasSuffix(task.Content, "\n") {
+		content = strings.TrimRight(content, "\r\n")
+	}
+	return content, nil
+}
+
+func setupRemove(fs *flag.FlagSet) runFunc {
+	return func(ctx context.Context, a *app, args []string) error {
+		ids, err := parseIDs(args, false)
+		if err != nil {
+			return err
+		}
+		c, err := a.client()
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := c.DeleteTask(ctx, id, 0); err != nil {
+				return fmt.Errorf("task %d: %w", id, err)
+			}
+			if a.output != "json" {
+				fmt.Fprintf(a.stdout, "Moved task %d to the trash\n", id)
+			}
+		}
+		if a.output == "json" {
+			return writeJSON(a.stdout, ids)
+		}
+		return nil
+	}
+}
+
+// setupIDs 补全脚本调用，每行输出“ID<Tab>标题”，出错时不输出任何内容
+func setupIDs(fs *flag.FlagSet) runFunc {
+	return func(ctx context.Context, a *app, args []string) error {
+		c, err := a.client()
+		if err != nil {
+			return errSilent
+		}
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+		tasks, err := c.ListTasks(ctx, &client.ListTasksOptions{Filter: "done:false"})
+		if err != nil {
+			return errSilent
+		}
+		for _, t := range tasks {
+			fmt.Fprintf(a.stdout, "%d\t%s\n", t.ID, t.Title)
+		}
+		return nil
+	}
+}
+
+// errSilent 只设置退出码，不输出错误信息
+var errSilent = errors.New("")
+
+// parseIDs 解析任务ID，single 为 true 时只接受一个ID
+func parseIDs(args []string, single bool) ([]int, error) {
+	if len(args) == 0 {
+		return nil, usagef("missing task id")
+	}
+	if single && len(args) > 1 {
+		return nil, usagef("expected one task id, got %d", len(args))
+	}
+	ids := make([]int, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+		if err != nil || id <= 0 {
+			return nil, usagef("invalid task id %q", arg)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseDue 解析截止时间，与过滤表达式中的时间写法相同，按本地时区计算
+func (a *app) parseDue(raw string) (*time.Time, error) {
+	t, ok := filter.ParseTime(raw, a.now())
+	if !ok {
+		return nil, usagef("invalid due date %q, use today, tomorrow, today+3d, 2006-01-02 or RFC3339", raw)
+	}
+	return &t, nil
+}
+
+var priorityNames = []string{"none", "low", "medium", "high", "urgent"}
+
+func parsePriority(raw string) (int, error) {
+	for i, name := range priorityNames {
+		if strings.EqualFold(raw, name) || raw == strconv.Itoa(i) {
+			return i, nil
+		}
+	}
+	return 0, usagef("invalid priority %q, use none, low, medium, high, urgent or 0-4", raw)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// 使用方法：
+//
+//	bash: source <(todo completion bash)
+//	zsh:  source <(todo completion zsh)
+//	fish: todo completion fish | source
+func setupCompletion(fs *flag.FlagSet) runFunc {
+	return func(ctx context.Context, a *app, args []string) error {
+		if len(args) != 1 {
+			return usagef("expected one shell: bash, zsh or fish")
+		}
+		switch args[0] {
+		case "bash":
+			writeBashCompletion(a.stdout)
+		case "zsh":
+			//zsh 通过 bashcompinit 使用 bash 的补全脚本
+			fmt.Fprintln(a.stdout, "autoload -U +X compinit && compinit")
+			fmt.Fprintln(a.stdout, "autoload -U +X bashcompinit && bashcompinit")
+			writeBashCompletion(a.stdout)
+		case "fish":
+			writeFishCompletion(a.stdout)
+		default:
+			return usagef("unsupported shell %q, use bash, zsh or fish", args[0])
+		}
+		return nil
+	}
+}
+
+// commandFlags 返回命令的所有标志名（包括共用的标志），从 setup 注册的标志中读取
+func commandFlags(cmd command) []*flag.Flag {
+	a := &app{}
+	fs := a.newFlagSet(cmd)
+	cmd.setup(fs)
+	var flags []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) { flags = append(flags, f) })
+	return flags
+}
+
+func flagName(f *flag.Flag) string {
+	if len(f.Name) == 1 {
+		return "-" + f.Name
+	}
+	return "--" + f.Name
+}
+
+func writeBashCompletion(w io.Writer) {
+	var names []string
+	for _, cmd := range commands() {
+		if !cmd.hidden {
+			names = append(names, cmd.name)
+		}
+	}
+	fmt.Fprintln(w, "_todo() {")
+	fmt.Fprintln(w, `  local cur="${COMP_WORDS[COMP_CWORD]}" opts=""`)
+	fmt.Fprintln(w, "  if [[ $COMP_CWORD -eq 1 ]]; then")
+	fmt.Fprintf(w, "    COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	fmt.Fprintln(w, "    return")
+	fmt.Fprintln(w, "  fi")
+	fmt.Fprintln(w, `  case "${COMP_WORDS[1]}" in`)
+	for _, cmd := range commands() {
+		if cmd.hidden {
+			continue
+		}
+		var flags []string
+		for _, f := range commandFlags(cmd) {
+			flags = append(flags, flagName(f))
+		}
+		fmt.Fprintf(w, "    %s) opts=%q ;;\n", cmd.name, strings.Join(flags, " "))
+	}
+	fmt.Fprintln(w, "  esac")
+	fmt.Fprintln(w, `  if [[ "$cur" == -* ]]; then`)
+	fmt.Fprintln(w, `    COMPREPLY=($(compgen -W "$opts" -- "$cur"))`)
+	fmt.Fprintln(w, "    return")
+	fmt.Fprintln(w, "  fi")
+	fmt.Fprintln(w, `  case "${COMP_WORDS[1]}" in`)
+	var idCommands []string
+	for _, cmd := range commands() {
+		if cmd.ids {
+			idCommands = append(idCommands, cmd.name)
+		}
+	}
+	fmt.Fprintf(w, "    %s) COMPREPLY=($(compgen -W \"$(todo __ids 2>/dev/null | cut -f1)\" -- \"$cur\")) ;;\n", strings.Join(idCommands, "|"))
+	fmt.Fprintln(w, `    completion) COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur")) ;;`)
+	fmt.Fprintln(w, "  esac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -F _todo todo")
+}
+
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprintln(w, "complete -c todo -f")
+	for _, cmd := range commands() {
+		if cmd.hidden {
+			continue
+		}
+		fmt.Fprintf(w, "complete -c todo -n __fish_use_subcommand -a %s -d %s\n", cmd.name, fishQuote(cmd.summary))
+		cond := "__fish_seen_subcommand_from " + cmd.name
+		for _, f := range commandFlags(cmd) {
+			opt := "-l " + f.Name
+			if len(f.Name) == 1 {
+				opt = "-s " + f.Name
+			}
+			fmt.Fprintf(w, "complete -c todo -n %s %s -d %s\n", fishQuote(cond), opt, fishQuote(f.Usage))
+		}
+		if cmd.ids {
+			//__ids 的输出为“ID<Tab>标题”，fish 会把标题作为说明显示
+			fmt.Fprintf(w, "complete -c todo -n %s -a '(todo __ids 2>/dev/null)'\n", fishQuote(cond))
+		}
+	}
+	fmt.Fprintln(w, "complete -c todo -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish'")
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,295 @@
+// cmd/todo/main.go
+//
+// todo 是 Todo API 的命令行客户端，通过 HTTP 访问服务端：
+//
+//	todo login --server http://localhost:8080
+//	todo add "写周报" --due tomorrow --tag work
+//	todo ls --filter "done:false AND due<today+7d"
+//	todo done 42
+//	todo tui
+//
+// 登录后 token 保存在用户配置目录（例如 ~/.config/todo/credentials.json）中。
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/HywlEch/Todo_list/pkg/client"
+)
+
+const (
+	defaultServer = "http://localhost:8080"
+	apiPrefix     = "/api/v1"
+)
+
+// runFunc 执行命令，args 为去掉标志之后的参数
+type runFunc func(ctx context.Context, a *app, args []string) error
+
+// command 一个子命令，setup 注册命令自己的标志并返回执行函数，补全脚本也通过 setup 读取标志
+type command struct {
+	name    string
+	args    string //参数说明，例如 <id>...
+	summary string
+	ids     bool //参数是任务ID，补全时列出未完成的任务
+	hidden  bool
+	setup   func(fs *flag.FlagSet) runFunc
+}
+
+// usageError 参数错误，退出码为2
+type usageError struct{ msg string }
+
+func (e *usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// app 命令运行时的环境，测试时替换输入输出和编辑器
+type app struct {
+	stdin  *bufio.Reader
+	stdout io.Writer
+	stderr io.Writer
+	now    func() time.Time
+	//editFile 用编辑器打开文件，返回后读取文件内容
+	editFile func(path string) error
+	//readPassword 读取密码，终端中不回显
+	readPassword func(prompt string) (string, error)
+
+	//所有命令共用的标志
+	output string
+	server string
+}
+
+func newApp() *app {
+	stdin := bufio.NewReader(os.Stdin)
+	return &app{
+		stdin:    stdin,
+		stdout:   os.Stdout,
+		stderr:   os.Stderr,
+		now:      time.Now,
+		editFile: runEditor,
+		readPassword: func(prompt string) (string, error) {
+			return readPassword(stdin, os.Stderr, prompt)
+		},
+	}
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(newApp().run(ctx, os.Args[1:]))
+}
+
+// commands 按名称排序后用于帮助信息和补全
+func commands() []command {
+	cmds := []command{
+		{name: "login", summary: "Log in and save the token", setup: setupLogin},
+		{name: "logout", summary: "Forget the saved token", setup: setupLogout},
+		{name: "add", args: "<title>", summary: "Create a task", setup: setupAdd},
+		{name: "ls", args: "[filter]", summary: "List tasks", setup: setupList},
+		{name: "show", args: "<id>", summary: "Show a task", ids: true, setup: setupShow},
+		{name: "done", args: "<id>...", summary: "Mark tasks as done", ids: true, setup: setupDone},
+		{name: "edit", args: "<id>", summary: "Edit a task's content in $EDITOR", ids: true, setup: setupEdit},
+		{name: "rm", args: "<id>...", summary: "Move tasks to the trash", ids: true, setup: setupRemove},
+		{name: "tui", summary: "Browse and edit tasks in a full-screen UI", setup: setupTUI},
+		{name: "completion", args: "bash|zsh|fish", summary: "Print a shell completion script", setup: setupCompletion},
+		{name: "__ids", summary: "List open task IDs for shell completion", hidden: true, setup: setupIDs},
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].name < cmds[j].name })
+	return cmds
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// run 执行命令并返回退出码
+func (a *app) run(ctx context.Context, args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		if len(args) > 1 {
+			if cmd, ok := findCommand(args[1]); ok {
+				fs := a.newFlagSet(cmd)
+				cmd.setup(fs)
+				fs.Usage()
+				return 0
+			}
+		}
+		a.usage()
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(a.stderr, "todo: unknown command %q\n\n", args[0])
+		a.usage()
+		return 2
+	}
+
+	fs := a.newFlagSet(cmd)
+	runCmd := cmd.setup(fs)
+	rest, err := parseArgs(fs, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err == nil && !validOutput(a.output) {
+		err = usagef("unknown output format %q, use table, json or plain", a.output)
+	}
+	if err == nil {
+		err = runCmd(ctx, a, rest)
+	}
+	if err == nil {
+		return 0
+	}
+	var ue *usageError
+	if errors.As(err, &ue) {
+		fmt.Fprintf(a.stderr, "todo %s: %s\n", cmd.name, ue.msg)
+		fs.Usage()
+		return 2
+	}
+	if err.Error() != "" {
+		fmt.Fprintf(a.stderr, "todo %s: %s\n", cmd.name, describeError(err))
+	}
+	return 1
+}
+
+func (a *app) newFlagSet(cmd command) *flag.FlagSet {
+	fs := flag.NewFlagSet("todo "+cmd.name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	a.output, a.server = "table", ""
+	fs.StringVar(&a.output, "output", "table", "output format: table, json or plain")
+	fs.StringVar(&a.output, "o", "table", "shorthand for --output")
+	fs.StringVar(&a.server, "server", "", "server URL (default $TODO_SERVER or the saved server)")
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: todo %s [flags] %s\n\n%s\n\nFlags:\n", cmd.name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func (a *app) usage() {
+	fmt.Fprint(a.stderr, "Usage: todo <command> [flags] [args]\n\nCommands:\n")
+	for _, cmd := range commands() {
+		if !cmd.hidden {
+			fmt.Fprintf(a.stderr, "  %-11s %s\n", cmd.name, cmd.summary)
+		}
+	}
+	fmt.Fprint(a.stderr, "\nRun 'todo help <command>' for the flags of a command.\n")
+}
+
+// parseArgs 允许标志出现在参数之后，例如 add "title" --due tomorrow；"--" 之后的内容都是参数
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var tail []string
+	for i, arg := range args {
+		if arg == "--" {
+			args, tail = args[:i], args[i+1:]
+			break
+		}
+	}
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	return append(positional, tail...), nil
+}
+
+// client 使用保存的 token 创建 API 客户端
+func (a *app) client() (*client.Client, error) {
+	creds, err := loadCredentials()
+	if err != nil {
+		return nil, err
+	}
+	token := creds.Token
+	if env := os.Getenv("TODO_TOKEN"); env != "" {
+		token = env
+	}
+	if token == "" {
+		return nil, errors.New("not logged in, run 'todo login' first")
+	}
+	return a.newClient(a.serverURL(creds), client.WithToken(token))
+}
+
+func (a *app) newClient(server string, opts ...client.Option) (*client.Client, error) {
+	opts = append([]client.Option{client.WithUserAgent("todo-cli"), client.WithLocale(localeFromEnv())}, opts...)
+	return client.New(strings.TrimSuffix(server, "/")+apiPrefix, opts...)
+}
+
+// serverURL 按 --server、$TODO_SERVER、保存的地址、默认地址的顺序选择服务端地址
+func (a *app) serverURL(creds *credentials) string {
+	switch {
+	case a.server != "":
+		return a.server
+	case os.Getenv("TODO_SERVER") != "":
+		return os.Getenv("TODO_SERVER")
+	case creds != nil && creds.Server != "":
+		return creds.Server
+	}
+	return defaultServer
+}
+
+// describeError 为常见的 API 错误加上提示
+func describeError(err error) string {
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		return err.Error()
+	}
+	msg := apiErr.Detail
+	if msg == "" {
+		msg = apiErr.Title
+	}
+	if msg == "" {
+		msg = fmt.Sprintf("request failed with status %d", apiErr.StatusCode)
+	}
+	for _, f := range apiErr.Fields {
+		msg += fmt.Sprintf("\n  %s: %s", strings.TrimPrefix(f.Pointer, "/"), f.Detail)
+	}
+	switch {
+	case errors.Is(err, client.CodeAuthInvalidToken), errors.Is(err, client.CodeAuthMissingHeader):
+		msg += "\nRun 'todo login' to log in again."
+	case errors.Is(err, client.CodeTaskVersionConflict):
+		msg += "\nThe task was changed by someone else, run the command again."
+	}
+	return msg
+}
+
+// localeFromEnv 把 LANG 等环境变量转换为 Accept-Language，例如 zh_CN.UTF-8 转为 zh-CN
+func localeFromEnv() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		if i := strings.IndexAny(value, ".@"); i >= 0 {
+			value = value[:i]
+		}
+		if value == "C" || value == "POSIX" {
+			return ""
+		}
+		return strings.ReplaceAll(value, "_", "-")
+	}
+	return ""
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/config"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/password"
+	"github.com/HywlEch/Todo_list/internal/router"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testNow = time.Date(2025, 3, 10, 15, 30, 0, 0, time.Local)
+
+// newTestEnv 用真实的路由和 MockStore 启动服务，登录信息保存在临时目录中
+func newTestEnv(t *testing.T) (*httptest.Server, *store.MockStore) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("TODO_CONFIG_DIR", t.TempDir())
+	t.Setenv("TODO_SERVER", "")
+	t.Setenv("TODO_TOKEN", "")
+	t.Setenv("LC_ALL", "en_US.UTF-8")
+
+	ms := store.NewMockStore()
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct-horse-1"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &models.User{ID: 1, Username: "alice", PasswordHash: string(hash), Timezone: "UTC", DefaultSort: "created_desc"}
+	ms.On("GetUserByUsername", mock.Anything, "alice").Return(user, nil).Maybe()
+	ms.On("GetUserByID", mock.Anything, 1).Return(user, nil).Maybe()
+
+	policy, err := password.NewPolicy(config.PasswordConfig{})
+	require.NoError(t, err)
+	engine, err := router.New(router.Deps{
+		Store:          ms,
+		JWT:            config.JWTConfig{Secret: "test-secret", ExpiresInHours: 1},
+		PasswordPolicy: policy,
+	})
+	require.NoError(t, err)
+	srv := httptest.NewServer(engine)
+	t.Cleanup(srv.Close)
+	return srv, ms
+}
+
+// runTodo 执行命令，返回退出码和标准输出
+func runTodo(t *testing.T, stdin string, args ...string) (int, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	a := &app{
+		stdin:    bufio.NewReader(strings.NewReader(stdin)),
+		stdout:   &stdout,
+		stderr:   &stderr,
+		now:      func() time.Time { return testNow },
+		editFile: func(string) error { t.Fatal("unexpected editor"); return nil },
+	}
+	a.readPassword = func(string) (string, error) { return readLine(a.stdin) }
+	code := a.run(context.Background(), args)
+	if code != 0 {
+		t.Logf("todo %s: %s", strings.Join(args, " "), stderr.String())
+	}
+	return code, stdout.String()
+}
+
+func TestTodo_LoginAddList(t *testing.T) {
+	srv, ms := newTestEnv(t)
+
+	code, _ := runTodo(t, "alice\ncorrect-horse-1\n", "login", "--server", srv.URL)
+	require.Equal(t, 0, code)
+	creds, err := loadCredentials()
+	require.NoError(t, err)
+	assert.Equal(t, srv.URL, creds.Server)
+	assert.NotEmpty(t, creds.Token)
+	path, _ := credentialsPath()
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	//标志可以写在标题之后，--due 使用过滤表达式中的时间写法
+	tomorrow := time.Date(2025, 3, 11, 0, 0, 0, 0, time.Local)
+	ms.On("CreateTask", mock.Anything, mock.MatchedBy(func(task *models.Task) bool {
+		return task.Title == "write report" && task.DueAt != nil && task.DueAt.Equal(tomorrow) &&
+			len(task.Tags) == 1 && task.Tags[0] == "work" && task.Priority == 3
+	})).Run(func(args mock.Arguments) {
+		task := args.Get(1).(*models.Task)
+		task.ID, task.Version = 7, 1
+	}).Return(nil).Once()
+	code, out := runTodo(t, "", "add", "write report", "--due", "tomorrow", "--tag", "work", "-p", "high", "-o", "json")
+	require.Equal(t, 0, code)
+	var created map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &created))
+	assert.Equal(t, float64(7), created["id"])
+
+	ms.On("QueryTasks", mock.Anything, 1, mock.MatchedBy(func(q store.TaskQuery) bool { return q.Filter != nil })).
+		Return([]models.Task{{ID: 7, UserID: 1, Title: "write report", Tags: []string{"work"}, DueAt: &tomorrow, Priority: 3}}, nil).Once()
+	code, out = runTodo(t, "", "ls", "--filter", "tag:work")
+	require.Equal(t, 0, code)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, []string{"ID", "DONE", "PRIORITY", "DUE", "TAGS", "TITLE"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"7", "[", "]", "high", "2025-03-11", "work", "write", "report"}, strings.Fields(lines[1]))
+
+	code, _ = runTodo(t, "", "show", "abc")
+	assert.Equal(t, 2, code)
+}
+
+func TestTodo_Edit(t *testing.T) {
+	srv, ms := newTestEnv(t)
+	require.NoError(t, saveCredentials(&credentials{Server: srv.URL, Username: "alice", Token: login(t, srv.URL)}))
+
+	ms.On("GetTaskByID", mock.Anything, 7, 1).Return(&models.Task{ID: 7, UserID: 1, Title: "write report", Content: "draft", Version: 3}, nil)
+	ms.On("PatchTask", mock.Anything, mock.MatchedBy(func(task *models.Task) bool {
+		return task.Content == "final version" && task.Version == 3
+	}), []string{"content"}).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Task).Version = 4
+	}).Return(nil).Once()
+
+	var stdout, stderr bytes.Buffer
+	a := &app{stdout: &stdout, stderr: &stderr, now: time.Now}
+	a.editFile = func(path string) error {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "draft", string(data))
+		assert.Equal(t, "task-7.md", filepath.Base(path))
+		//编辑器在末尾加的换行会被去掉
+		return os.WriteFile(path, []byte("final version\n"), 0o600)
+	}
+	require.Equal(t, 0, a.run(context.Background(), []string{"edit", "7", "-o", "plain"}), stderr.String())
+	assert.Equal(t, "write report\n\nfinal version\n", stdout.String())
+	ms.AssertExpectations(t)
+}
+
+func login(t *testing.T, server string) string {
+	t.Helper()
+	a := &app{}
+	c, err := a.newClient(server)
+	require.NoError(t, err)
+	token, err := c.Login(context.Background(), "alice", "correct-horse-1")
+	require.NoError(t, err)
+	return token
+}
+
+func TestParseArgs(t *testing.T) {
+	a := &app{stderr: &bytes.Buffer{}}
+	cmd, _ := findCommand("add")
+	fs := a.newFlagSet(cmd)
+	cmd.setup(fs)
+	args, err := parseArgs(fs, []string{"buy", "--tag", "home", "milk", "-o", "plain", "--", "--not-a-flag"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"buy", "milk", "--not-a-flag"}, args)
+	assert.Equal(t, "plain", a.output)
+	assert.Equal(t, "home", fs.Lookup("tag").Value.String())
+}
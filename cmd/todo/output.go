@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HywlEch/Todo_list/pkg/client"
+)
+
+// 输出格式：table 给人看，json 与 API 的响应相同，plain 每行一个任务，方便 grep 和 cut
+func validOutput(format string) bool {
+	return format == "table" || format == "json" || format == "plain"
+}
+
+func (a *app) printTasks(tasks []client.Task) error {
+	switch a.output {
+	case "json":
+		if tasks == nil {
+			tasks = []client.Task{}
+		}
+		return writeJSON(a.stdout, tasks)
+	case "plain":
+		for _, t := range tasks {
+			fmt.Fprintf(a.stdout, "%d\t%s\t%s\n", t.ID, doneMark(t.Done), t.Title)
+		}
+		return nil
+	}
+	if len(tasks) == 0 {
+		fmt.Fprintln(a.stderr, "No tasks.")
+		return nil
+	}
+	w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDONE\tPRIORITY\tDUE\tTAGS\tTITLE")
+	for _, t := range tasks {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			t.ID, doneMark(t.Done), priorityName(t.Priority), formatTime(t.DueAt), strings.Join(t.Tags, ","), t.Title)
+	}
+	return w.Flush()
+}
+
+func (a *app) printTask(task *client.Task) error {
+	switch a.output {
+	case "json":
+		return writeJSON(a.stdout, task)
+	case "plain":
+		//第一行为标题，空一行之后为内容
+		fmt.Fprintln(a.stdout, task.Title)
+		if task.Content != "" {
+			fmt.Fprintf(a.stdout, "\n%s\n", strings.TrimRight(task.Content, "\n"))
+		}
+		return nil
+	}
+	w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	rows := [][2]string{
+		{"ID", fmt.Sprint(task.ID)},
+		{"Title", task.Title},
+		{"Done", doneMark(task.Done)},
+		{"Status", task.Status},
+		{"Priority", priorityName(task.Priority)},
+		{"Due", formatTime(task.DueAt)},
+		{"Tags", strings.Join(task.Tags, ", ")},
+		{"Blocked", fmt.Sprint(task.IsBlocked)},
+		{"Version", fmt.Sprint(task.Version)},
+		{"Created", formatTime(&task.CreatedAt)},
+		{"Updated", formatTime(&task.UpdatedAt)},
+	}
+	if task.CompletedAt != nil {
+		rows = append(rows, [2]string{"Completed", formatTime(task.CompletedAt)})
+	}
+	for _, row := range rows {
+		fmt.Fprintf(w, "%s:\t%s\n", row[0], row[1])
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if task.Content != "" {
+		fmt.Fprintf(a.stdout, "\n%s\n", strings.TrimRight(task.Content, "\n"))
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func doneMark(done bool) string {
+	if done {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+func priorityName(p int) string {
+	if p >= 0 && p < len(priorityNames) {
+		return priorityNames[p]
+	}
+	return fmt.Sprint(p)
+}
+
+// formatTime 按本地时区显示，零点只显示日期
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	local := t.Local()
+	if local.Hour() == 0 && local.Minute() == 0 && local.Second() == 0 {
+		return local.Format("2006-01-02")
+	}
+	return local.Format("2006-01-02 15:04")
+}
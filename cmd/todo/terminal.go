@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// readLine 读取一行输入，去掉行尾的换行
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readPassword 读取密码，标准输入是终端时用 stty 关闭回显（Windows 上仍会回显）
+func readPassword(stdin *bufio.Reader, prompt io.Writer, label string) (string, error) {
+	fmt.Fprint(prompt, label)
+	if isTerminal(os.Stdin) && stty("-echo") == nil {
+		defer func() {
+			stty("echo")
+			fmt.Fprintln(prompt)
+		}()
+	}
+	return readLine(stdin)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func stty(arg string) error {
+	if runtime.GOOS == "windows" {
+		return fmt.Errorf("stty is not available on windows")
+	}
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
+
+// runEditor 用 $VISUAL 或 $EDITOR 打开文件，都没有设置时使用 vi（Windows 上为 notepad）
+// 编辑器命令可以带参数，例如 EDITOR="code --wait"
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run editor %q: %w", editor, err)
+	}
+	return nil
+}
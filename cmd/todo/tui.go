@@ -0,0 +1,535 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/HywlEch/Todo_list/pkg/client"
+	"golang.org/x/text/width"
+)
+
+// tuiMode 当前的输入模式
+type tuiMode int
+
+const (
+	modeList    tuiMode = iota
+	modePrompt          //在底部输入一行文字
+	modeConfirm         //等待 y/n
+)
+
+const tuiHelp = "j/k move  space done  e edit  t title  a add  J/K reorder  d delete  / filter  r refresh  q quit"
+
+// tui 全屏界面的状态，不依赖终端，按键由 handleKey 处理，画面由 render 生成
+type tui struct {
+	c        *client.Client
+	timeout  time.Duration //每次 API 调用的超时时间
+	editFile func(path string) error
+	//suspend 暂时退出全屏界面执行 fn，用于打开编辑器
+	suspend func(fn func() error) error
+
+	tasks  []client.Task
+	cursor int
+	offset int //列表第一行显示的任务序号
+	filter string
+	sort   string
+	status string
+	isErr  bool
+	loaded time.Time
+
+	mode     tuiMode
+	label    string
+	input    []rune
+	onSubmit func(ctx context.Context, value string)
+
+	width, height int
+}
+
+func setupTUI(fs *flag.FlagSet) runFunc {
+	expr := fs.String("filter", "done:false", `initial filter expression, empty shows all tasks`)
+	fs.StringVar(expr, "f", "done:false", "shorthand for --filter")
+	sortBy := fs.String("sort", "manual", "sort order; J/K reorder only makes sense with manual")
+	interval := fs.Duration("refresh", 5*time.Second, "reload the list this often, 0 disables live refresh")
+	return func(ctx context.Context, a *app, args []string) error {
+		if len(args) > 0 {
+			return usagef("unexpected arguments %q", args)
+		}
+		c, err := a.client()
+		if err != nil {
+			return err
+		}
+		t := &tui{c: c, timeout: 10 * time.Second, editFile: a.editFile, filter: *expr, sort: *sortBy}
+		return runTUI(ctx, t, *interval)
+	}
+}
+
+// runTUI 进入全屏界面，直到按 q 或 ctx 被取消
+func runTUI(ctx context.Context, t *tui, interval time.Duration) error {
+	term, err := openTerminal()
+	if err != nil {
+		return err
+	}
+	defer term.close()
+	t.suspend = term.suspend
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	resize := resizeSignal()
+	defer stopResizeSignal(resize)
+
+	t.width, t.height = term.size()
+	t.reload(ctx)
+	for {
+		term.draw(t.render())
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-resize:
+			t.width, t.height = term.size()
+		case <-tick:
+			t.width, t.height = term.size()
+			//输入过程中不刷新，避免光标下的任务变化
+			if t.mode == modeList {
+				t.reload(ctx)
+			}
+		case k, ok := <-term.keys():
+			if !ok {
+				return term.err()
+			}
+			if t.handleKey(ctx, k) {
+				return nil
+			}
+		}
+	}
+}
+
+// call 执行一次 API 调用，出错时在状态栏显示
+func (t *tui) call(ctx context.Context, fn func(ctx context.Context) error) bool {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	if err := fn(ctx); err != nil {
+		t.setError(err)
+		return false
+	}
+	return true
+}
+
+func (t *tui) setStatus(format string, args ...any) {
+	t.status, t.isErr = fmt.Sprintf(format, args...), false
+}
+
+func (t *tui) setError(err error) {
+	t.status, t.isErr = strings.ReplaceAll(describeError(err), "\n", " "), true
+}
+
+// reload 重新读取任务列表，尽量保持选中同一个任务
+func (t *tui) reload(ctx context.Context) {
+	selected := 0
+	if task := t.selected(); task != nil {
+		selected = task.ID
+	}
+	var tasks []client.Task
+	ok := t.call(ctx, func(ctx context.Context) (err error) {
+		tasks, err = t.c.ListTasks(ctx, &client.ListTasksOptions{Filter: t.filter, Sort: t.sort})
+		return err
+	})
+	if !ok {
+		return
+	}
+	t.tasks, t.loaded = tasks, time.Now()
+	t.cursor = min(t.cursor, max(len(tasks)-1, 0))
+	for i, task := range tasks {
+		if task.ID == selected {
+			t.cursor = i
+			break
+		}
+	}
+}
+
+func (t *tui) selected() *client.Task {
+	if t.cursor < 0 || t.cursor >= len(t.tasks) {
+		return nil
+	}
+	return &t.tasks[t.cursor]
+}
+
+// replace 用服务端返回的任务替换列表中的旧内容
+func (t *tui) replace(task *client.Task) {
+	for i := range t.tasks {
+		if t.tasks[i].ID == task.ID {
+			t.tasks[i] = *task
+			return
+		}
+	}
+}
+
+// prompt 在底部输入一行文字，回车后调用 onSubmit
+func (t *tui) prompt(label, initial string, onSubmit func(ctx context.Context, value string)) {
+	t.mode, t.label, t.input, t.onSubmit = modePrompt, label, []rune(initial), onSubmit
+}
+
+func (t *tui) confirm(label string, onYes func(ctx context.Context, value string)) {
+	t.mode, t.label, t.input, t.onSubmit = modeConfirm, label, nil, onYes
+}
+
+// handleKey 处理一个按键，返回 true 表示退出
+func (t *tui) handleKey(ctx context.Context, k key) bool {
+	switch t.mode {
+	case modePrompt:
+		t.handlePromptKey(ctx, k)
+		return false
+	case modeConfirm:
+		t.mode = modeList
+		if k.r == 'y' || k.r == 'Y' {
+			t.onSubmit(ctx, "")
+		} else {
+			t.setStatus("Cancelled")
+		}
+		return false
+	}
+
+	task := t.selected()
+	switch {
+	case k.name == keyCtrlC || k.r == 'q':
+		return true
+	case k.name == keyDown || k.r == 'j':
+		t.cursor = min(t.cursor+1, max(len(t.tasks)-1, 0))
+	case k.name == keyUp || k.r == 'k':
+		t.cursor = max(t.cursor-1, 0)
+	case k.name == keyPageDown:
+		t.cursor = min(t.cursor+t.listHeight(), max(len(t.tasks)-1, 0))
+	case k.name == keyPageUp:
+		t.cursor = max(t.cursor-t.listHeight(), 0)
+	case k.name == keyHome || k.r == 'g':
+		t.cursor = 0
+	case k.name == keyEnd || k.r == 'G':
+		t.cursor = max(len(t.tasks)-1, 0)
+	case k.r == 'r':
+		t.reload(ctx)
+		if !t.isErr {
+			t.setStatus("Refreshed")
+		}
+	case k.r == '/':
+		t.prompt("Filter: ", t.filter, func(ctx context.Context, value string) {
+			old := t.filter
+			t.filter = strings.TrimSpace(value)
+			t.reload(ctx)
+			if t.isErr {
+				t.filter = old
+			}
+		})
+	case k.r == 'a':
+		t.prompt("New task: ", "", func(ctx context.Context, value string) {
+			if strings.TrimSpace(value) == "" {
+				return
+			}
+			var created *client.Task
+			if t.call(ctx, func(ctx context.Context) (err error) {
+				created, err = t.c.CreateTask(ctx, client.TaskInput{Title: strings.TrimSpace(value)})
+				return err
+			}) {
+				t.reload(ctx)
+				t.selectID(created.ID)
+				t.setStatus("Created task %d", created.ID)
+			}
+		})
+	case task == nil:
+		//以下按键都需要选中的任务
+	case k.r == ' ' || k.r == 'x':
+		t.toggle(ctx, task)
+	case k.r == 't':
+		id, version := task.ID, task.Version
+		t.prompt("Title: ", task.Title, func(ctx context.Context, value string) {
+			t.patch(ctx, id, map[string]any{"title": value}, version)
+		})
+	case k.r == 'e':
+		t.editContent(ctx, task)
+	case k.r == 'J':
+		t.move(ctx, task, 1)
+	case k.r == 'K':
+		t.move(ctx, task, -1)
+	case k.r == 'd':
+		id, version, title := task.ID, task.Version, task.Title
+		t.confirm(fmt.Sprintf("Move %q to the trash? [y/N]", title), func(ctx context.Context, _ string) {
+			if t.call(ctx, func(ctx context.Context) error { return t.c.DeleteTask(ctx, id, version) }) {
+				t.reload(ctx)
+				t.setStatus("Moved task %d to the trash", id)
+			}
+		})
+	}
+	return false
+}
+
+func (t *tui) handlePromptKey(ctx context.Context, k key) {
+	switch {
+	case k.name == keyEscape || k.name == keyCtrlC:
+		t.mode = modeList
+		t.setStatus("Cancelled")
+	case k.name == keyEnter:
+		t.mode = modeList
+		t.status = ""
+		t.onSubmit(ctx, string(t.input))
+	case k.name == keyBackspace:
+		if len(t.input) > 0 {
+			t.input = t.input[:len(t.input)-1]
+		}
+	case k.r != 0 && unicode.IsPrint(k.r):
+		t.input = append(t.input, k.r)
+	}
+}
+
+func (t *tui) selectID(id int) {
+	for i, task := range t.tasks {
+		if task.ID == id {
+			t.cursor = i
+			return
+		}
+	}
+}
+
+func (t *tui) toggle(ctx context.Context, task *client.Task) {
+	id, done, version := task.ID, !task.Done, task.Version
+	var updated *client.Task
+	if t.call(ctx, func(ctx context.Context) (err error) {
+		updated, err = t.c.CompleteTask(ctx, id, done, version)
+		return err
+	}) {
+		//不立即重新读取，完成的任务在下次刷新时才从 done:false 的列表中消失，误操作时可以再按一次
+		t.replace(updated)
+		if done {
+			t.setStatus("Completed task %d", id)
+		} else {
+			t.setStatus("Reopened task %d", id)
+		}
+	}
+}
+
+// patch 修改任务，version 为读取时的版本号，期间被别人修改时显示冲突
+func (t *tui) patch(ctx context.Context, id int, fields map[string]any, version int) {
+	var updated *client.Task
+	if t.call(ctx, func(ctx context.Context) (err error) {
+		updated, err = t.c.PatchTask(ctx, id, fields, version)
+		return err
+	}) {
+		t.replace(updated)
+		t.setStatus("Updated task %d", id)
+	}
+}
+
+func (t *tui) editContent(ctx context.Context, task *client.Task) {
+	current := *task
+	a := &app{editFile: t.editFile}
+	var content string
+	err := t.suspend(func() (err error) {
+		content, err = a.editContent(&current)
+		return err
+	})
+	switch {
+	case err != nil:
+		t.setError(err)
+	case content == current.Content:
+		t.setStatus("Content unchanged")
+	default:
+		t.patch(ctx, current.ID, map[string]any{"content": content}, current.Version)
+	}
+}
+
+// move 把任务与上一个（delta=-1）或下一个（delta=1）任务交换位置
+func (t *tui) move(ctx context.Context, task *client.Task, delta int) {
+	other := t.cursor + delta
+	if other < 0 || other >= len(t.tasks) {
+		return
+	}
+	input := client.MoveInput{AfterID: t.tasks[other].ID}
+	if delta < 0 {
+		input = client.MoveInput{BeforeID: t.tasks[other].ID}
+	}
+	id, version := task.ID, task.Version
+	if t.call(ctx, func(ctx context.Context) error {
+		_, err := t.c.MoveTask(ctx, id, input, version)
+		return err
+	}) {
+		t.reload(ctx)
+		t.selectID(id)
+		if t.sort != "manual" {
+			t.setStatus("Moved task %d; the list is sorted by %s, use --sort manual to see the order", id, t.sort)
+		}
+	}
+}
+
+// listHeight 列表区域的行数：去掉标题行、表头和底部两行
+func (t *tui) listHeight() int {
+	return max(t.height-4, 1)
+}
+
+// render 生成整个画面，每个元素为一行，宽度不超过 t.width
+func (t *tui) render() []string {
+	w, h := max(t.width, 20), max(t.height, 5)
+	lines := make([]string, 0, h)
+
+	header := fmt.Sprintf(" todo — %d tasks", len(t.tasks))
+	if t.filter != "" {
+		header += "  filter: " + t.filter
+	}
+	if !t.loaded.IsZero() {
+		header += "  updated " + t.loaded.Format("15:04:05")
+	}
+	lines = append(lines, sgrReverse+fit(header, w)+sgrReset)
+
+	listW := w * 45 / 100
+	detailW := w - listW - 3
+	list := t.renderList(listW, t.listHeight()+1)
+	detail := t.renderDetail(detailW, t.listHeight()+1)
+	for i := 0; i < t.listHeight()+1; i++ {
+		lines = append(lines, list[i]+" "+sgrDim+"│"+sgrReset+" "+detail[i])
+	}
+
+	switch t.mode {
+	case modePrompt:
+		lines = append(lines, fit(t.label+string(t.input)+"▏", w))
+	case modeConfirm:
+		lines = append(lines, sgrBold+fit(t.label, w)+sgrReset)
+	default:
+		if t.isErr {
+			lines = append(lines, sgrRed+fit(t.status, w)+sgrReset)
+		} else {
+			lines = append(lines, fit(t.status, w))
+		}
+	}
+	lines = append(lines, sgrDim+fit(tuiHelp, w)+sgrReset)
+	return lines
+}
+
+func (t *tui) renderList(w, h int) []string {
+	lines := make([]string, 0, h)
+	lines = append(lines, sgrBold+fit(fmt.Sprintf("%5s %-3s %s", "ID", "", "TITLE"), w)+sgrReset)
+	rows := h - 1
+	//保持光标在可见范围内
+	if t.cursor < t.offset {
+		t.offset = t.cursor
+	}
+	if t.cursor >= t.offset+rows {
+		t.offset = t.cursor - rows + 1
+	}
+	for i := t.offset; i < t.offset+rows; i++ {
+		if i >= len(t.tasks) {
+			if i == 0 {
+				lines = append(lines, fit("  No tasks. Press a to add one.", w))
+				continue
+			}
+			lines = append(lines, fit("", w))
+			continue
+		}
+		task := t.tasks[i]
+		row := fit(fmt.Sprintf("%5d %s %s", task.ID, doneMark(task.Done), task.Title), w)
+		switch {
+		case i == t.cursor:
+			row = sgrReverse + row + sgrReset
+		case task.Done:
+			row = sgrDim + row + sgrReset
+		}
+		lines = append(lines, row)
+	}
+	return lines
+}
+
+func (t *tui) renderDetail(w, h int) []string {
+	var lines []string
+	if task := t.selected(); task != nil {
+		lines = append(lines, sgrBold+fit(task.Title, w)+sgrReset)
+		fields := [][2]string{
+			{"Done", doneMark(task.Done)},
+			{"Status", task.Status},
+			{"Priority", priorityName(task.Priority)},
+			{"Due", formatTime(task.DueAt)},
+			{"Tags", strings.Join(task.Tags, ", ")},
+			{"Updated", formatTime(&task.UpdatedAt)},
+		}
+		if task.IsBlocked {
+			fields = append(fields, [2]string{"Blocked", "yes"})
+		}
+		for _, f := range fields {
+			lines = append(lines, fit(fmt.Sprintf("%-9s %s", f[0]+":", f[1]), w))
+		}
+		lines = append(lines, fit("", w))
+		for _, l := range wrap(task.Content, w) {
+			lines = append(lines, fit(l, w))
+		}
+	}
+	for len(lines) < h {
+		lines = append(lines, fit("", w))
+	}
+	return lines[:h]
+}
+
+// 终端的显示属性
+const (
+	sgrReset   = "\x1b[0m"
+	sgrBold    = "\x1b[1m"
+	sgrDim     = "\x1b[2m"
+	sgrReverse = "\x1b[7m"
+	sgrRed     = "\x1b[31m"
+)
+
+// runeWidth 字符在终端中占的列数，中日韩等全角字符占两列
+func runeWidth(r rune) int {
+	if r < 0x20 || r == 0x7f {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// fit 截断或用空格补齐到正好 w 列，控制字符替换为空格
+func fit(s string, w int) string {
+	var b strings.Builder
+	cols := 0
+	for _, r := range s {
+		if r == '\t' || r == '\n' || r == '\r' {
+			r = ' '
+		}
+		rw := runeWidth(r)
+		if rw == 0 {
+			continue
+		}
+		if cols+rw > w {
+			break
+		}
+		b.WriteRune(r)
+		cols += rw
+	}
+	b.WriteString(strings.Repeat(" ", max(w-cols, 0)))
+	return b.String()
+}
+
+// wrap 按列宽折行，保留原有的换行
+func wrap(s string, w int) []string {
+	var lines []string
+	for _, para := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		line, cols := []rune{}, 0
+		for _, r := range para {
+			rw := max(runeWidth(r), 1)
+			if cols+rw > w && len(line) > 0 {
+				lines = append(lines, string(line))
+				line, cols = line[:0], 0
+			}
+			line = append(line, r)
+			cols += rw
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+var errNoTerminal = errors.New("the interactive UI needs a terminal (/dev/tty)")
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// resizeSignal 没有 SIGWINCH 的系统上在定时刷新时更新窗口大小
+func resizeSignal() chan os.Signal { return nil }
+
+func stopResizeSignal(chan os.Signal) {}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// 按键名称，普通字符的 name 为空，r 为字符本身
+const (
+	keyUp = iota + 1
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyEnter
+	keyEscape
+	keyBackspace
+	keyCtrlC
+)
+
+type key struct {
+	name int
+	r    rune
+}
+
+// 方向键等发送的转义序列
+var escapeKeys = map[string]int{
+	"\x1b[A": keyUp, "\x1bOA": keyUp,
+	"\x1b[B": keyDown, "\x1bOB": keyDown,
+	"\x1b[5~": keyPageUp, "\x1b[6~": keyPageDown,
+	"\x1b[H": keyHome, "\x1b[1~": keyHome, "\x1bOH": keyHome,
+	"\x1b[F": keyEnd, "\x1b[4~": keyEnd, "\x1bOF": keyEnd,
+}
+
+// parseKeys 把一次读到的字节解析为按键，不认识的转义序列会被忽略
+func parseKeys(b []byte) []key {
+	var keys []key
+	for len(b) > 0 {
+		switch b[0] {
+		case '\x1b':
+			//ESC [ 或 ESC O 开头的序列以字母或 ~ 结束，其他情况是单独按了 Esc
+			if len(b) == 1 || (b[1] != '[' && b[1] != 'O') {
+				keys = append(keys, key{name: keyEscape})
+				break
+			}
+			end := 2
+			for end < len(b) && !isFinalByte(b[end]) {
+				end++
+			}
+			end = min(end+1, len(b))
+			if name, ok := escapeKeys[string(b[:end])]; ok {
+				keys = append(keys, key{name: name})
+			}
+			b = b[end:]
+			continue
+		case '\r', '\n':
+			keys = append(keys, key{name: keyEnter})
+		case 0x7f, 0x08:
+			keys = append(keys, key{name: keyBackspace})
+		case 0x03:
+			keys = append(keys, key{name: keyCtrlC})
+		default:
+			r, size := utf8.DecodeRune(b)
+			if r >= 0x20 {
+				keys = append(keys, key{r: r})
+			}
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+func isFinalByte(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c == '~'
+}
+
+// terminal 通过 /dev/tty 读写终端，进入时切换到备用屏幕并关闭行缓冲和回显
+// 打开编辑器前关闭 tty，读按键的 goroutine 随之退出，不会抢走编辑器的输入
+type terminal struct {
+	tty   *os.File
+	saved string //stty -g 保存的终端设置
+	ch    chan key
+
+	mu      sync.Mutex
+	readErr error
+	last    []string //上一次画的内容，只重画变化的行
+}
+
+func openTerminal() (*terminal, error) {
+	t := &terminal{}
+	if err := t.enter(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *terminal) enter() error {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return errNoTerminal
+	}
+	saved, err := t.stty(tty, "-g")
+	if err != nil {
+		tty.Close()
+		return fmt.Errorf("%w: %v", errNoTerminal, err)
+	}
+	if _, err := t.stty(tty, "raw", "-echo"); err != nil {
+		tty.Close()
+		return fmt.Errorf("%w: %v", errNoTerminal, err)
+	}
+	t.mu.Lock()
+	t.tty, t.saved, t.last, t.readErr = tty, strings.TrimSpace(saved), nil, nil
+	t.mu.Unlock()
+	//备用屏幕，隐藏光标
+	tty.WriteString("\x1b[?1049h\x1b[?25l\x1b[2J")
+
+	ch := make(chan key, 64)
+	t.ch = ch
+	go func() {
+		defer close(ch)
+		buf := make([]byte, 256)
+		for {
+			n, err := tty.Read(buf)
+			for _, k := range parseKeys(buf[:n]) {
+				ch <- k
+			}
+			if err != nil {
+				//suspend 关闭的旧 tty 的错误不用记录
+				t.mu.Lock()
+				if t.tty == tty {
+					t.readErr = err
+				}
+				t.mu.Unlock()
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// leave 恢复终端设置并回到原来的屏幕
+func (t *terminal) leave() {
+	if t.tty == nil {
+		return
+	}
+	t.tty.WriteString("\x1b[0m\x1b[?25h\x1b[?1049l")
+	t.stty(t.tty, t.saved)
+	t.mu.Lock()
+	tty := t.tty
+	t.tty = nil
+	t.mu.Unlock()
+	//关闭后读按键的 goroutine 随之退出
+	tty.Close()
+}
+
+func (t *terminal) close() { t.leave() }
+
+// suspend 暂时恢复终端执行 fn，例如打开编辑器，返回后重新进入全屏界面
+func (t *terminal) suspend(fn func() error) error {
+	t.leave()
+	err := fn()
+	if enterErr := t.enter(); enterErr != nil {
+		return enterErr
+	}
+	return err
+}
+
+func (t *terminal) keys() <-chan key { return t.ch }
+
+// err 返回读按键时的错误
+func (t *terminal) err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.readErr
+}
+
+func (t *terminal) stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := cmd.Run()
+	return out.String(), err
+}
+
+// size 返回终端的宽和高，读取失败时使用 80x24
+func (t *terminal) size() (int, int) {
+	out, err := t.stty(t.tty, "size")
+	if err == nil {
+		if fields := strings.Fields(out); len(fields) == 2 {
+			rows, err1 := strconv.Atoi(fields[0])
+			cols, err2 := strconv.Atoi(fields[1])
+			if err1 == nil && err2 == nil && rows > 0 && cols > 0 {
+				return cols, rows
+			}
+		}
+	}
+	return 80, 24
+}
+
+// draw 把画面写到终端，只重画和上次不同的行
+func (t *terminal) draw(lines []string) {
+	var b strings.Builder
+	if len(lines) != len(t.last) {
+		b.WriteString("\x1b[2J")
+		t.last = nil
+	}
+	for i, line := range lines {
+		if i < len(t.last) && t.last[i] == line {
+			continue
+		}
+		fmt.Fprintf(&b, "\x1b[%d;1H%s\x1b[K", i+1, line)
+	}
+	t.last = lines
+	t.tty.WriteString(b.String())
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeys(t *testing.T) {
+	keys := parseKeys([]byte("j\x1b[A\x1b[6~中\r\x7f\x1b\x03"))
+	assert.Equal(t, []key{
+		{r: 'j'}, {name: keyUp}, {name: keyPageDown}, {r: '中'},
+		{name: keyEnter}, {name: keyBackspace}, {name: keyEscape}, {name: keyCtrlC},
+	}, keys)
+	//不认识的序列被忽略
+	assert.Equal(t, []key{{r: 'q'}}, parseKeys([]byte("\x1b[1;5Cq")))
+}
+
+func TestFit(t *testing.T) {
+	assert.Equal(t, "写周报 ", fit("写周报", 7))
+	assert.Equal(t, "写周 ", fit("写周报", 5), "放不下的全角字符用空格补齐")
+	assert.Equal(t, "a b  ", fit("a\tb", 5))
+	assert.Equal(t, []string{"abc", "de", "", "f"}, wrap("abcde\n\nf", 3))
+}
+
+func TestTUI_ToggleAndMove(t *testing.T) {
+	srv, ms := newTestEnv(t)
+	require.NoError(t, saveCredentials(&credentials{Server: srv.URL, Username: "alice", Token: login(t, srv.URL)}))
+	c, err := (&app{}).client()
+	require.NoError(t, err)
+
+	tasks := []models.Task{
+		{ID: 1, UserID: 1, Title: "first", Version: 1},
+		{ID: 2, UserID: 1, Title: "second", Content: "details here", Version: 1},
+	}
+	ms.On("QueryTasks", mock.Anything, 1, mock.MatchedBy(func(q store.TaskQuery) bool {
+		return q.Sort == "manual" && q.Filter != nil
+	})).Return(tasks, nil)
+
+	ui := &tui{c: c, timeout: 5e9, filter: "done:false", sort: "manual", width: 80, height: 12}
+	ctx := context.Background()
+	ui.reload(ctx)
+	require.Len(t, ui.tasks, 2)
+
+	ui.handleKey(ctx, key{name: keyDown})
+	screen := strings.Join(ui.render(), "\n")
+	assert.Contains(t, screen, "second")
+	assert.Contains(t, screen, "details here", "详情栏显示选中任务的内容")
+	assert.Len(t, ui.render(), 12)
+
+	//空格切换完成状态，带上版本号
+	ms.On("GetTaskByID", mock.Anything, 2, 1).Return(&tasks[1], nil).Once()
+	ms.On("PatchTask", mock.Anything, mock.MatchedBy(func(task *models.Task) bool {
+		return task.ID == 2 && task.Done
+	}), []string{"done"}).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Task).Version = 2
+	}).Return(nil).Once()
+	ui.handleKey(ctx, key{r: ' '})
+	assert.False(t, ui.isErr, ui.status)
+	assert.True(t, ui.tasks[1].Done)
+	assert.Equal(t, 2, ui.tasks[1].Version)
+
+	//K 把任务移到上一个任务之前
+	ms.On("MoveTask", mock.Anything, 2, 1, 2, 1, 0).Return(&models.Task{ID: 2, UserID: 1, Version: 3}, nil).Once()
+	ui.handleKey(ctx, key{r: 'K'})
+	assert.False(t, ui.isErr, ui.status)
+	assert.Equal(t, 2, ui.selected().ID)
+
+	//在输入框中按 Esc 取消
+	ui.handleKey(ctx, key{r: 'a'})
+	ui.handleKey(ctx, key{r: 'x'})
+	assert.Equal(t, modePrompt, ui.mode)
+	ui.handleKey(ctx, key{name: keyEscape})
+	assert.Equal(t, modeList, ui.mode)
+
+	assert.True(t, ui.handleKey(ctx, key{r: 'q'}))
+	ms.AssertExpectations(t)
+}
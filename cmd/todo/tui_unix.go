@@ -0,0 +1,18 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// resizeSignal 终端窗口大小变化时收到通知
+func resizeSignal() chan os.Signal {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGWINCH)
+	return ch
+}
+
+func stopResizeSignal(ch chan os.Signal) { signal.Stop(ch) }
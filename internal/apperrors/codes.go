@@ -0,0 +1,124 @@
+package apperrors
+
+// 稳定的错误码，客户端可以据此判断错误类型，也是 i18n 消息目录中的 key
+// 错误码一经发布就不再修改，需要新的语义时添加新的错误码
+const (
+	//通用
+	CodeInternal             = "internal_error"
+	CodeRetryLater           = "retry_later"
+	CodeInvalidBody          = "request.invalid_body"
+	CodeReadBodyFailed       = "request.read_body_failed"
+	CodeInvalidID            = "request.invalid_id"
+	CodeUnsupportedMediaType = "request.unsupported_media_type"
+	CodeBodyTooLarge         = "request.body_too_large"
+	CodeNotFound             = "resource.not_found"
+	CodeInvalidPage          = "pagination.invalid_page"
+	CodeInvalidPageSize      = "pagination.invalid_page_size"
+	CodeInvalidIfMatch       = "precondition.invalid_if_match"
+	CodeRateLimited          = "rate_limit.exceeded"
+	CodeTimeout              = "request.timeout"
+	CodeAPISunset            = "api.sunset"
+
+	//认证和用户
+	CodeAuthMissingHeader      = "auth.missing_header"
+	CodeAuthInvalidHeader      = "auth.invalid_header"
+	CodeAuthInvalidToken       = "auth.invalid_token"
+	CodeAuthInvalidCredentials = "auth.invalid_credentials"
+	CodeAuthUnauthenticated    = "auth.unauthenticated"
+	CodeAuthCSRFInvalid        = "auth.csrf_invalid"
+	CodeUserExists             = "user.exists"
+	CodeWeakPassword           = "user.weak_password"
+	CodeInvalidTimezone        = "profile.invalid_timezone"
+	CodeUnsupportedLocale      = "profile.unsupported_locale"
+
+	//幂等
+	CodeIdempotencyKeyTooLong = "idempotency.key_too_long"
+	CodeIdempotencyInProgress = "idempotency.in_progress"
+	CodeIdempotencyKeyReused  = "idempotency.key_reused"
+
+	//任务
+	CodeTaskVersionConflict      = "task.version_conflict"
+	CodeTaskBusy                 = "task.busy"
+	CodeTaskInvalidMove          = "task.invalid_move"
+	CodeTaskMoveTargetRequired   = "task.move_target_required"
+	CodeTaskInvalidStatus        = "task.invalid_status"
+	CodeTaskTransitionNotAllowed = "task.transition_not_allowed"
+	CodeTaskInvalidRevision      = "task.invalid_revision"
+	CodeInvalidSort              = "task.invalid_sort"
+	CodeInvalidFilter            = "task.invalid_filter"
+	CodeSearchQueryRequired      = "task.search_query_required"
+	CodeCommentInvalidID         = "comment.invalid_id"
+
+	//过滤表达式的语法错误，作为 task.invalid_filter 的 detail
+	CodeFilterSyntax             = "filter.syntax_error"
+	CodeFilterEmpty              = "filter.empty"
+	CodeFilterTooLong            = "filter.too_long"
+	CodeFilterTooDeep            = "filter.too_deep"
+	CodeFilterTooManyConditions  = "filter.too_many_conditions"
+	CodeFilterUnexpectedParen    = "filter.unexpected_paren"
+	CodeFilterUnexpectedInput    = "filter.unexpected_input"
+	CodeFilterIncomplete         = "filter.incomplete"
+	CodeFilterMissingParen       = "filter.missing_paren"
+	CodeFilterExpectedField      = "filter.expected_field"
+	CodeFilterUnknownField       = "filter.unknown_field"
+	CodeFilterExpectedOperator   = "filter.expected_operator"
+	CodeFilterExpectedValue      = "filter.expected_value"
+	CodeFilterUnterminatedString = "filter.unterminated_string"
+	CodeFilterEqualityOnly       = "filter.equality_only"
+	CodeFilterInvalidBool        = "filter.invalid_bool"
+	CodeFilterInvalidPriority    = "filter.invalid_priority"
+	CodeFilterInvalidTime        = "filter.invalid_time"
+	CodeFilterTimeOffsetTooLarge = "filter.time_offset_too_large"
+
+	//补丁
+	CodePatchInvalidMerge  = "patch.invalid_merge_patch"
+	CodePatchInvalidJSON   = "patch.invalid_json_patch"
+	CodePatchTestFailed    = "patch.test_failed"
+	CodePatchApplyFailed   = "patch.apply_failed"
+	CodePatchReadonlyField = "patch.readonly_field"
+	CodePatchInvalidResult = "patch.invalid_result"
+
+	//批量操作
+	CodeBulkInvalidBody        = "bulk.invalid_body"
+	CodeBulkInvalidOperation   = "bulk.invalid_operation"
+	CodeBulkOperationFailed    = "bulk.operation_failed"
+	CodeBulkTitleRequired      = "bulk.title_required"
+	CodeBulkIDRequired         = "bulk.id_required"
+	CodeBulkNoFields           = "bulk.no_fields"
+	CodeBulkMoveTargetRequired = "bulk.move_target_required"
+
+	//依赖
+	CodeDependencyInvalidRequest = "dependency.invalid_request"
+	CodeDependencyCycle          = "dependency.cycle"
+	CodeDependencySelf           = "dependency.self"
+
+	//项目和视图
+	CodeProjectUnknown          = "project.unknown"
+	CodeProjectInvalidWorkflow  = "project.invalid_workflow"
+	CodeProjectWIPLimitExceeded = "project.wip_limit_exceeded"
+	CodeProjectStatusInUse      = "project.status_in_use"
+	CodeViewNotFound            = "view.not_found"
+	CodeViewBuiltinReadonly     = "view.builtin_readonly"
+	CodeViewInvalidFilter       = "view.invalid_filter"
+
+	//工作流的校验错误，作为 project.invalid_workflow 的 detail
+	CodeWorkflowInvalidKey        = "workflow.invalid_key"
+	CodeWorkflowDuplicateKey      = "workflow.duplicate_key"
+	CodeWorkflowInvalidCategory   = "workflow.invalid_category"
+	CodeWorkflowInvalidWIPLimit   = "workflow.invalid_wip_limit"
+	CodeWorkflowMissingCategory   = "workflow.missing_category"
+	CodeWorkflowUnknownTransition = "workflow.unknown_transition"
+
+	//计时和统计
+	CodeTimerRunning    = "timer.already_running"
+	CodeTimerNotRunning = "timer.not_running"
+	CodeTimerBusy       = "timer.busy"
+	CodeInvalidTaskID   = "time.invalid_task_id"
+	CodeInvalidTZ       = "time.invalid_tz"
+	CodeInvalidFrom     = "time.invalid_from"
+	CodeInvalidTo       = "time.invalid_to"
+	CodeInvalidRange    = "time.invalid_range"
+	CodeInvalidGroupBy  = "report.invalid_group_by"
+	CodeInvalidFormat   = "report.invalid_format"
+	CodeInvalidInterval = "stats.invalid_interval"
+)
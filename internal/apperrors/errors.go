@@ -4,64 +4,135 @@ import (
 	"fmt"
 )
 
-//定义一个包含HTTP状态码和错误信息的结构体
+//定义一个包含HTTP状态码和稳定错误码的结构体
+//展示给用户的消息由 ErrorMiddleware 按请求的语言从消息目录中生成
 type AppError struct {
-	Code int 
-	Message string
+	Code int //HTTP状态码
+	ErrorCode string //例如 task.not_found，见 codes.go
+	Params map[string]any //消息模板中的参数，值为 *AppError 时先翻译再填入
+	Extensions map[string]any //problem+json 的扩展成员，例如冲突时的当前内容
 	Err error
 }
 
 func (e *AppError)Error() string {
 	if e.Err != nil {
-		return fmt.Sprintf("Code: %d, Message: %s, InternalError: %v", e.Code, e.Message, e.Err)
+		return fmt.Sprintf("Code: %d, ErrorCode: %s, Params: %v, InternalError: %v", e.Code, e.ErrorCode, e.Params, e.Err)
 	}
-	return fmt.Sprintf("Code: %d, Message: %s", e.Code, e.Message)
+	return fmt.Sprintf("Code: %d, ErrorCode: %s, Params: %v", e.Code, e.ErrorCode, e.Params)
 }
 
-//Unwarp用于errors.Is和errors.As
-func (e *AppError)Unwarp() error {
+//Unwrap用于errors.Is和errors.As
+func (e *AppError)Unwrap() error {
 	return e.Err
 }
 
+//With 设置消息模板中的参数
+func (e *AppError)With(name string, value any) *AppError {
+	if e.Params == nil {
+		e.Params = map[string]any{}
+	}
+	e.Params[name] = value
+	return e
+}
+
+//WithExtension 在错误响应中附加一个扩展成员
+func (e *AppError)WithExtension(name string, value any) *AppError {
+	if e.Extensions == nil {
+		e.Extensions = map[string]any{}
+	}
+	e.Extensions[name] = value
+	return e
+}
+
+//FieldViolation 某个字段不满足的规则，由 ErrorMiddleware 放入 problem+json 的 errors 中
+type FieldViolation struct {
+	Field string //JSON 中的字段路径，以 . 分隔，例如 operations.0.title
+	Rule string
+	Param string
+}
+
+//FieldViolations 不是由 validator 检查出的字段错误，例如只读字段、密码强度
+type FieldViolations []FieldViolation
+
+func (v FieldViolations)Error() string {
+	return fmt.Sprintf("field violations: %v", []FieldViolation(v))
+}
+
 //构造函数
-func NewAppError(code int, message string, err error) *AppError{
+func NewAppError(code int, errorCode string, err error) *AppError{
 	return &AppError{
 		Code: code,
-		Message: message,
+		ErrorCode: errorCode,
 		Err: err,
 	}
 }
 
-func NewNotFoundError(message string, err error) *AppError{
-	if message == "" {
-		message = "Not Found"
+func NewNotFoundError(errorCode string, err error) *AppError{
+	if errorCode == "" {
+		errorCode = CodeNotFound
+	}
+	return NewAppError(404, errorCode, err)
+}
+
+func NewInternalServerError(errorCode string, err error) *AppError{
+	if errorCode == "" {
+		errorCode = CodeInternal
 	}
-	return NewAppError(404, message, err)
+	return NewAppError(500, errorCode, err)
 }
 
-func NewInternalServerError(message string, err error) *AppError{
-	if message == "" {
-		message = "Internal Server Error"
+func NewBadRequestError(errorCode string, err error) *AppError{
+	if errorCode == "" {
+		errorCode = CodeInvalidBody
 	}
-	return NewAppError(500, message, err)
+	return NewAppError(400, errorCode, err)
 }
 
-func NewBadRequestError(message string, err error) *AppError{
-	if message == "" {
-		message = "Bad Request"
+func NewUnauthorizedError(errorCode string, err error) *AppError{
+	if errorCode == "" {
+		errorCode = CodeAuthUnauthenticated
 	}
-	return NewAppError(400, message, err)
+	return NewAppError(401, errorCode, err)
+}
+func NewForbiddenError(errorCode string, err error) *AppError{
+	return NewAppError(403, errorCode, err)
+}
+func NewConfilictError(errorCode string, err error) *AppError{
+	return NewAppError(409, errorCode, err)
+}
+func NewPreconditionFailedError(errorCode string, err error) *AppError{
+	if errorCode == "" {
+		errorCode = CodeTaskVersionConflict
+	}
+	return NewAppError(412, errorCode, err)
+}
+
+func NewRequestEntityTooLargeError(errorCode string, err error) *AppError{
+	return NewAppError(413, errorCode, err)
+}
+
+func NewUnsupportedMediaTypeError(errorCode string, err error) *AppError{
+	return NewAppError(415, errorCode, err)
+}
+
+func NewUnprocessableEntityError(errorCode string, err error) *AppError{
+	return NewAppError(422, errorCode, err)
 }
 
-func NewUnauthorizedError(message string, err error) *AppError{
-	if message == "" {
-		message = "Unauthorized"
+func NewGoneError(errorCode string, err error) *AppError{
+	return NewAppError(410, errorCode, err)
+}
+
+func NewTooManyRequestsError(errorCode string, err error) *AppError{
+	if errorCode == "" {
+		errorCode = CodeRateLimited
 	}
-	return NewAppError(401, message, err)
+	return NewAppError(429, errorCode, err)
 }
-func NewConfilictError(message string, err error) *AppError{
-	if message == "" {
-		message = "Confilict"
+
+func NewGatewayTimeoutError(errorCode string, err error) *AppError{
+	if errorCode == "" {
+		errorCode = CodeTimeout
 	}
-	return NewAppError(409, message, err)
-}
\ No newline at end of file
+	return NewAppError(504, errorCode, err)
+}
@@ -0,0 +1,15 @@
+package apperrors_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAppError_Unwrap errors.Is 能找到 AppError 包装的原始错误
+func TestAppError_Unwrap(t *testing.T) {
+	assert.True(t, errors.Is(apperrors.NewBadRequestError(apperrors.CodeInvalidID, store.ErrNotFound), store.ErrNotFound))
+}
@@ -10,6 +10,10 @@ type Config struct {
 	Server   ServerConfig
 	JWT      JWTConfig
 	Redis    RedisConfig
+	Trash    TrashConfig
+	Ranking  RankingConfig
+	Password PasswordConfig
+	API      APIConfig
 }
 
 // DBConfig 结构体用于映射 database 部分的配置
@@ -38,7 +42,42 @@ type RedisConfig struct {
 	Addr 		string
 	Password 	string
 	DB 			int
+	TaskLock 	bool //更新任务时是否额外使用分布式锁，版本号已能防止覆盖写
 }
+//TrashConfig 结构体用于映射 trash 部分的配置
+type TrashConfig struct {
+	RetentionDays        int
+	PurgeIntervalMinutes int
+}
+//RankingConfig 结构体用于映射 ranking 部分的配置
+type RankingConfig struct {
+	MaxKeyLength             int //排序键超过该长度时重新平衡整个列表
+	RebalanceIntervalMinutes int
+}
+
+//PasswordConfig 结构体用于映射 password 部分的配置，注册时检查密码强度
+type PasswordConfig struct {
+	MinLength        int
+	RequireUpper     bool
+	RequireLower     bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	BreachedListFile string //额外的泄露密码列表，每行一个，为空时只使用内置列表
+}
+
+//APIConfig 结构体用于映射 api 部分的配置，key 为版本名，例如 v1
+type APIConfig struct {
+	Versions map[string]DeprecationConfig
+	Legacy   DeprecationConfig //不带版本前缀的旧路径，与 v1 相同
+}
+
+//DeprecationConfig 一个 API 版本的弃用信息，时间使用 RFC 3339 格式，Deprecated 为空表示未弃用
+type DeprecationConfig struct {
+	Deprecated string
+	Sunset     string
+	Successor  string //替代版本的路径前缀，例如 /api/v2
+}
+
 // LoadConfig 从 config.yaml 文件加载配置
 func LoadConfig() (config Config, err error) {
 	// 设置配置文件的名称和类型
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadConfig 仓库中的 config.yaml 的 key 与结构体字段一致
+func TestLoadConfig(t *testing.T) {
+	wd, _ := os.Getwd()
+	require.NoError(t, os.Chdir("../.."))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.NotEmpty(t, cfg.JWT.Secret)
+	assert.Equal(t, 72, cfg.JWT.ExpiresInHours)
+}
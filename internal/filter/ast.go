@@ -0,0 +1,92 @@
+package filter
+
+import "time"
+
+// FieldType 过滤字段的类型，决定可用的运算符和值的解析方式
+type FieldType int
+
+const (
+	BoolField FieldType = iota
+	TextField
+	TagField
+	PriorityField
+	TimeField
+)
+
+// Fields 过滤语言支持的字段
+var Fields = map[string]FieldType{
+	"done":      BoolField,
+	"blocked":   BoolField,
+	"title":     TextField,
+	"content":   TextField,
+	"tag":       TagField,
+	"priority":  PriorityField,
+	"due":       TimeField,
+	"created":   TimeField,
+	"updated":   TimeField,
+	"completed": TimeField,
+}
+
+// 优先级的名字，对应 models 中的 Priority 常量
+var priorityNames = map[string]int{
+	"none":   0,
+	"low":    1,
+	"medium": 2,
+	"high":   3,
+	"urgent": 4,
+}
+
+// 运算符
+const (
+	OpMatch = ":"
+	OpEq    = "="
+	OpNe    = "!="
+	OpLt    = "<"
+	OpLe    = "<="
+	OpGt    = ">"
+	OpGe    = ">="
+)
+
+// Node 过滤表达式语法树的节点
+type Node interface {
+	node()
+}
+
+// And 两个条件同时满足
+type And struct {
+	Left, Right Node
+}
+
+// Or 任意一个条件满足
+type Or struct {
+	Left, Right Node
+}
+
+// Not 条件取反
+type Not struct {
+	X Node
+}
+
+// Cond 单个条件，例如 priority>=high
+// Value 的类型由字段决定：bool、string、int、time.Time，
+// 时间字段的值为 none 时 Value 为 nil
+type Cond struct {
+	Field string
+	Type  FieldType
+	Op    string
+	Value interface{}
+	// Day 为 true 表示时间字段使用 : 运算符，匹配 Value 所在的那一天
+	Day bool
+}
+
+func (*And) node()  {}
+func (*Or) node()   {}
+func (*Not) node()  {}
+func (*Cond) node() {}
+
+// DayRange 返回 Day 条件匹配的时间范围 [start, end)
+func (c *Cond) DayRange() (time.Time, time.Time) {
+	t := c.Value.(time.Time)
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 0, 1)
+}
@@ -0,0 +1,414 @@
+// Package filter 实现任务的过滤查询语言，例如：
+//
+//	done:false AND (tag:backend OR priority>=high) AND due<now+7d
+//
+// 支持 AND、OR、NOT 和括号，相邻的条件之间默认为 AND
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+)
+
+// SyntaxError 过滤表达式的语法错误，Pos 为出错位置（从1开始的字符序号）
+// Code 和 Params 是 i18n 消息目录中的 key 和参数，由 AppError 按请求的语言显示
+type SyntaxError struct {
+	Pos    int
+	Code   string
+	Params map[string]any
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("filter: %s near character %d %v", e.Code, e.Pos, e.Params)
+}
+
+// AppError 把解析错误转换为400 task.invalid_filter，语法错误的位置和原因作为 detail
+func AppError(err error) *apperrors.AppError {
+	appErr := apperrors.NewBadRequestError(apperrors.CodeInvalidFilter, err)
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return appErr.With("detail", err.Error())
+	}
+	reason := apperrors.NewBadRequestError(syntaxErr.Code, nil)
+	for name, value := range syntaxErr.Params {
+		reason.With(name, value)
+	}
+	return appErr.With("detail", apperrors.NewBadRequestError(apperrors.CodeFilterSyntax, nil).
+		With("pos", syntaxErr.Pos).With("reason", reason))
+}
+
+// fieldNames 未知字段的错误中列出的可用字段
+const fieldNames = "done, blocked, title, content, tag, priority, due, created, updated, completed"
+
+// 表达式的长度、嵌套层数和节点数的上限，避免一个请求占用过多的 CPU
+// 保存的视图每次打开都会重新解析同样的表达式
+const (
+	MaxLength = 2048 //字符数
+	MaxDepth  = 32   //括号和 NOT 的嵌套层数
+	MaxNodes  = 256  //条件和 AND/OR/NOT 的总数
+)
+
+// MaxTimeOffsetDays 相对时间每个偏移量的上限（天），+3h、+2w 按天数折算
+// 防止 today+99999999999d 这样的偏移量溢出
+const MaxTimeOffsetDays = 36500
+
+// 运算符按长度从长到短匹配
+var operators = []string{OpGe, OpLe, OpNe, OpMatch, OpEq, OpLt, OpGt}
+
+// 相对时间，例如 now+7d、today-1w
+var relativeTime = regexp.MustCompile(`^(now|today|tomorrow|yesterday|week)((?:[+-]\d+[hdw])*)$`)
+var timeOffset = regexp.MustCompile(`([+-])(\d+)([hdw])`)
+
+type parser struct {
+	src       []rune
+	pos       int
+	now       time.Time
+	weekStart time.Weekday
+	depth     int
+	nodes     int
+}
+
+// Parse 解析过滤表达式，相对时间以 now 为基准，“今天”按 now 所在的时区计算，week 为本周一
+func Parse(input string, now time.Time) (Node, error) {
+	return ParseWithWeekStart(input, now, time.Monday)
+}
+
+// ParseWithWeekStart 与 Parse 相同，但 week 为本周的 weekStart（例如周日）
+func ParseWithWeekStart(input string, now time.Time, weekStart time.Weekday) (Node, error) {
+	if utf8.RuneCountInString(input) > MaxLength {
+		return nil, &SyntaxError{Pos: MaxLength + 1, Code: apperrors.CodeFilterTooLong, Params: map[string]any{"max": MaxLength}}
+	}
+	p := &parser{src: []rune(input), now: now, weekStart: weekStart}
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.fail(apperrors.CodeFilterEmpty)
+	}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.eof() {
+		if p.peek() == ')' {
+			return nil, p.fail(apperrors.CodeFilterUnexpectedParen)
+		}
+		return nil, p.fail(apperrors.CodeFilterUnexpectedInput, "text", string(p.src[p.pos:]))
+	}
+	return n, nil
+}
+
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.addNode(); err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if p.eof() || p.peek() == ')' || p.peekKeyword("OR") {
+			return left, nil
+		}
+		p.keyword("AND")
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.addNode(); err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	p.skipSpace()
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > MaxDepth {
+		return nil, p.fail(apperrors.CodeFilterTooDeep, "max", MaxDepth)
+	}
+	if p.keyword("NOT") {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.addNode(); err != nil {
+			return nil, err
+		}
+		return &Not{X: x}, nil
+	}
+	if p.eof() {
+		return nil, p.fail(apperrors.CodeFilterIncomplete)
+	}
+	if p.peek() == '(' {
+		p.pos++
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.eof() || p.peek() != ')' {
+			return nil, p.fail(apperrors.CodeFilterMissingParen)
+		}
+		p.pos++
+		return n, nil
+	}
+	return p.parseCond()
+}
+
+func (p *parser) parseCond() (Node, error) {
+	start := p.pos
+	for !p.eof() && (unicode.IsLetter(p.peek()) || p.peek() == '_') {
+		p.pos++
+	}
+	field := strings.ToLower(string(p.src[start:p.pos]))
+	if field == "" {
+		return nil, p.fail(apperrors.CodeFilterExpectedField)
+	}
+	typ, ok := Fields[field]
+	if !ok {
+		p.pos = start
+		return nil, p.fail(apperrors.CodeFilterUnknownField, "field", field, "fields", fieldNames)
+	}
+
+	op := ""
+	for _, candidate := range operators {
+		if strings.HasPrefix(string(p.src[p.pos:]), candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return nil, p.fail(apperrors.CodeFilterExpectedOperator, "field", field)
+	}
+	p.pos += len(op)
+
+	valuePos := p.pos
+	raw, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	//值的错误指向值的开头
+	end := p.pos
+	p.pos = valuePos
+	cond, err := p.convert(field, typ, op, raw)
+	if err != nil {
+		return nil, err
+	}
+	p.pos = end
+	if err := p.addNode(); err != nil {
+		return nil, err
+	}
+	return cond, nil
+}
+
+// parseValue 读取带引号的字符串，或者读到空白/括号为止
+func (p *parser) parseValue() (string, error) {
+	if p.eof() {
+		return "", p.fail(apperrors.CodeFilterExpectedValue)
+	}
+	if p.peek() == '"' {
+		p.pos++
+		var b strings.Builder
+		for !p.eof() && p.peek() != '"' {
+			if p.peek() == '\\' && p.pos+1 < len(p.src) {
+				p.pos++
+			}
+			b.WriteRune(p.peek())
+			p.pos++
+		}
+		if p.eof() {
+			return "", p.fail(apperrors.CodeFilterUnterminatedString)
+		}
+		p.pos++
+		return b.String(), nil
+	}
+	start := p.pos
+	for !p.eof() && !unicode.IsSpace(p.peek()) && p.peek() != '(' && p.peek() != ')' {
+		p.pos++
+	}
+	if start == p.pos {
+		return "", p.fail(apperrors.CodeFilterExpectedValue)
+	}
+	return string(p.src[start:p.pos]), nil
+}
+
+// convert 按字段类型检查运算符并解析值
+func (p *parser) convert(field string, typ FieldType, op string, raw string) (*Cond, error) {
+	cond := &Cond{Field: field, Type: typ, Op: op}
+	equality := op == OpMatch || op == OpEq || op == OpNe
+	switch typ {
+	case BoolField:
+		if !equality {
+			return nil, p.fail(apperrors.CodeFilterEqualityOnly, "field", field)
+		}
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, p.fail(apperrors.CodeFilterInvalidBool, "field", field)
+		}
+		cond.Value = b
+	case TextField, TagField:
+		if !equality {
+			return nil, p.fail(apperrors.CodeFilterEqualityOnly, "field", field)
+		}
+		cond.Value = raw
+	case PriorityField:
+		if n, ok := priorityNames[strings.ToLower(raw)]; ok {
+			cond.Value = n
+		} else if n, err := strconv.Atoi(raw); err == nil && n >= 0 && n <= 4 {
+			cond.Value = n
+		} else {
+			return nil, p.fail(apperrors.CodeFilterInvalidPriority)
+		}
+		if op == OpMatch {
+			cond.Op = OpEq
+		}
+	case TimeField:
+		if strings.EqualFold(raw, "none") {
+			if !equality {
+				return nil, p.fail(apperrors.CodeFilterEqualityOnly, "field", field+":none")
+			}
+			if op == OpMatch {
+				cond.Op = OpEq
+			}
+			return cond, nil
+		}
+		t, err := p.parseTime(raw)
+		if err != nil {
+			return nil, err
+		}
+		cond.Value = t
+		cond.Day = op == OpMatch
+	}
+	return cond, nil
+}
+
+// ParseTime 解析过滤表达式中使用的时间，例如 tomorrow、today+3d、2006-01-02 或 RFC3339
+func ParseTime(raw string, now time.Time) (time.Time, bool) {
+	p := &parser{now: now, weekStart: time.Monday}
+	t, err := p.parseTime(raw)
+	return t, err == nil
+}
+
+// timeOffsetUnits 偏移量单位折算的天数和小时数
+var timeOffsetUnits = map[string]struct{ days, hours int }{
+	"h": {0, 1},
+	"d": {1, 0},
+	"w": {7, 0},
+}
+
+func (p *parser) parseTime(raw string) (time.Time, error) {
+	lower := strings.ToLower(raw)
+	if m := relativeTime.FindStringSubmatch(lower); m != nil {
+		today := time.Date(p.now.Year(), p.now.Month(), p.now.Day(), 0, 0, 0, 0, p.now.Location())
+		base := map[string]time.Time{
+			"now":       p.now,
+			"today":     today,
+			"tomorrow":  today.AddDate(0, 0, 1),
+			"yesterday": today.AddDate(0, 0, -1),
+			// 本周的第一天
+			"week": today.AddDate(0, 0, -(int(today.Weekday())-int(p.weekStart)+7)%7),
+		}[m[1]]
+		for _, off := range timeOffset.FindAllStringSubmatch(m[2], -1) {
+			unit := timeOffsetUnits[off[3]]
+			limit := MaxTimeOffsetDays * 24
+			if unit.days > 0 {
+				limit = MaxTimeOffsetDays / unit.days
+			}
+			n, err := strconv.Atoi(off[2])
+			if err != nil || n > limit {
+				return time.Time{}, p.fail(apperrors.CodeFilterTimeOffsetTooLarge, "value", raw, "max", MaxTimeOffsetDays)
+			}
+			if off[1] == "-" {
+				n = -n
+			}
+			base = base.AddDate(0, 0, n*unit.days).Add(time.Duration(n*unit.hours) * time.Hour)
+		}
+		return base, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", raw, p.now.Location()); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Time{}, p.fail(apperrors.CodeFilterInvalidTime, "value", raw)
+}
+
+// addNode 记录新建的节点，超过 MaxNodes 时返回错误
+func (p *parser) addNode() error {
+	p.nodes++
+	if p.nodes > MaxNodes {
+		return p.fail(apperrors.CodeFilterTooManyConditions, "max", MaxNodes)
+	}
+	return nil
+}
+
+// keyword 如果下一个词是 kw（不区分大小写）则消费它
+func (p *parser) keyword(kw string) bool {
+	p.skipSpace()
+	if !p.peekKeyword(kw) {
+		return false
+	}
+	p.pos += len(kw)
+	return true
+}
+
+func (p *parser) peekKeyword(kw string) bool {
+	end := p.pos + len(kw)
+	if end > len(p.src) || !strings.EqualFold(string(p.src[p.pos:end]), kw) {
+		return false
+	}
+	return end == len(p.src) || unicode.IsSpace(p.src[end]) || p.src[end] == '('
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() rune {
+	return p.src[p.pos]
+}
+
+// fail 返回当前位置的语法错误，params 为成对的参数名和值
+func (p *parser) fail(code string, params ...any) error {
+	e := &SyntaxError{Pos: p.pos + 1, Code: code}
+	if len(params) > 0 {
+		e.Params = make(map[string]any, len(params)/2)
+		for i := 0; i+1 < len(params); i += 2 {
+			e.Params[params[i].(string)] = params[i+1]
+		}
+	}
+	return e
+}
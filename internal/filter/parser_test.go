@@ -0,0 +1,155 @@
+package filter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2025, 3, 10, 15, 30, 0, 0, time.UTC)
+
+// TestParse_Precedence 测试 AND/OR/NOT 和括号的优先级
+func TestParse_Precedence(t *testing.T) {
+	n, err := Parse(`done:false AND (tag:backend OR priority>=high) AND due<now+7d`, testNow)
+	assert.NoError(t, err)
+
+	and, ok := n.(*And)
+	assert.True(t, ok)
+	due := and.Right.(*Cond)
+	assert.Equal(t, "due", due.Field)
+	assert.Equal(t, OpLt, due.Op)
+	assert.Equal(t, testNow.AddDate(0, 0, 7), due.Value)
+
+	inner := and.Left.(*And)
+	assert.Equal(t, false, inner.Left.(*Cond).Value)
+	or := inner.Right.(*Or)
+	assert.Equal(t, "backend", or.Left.(*Cond).Value)
+	assert.Equal(t, 3, or.Right.(*Cond).Value)
+
+	// 相邻条件默认为 AND，OR 的优先级低于 AND
+	n, err = Parse(`NOT done:true title:"weekly report" OR due:none`, testNow)
+	assert.NoError(t, err)
+	or = n.(*Or)
+	assert.IsType(t, &Not{}, or.Left.(*And).Left)
+	assert.Equal(t, "weekly report", or.Left.(*And).Right.(*Cond).Value)
+	assert.Nil(t, or.Right.(*Cond).Value)
+}
+
+// TestParse_Time 测试相对时间和日期
+func TestParse_Time(t *testing.T) {
+	n, err := Parse(`due:tomorrow`, testNow)
+	assert.NoError(t, err)
+	cond := n.(*Cond)
+	assert.True(t, cond.Day)
+	start, end := cond.DayRange()
+	assert.Equal(t, time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC), end)
+
+	n, err = Parse(`created>=2025-01-31 updated<today-1w+3h`, testNow)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), n.(*And).Left.(*Cond).Value)
+	assert.Equal(t, time.Date(2025, 3, 3, 3, 0, 0, 0, time.UTC), n.(*And).Right.(*Cond).Value)
+
+	// 2025-03-10 是周一，week 指向当天零点
+	n, err = Parse(`completed>=week`, testNow.AddDate(0, 0, 6))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), n.(*Cond).Value)
+
+	// 同一天（周日）在一周从周日开始时，week 指向当天零点
+	n, err = ParseWithWeekStart(`completed>=week`, testNow.AddDate(0, 0, 6), time.Sunday)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC), n.(*Cond).Value)
+}
+
+// TestParse_Errors 测试语法错误的位置和错误码
+func TestParse_Errors(t *testing.T) {
+	cases := []struct {
+		input string
+		pos   int
+		code  string
+	}{
+		{``, 1, apperrors.CodeFilterEmpty},
+		{`done:false AND`, 15, apperrors.CodeFilterIncomplete},
+		{`(done:false`, 12, apperrors.CodeFilterMissingParen},
+		{`done:false)`, 11, apperrors.CodeFilterUnexpectedParen},
+		{`owner:me`, 1, apperrors.CodeFilterUnknownField},
+		{`done>true`, 6, apperrors.CodeFilterEqualityOnly},
+		{`priority:huge`, 10, apperrors.CodeFilterInvalidPriority},
+		{`due<someday`, 5, apperrors.CodeFilterInvalidTime},
+		{`due<today+99999999999999999999d`, 5, apperrors.CodeFilterTimeOffsetTooLarge},
+		{`due<now+9999999999999h`, 5, apperrors.CodeFilterTimeOffsetTooLarge},
+		{`due<today-5300w`, 5, apperrors.CodeFilterTimeOffsetTooLarge},
+		{`title:"unterminated`, 20, apperrors.CodeFilterUnterminatedString},
+		{`done`, 5, apperrors.CodeFilterExpectedOperator},
+	}
+	for _, tc := range cases {
+		_, err := Parse(tc.input, testNow)
+		var syntaxErr *SyntaxError
+		if assert.True(t, errors.As(err, &syntaxErr), tc.input) {
+			assert.Equal(t, tc.pos, syntaxErr.Pos, "%s: %v", tc.input, syntaxErr)
+			assert.Equal(t, tc.code, syntaxErr.Code, tc.input)
+		}
+	}
+}
+
+// TestAppError 测试语法错误按请求的语言显示，位置和原因都来自消息目录
+func TestAppError(t *testing.T) {
+	_, err := Parse(`owner:me`, testNow)
+	appErr := AppError(err)
+	assert.Equal(t, apperrors.CodeInvalidFilter, appErr.ErrorCode)
+	detail, ok := appErr.Params["detail"].(*apperrors.AppError)
+	if assert.True(t, ok) {
+		assert.Equal(t, apperrors.CodeFilterSyntax, detail.ErrorCode)
+		assert.Equal(t, 1, detail.Params["pos"])
+		reason := detail.Params["reason"].(*apperrors.AppError)
+		assert.Equal(t, apperrors.CodeFilterUnknownField, reason.ErrorCode)
+		assert.Equal(t, "owner", reason.Params["field"])
+	}
+}
+
+// TestParse_Limits 过长、嵌套过深或条件过多的表达式直接拒绝
+func TestParse_Limits(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		ok    bool
+	}{
+		{"长度等于上限", `title:"` + strings.Repeat("a", MaxLength-8) + `"`, true},
+		{"超过长度", `title:"` + strings.Repeat("a", MaxLength) + `"`, false},
+		{"NOT 的层数等于上限", strings.Repeat("NOT ", MaxDepth-1) + "done:true", true},
+		{"NOT 嵌套过深", strings.Repeat("NOT ", MaxDepth) + "done:true", false},
+		{"括号嵌套过深", strings.Repeat("(", MaxDepth) + "done:true" + strings.Repeat(")", MaxDepth), false},
+		{"条件过多", strings.TrimSpace(strings.Repeat("done:true ", MaxNodes/2+1)), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(tc.input, testNow)
+			if tc.ok {
+				assert.NoError(t, err)
+				return
+			}
+			var syntaxErr *SyntaxError
+			assert.True(t, errors.As(err, &syntaxErr), "%v", err)
+		})
+	}
+}
+
+// TestParseTime 测试单独解析时间，命令行的 --due 使用同样的写法
+func TestParseTime(t *testing.T) {
+	due, ok := ParseTime("tomorrow+2h", testNow)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2025, 3, 11, 2, 0, 0, 0, time.UTC), due)
+
+	_, ok = ParseTime("next tuesday", testNow)
+	assert.False(t, ok)
+
+	due, ok = ParseTime("today+36500d-876000h", testNow)
+	assert.True(t, ok, "上限以内的偏移量")
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), due)
+	_, ok = ParseTime("today+36501d", testNow)
+	assert.False(t, ok)
+}
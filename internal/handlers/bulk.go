@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// maxBulkOperations 取自 BulkRequest.Operations 的 binding tag，校验、错误信息和 OpenAPI 文档使用同一个上限
+var maxBulkOperations = bindingParam(BulkRequest{}, "Operations", "max")
+
+// BulkRequest 定义批量操作请求的JSON结构
+// atomic 为 true 时全部成功或全部回滚，否则逐条返回结果
+type BulkRequest struct {
+	Atomic     bool                   `json:"atomic"`
+	Operations []models.BulkOperation `json:"operations" binding:"required,min=1,max=500,dive"`
+}
+
+// validateBulkOperation 在进入数据库之前检查单个操作的参数
+func validateBulkOperation(op models.BulkOperation) *apperrors.AppError {
+	if op.ProjectID.Value != nil && *op.ProjectID.Value < 1 {
+		return apperrors.NewBadRequestError(apperrors.CodeProjectUnknown, nil)
+	}
+	switch op.Op {
+	case models.BulkOpCreate:
+		if op.Title == nil || strings.TrimSpace(*op.Title) == "" {
+			return apperrors.NewBadRequestError(apperrors.CodeBulkTitleRequired, nil)
+		}
+	case models.BulkOpUpdate:
+		if op.ID <= 0 {
+			return apperrors.NewBadRequestError(apperrors.CodeBulkIDRequired, nil).With("op", op.Op)
+		}
+		if op.Title == nil && op.Content == nil && op.Done == nil && !op.DueAt.Set && op.Priority == nil && op.Tags == nil && !op.ProjectID.Set && op.Status == nil {
+			return apperrors.NewBadRequestError(apperrors.CodeBulkNoFields, nil)
+		}
+		if op.Title != nil && strings.TrimSpace(*op.Title) == "" {
+			return apperrors.NewBadRequestError(apperrors.CodeBulkTitleRequired, nil)
+		}
+	case models.BulkOpDelete, models.BulkOpComplete:
+		if op.ID <= 0 {
+			return apperrors.NewBadRequestError(apperrors.CodeBulkIDRequired, nil).With("op", op.Op)
+		}
+	case models.BulkOpMove:
+		if op.ID <= 0 {
+			return apperrors.NewBadRequestError(apperrors.CodeBulkIDRequired, nil).With("op", op.Op)
+		}
+		if op.BeforeID < 0 || op.AfterID < 0 || (op.BeforeID == 0 && op.AfterID == 0) {
+			return apperrors.NewBadRequestError(apperrors.CodeBulkMoveTargetRequired, nil)
+		}
+	}
+	return nil
+}
+
+// newBulkResultResponse 生成单个操作的结果，错误转换为状态码、错误码和按请求语言生成的提示信息
+func newBulkResultResponse(c *gin.Context, r *models.BulkResult) BulkResultResponse {
+	resp := BulkResultResponse{Index: r.Index, Op: r.Op, ID: r.ID}
+	if r.Err == nil {
+		resp.Status = bulkSuccessStatus(r.Op)
+		if r.Task != nil {
+			resp.Task = newTaskResponse(r.Task)
+		}
+		return resp
+	}
+	appErr := middleware.ToAppError(r.Err)
+	if appErr.Code >= http.StatusInternalServerError {
+		log.Printf("Bulk operation error: %v", r.Err)
+	}
+	resp.Status, resp.Code, resp.Error = appErr.Code, appErr.ErrorCode, middleware.Message(c, appErr)
+	return resp
+}
+
+// bulkSuccessStatus 单个操作成功时的状态码
+func bulkSuccessStatus(op string) int {
+	switch op {
+	case models.BulkOpCreate:
+		return http.StatusCreated
+	case models.BulkOpDelete:
+		return http.StatusNoContent
+	}
+	return http.StatusOK
+}
+
+// BulkTasks 在一个事务中执行多个创建/更新/删除/完成/移动操作
+func (h *TaskHandler) BulkTasks(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	var req BulkRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(invalidBody(apperrors.CodeBulkInvalidBody, err).With("max", maxBulkOperations))
+		return
+	}
+
+	results := make([]models.BulkResult, len(req.Operations))
+	var valid []models.BulkOperation
+	var validIndex []int
+	for i, op := range req.Operations {
+		if err := validateBulkOperation(op); err != nil {
+			if req.Atomic {
+				c.Error(apperrors.NewBadRequestError(apperrors.CodeBulkInvalidOperation, err).With("index", i).With("reason", err))
+				return
+			}
+			results[i] = models.BulkResult{Index: i, Op: op.Op, ID: op.ID, Err: err}
+			continue
+		}
+		valid = append(valid, op)
+		validIndex = append(validIndex, i)
+	}
+
+	if len(valid) > 0 {
+		applied, err := h.Store.BulkApply(c.Request.Context(), userID, valid, req.Atomic)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		for j, r := range applied {
+			r.Index = validIndex[j]
+			results[r.Index] = r
+		}
+	}
+
+	responses := make([]BulkResultResponse, len(results))
+	for i := range results {
+		r := &results[i]
+		responses[i] = newBulkResultResponse(c, r)
+		if r.Err != nil && req.Atomic {
+			c.Error(apperrors.NewAppError(responses[i].Status, apperrors.CodeBulkOperationFailed, r.Err).With("index", i).With("reason", middleware.ToAppError(r.Err)))
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"atomic": req.Atomic, "results": responses})
+}
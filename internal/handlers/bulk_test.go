@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func postBulk(mockStore *store.MockStore, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	taskHandler := NewTaskHandler(mockStore, nil)
+	router := gin.New()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
+	router.POST("/tasks/bulk", taskHandler.BulkTasks)
+	req := httptest.NewRequest(http.MethodPost, "/tasks/bulk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "en")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestBulkTasks_PerItem 测试逐条模式：无效的操作不交给 store，每个操作都有自己的结果
+func TestBulkTasks_PerItem(t *testing.T) {
+	// ARRANGE
+	mockStore := new(store.MockStore)
+	mockStore.On("BulkApply", mock.Anything, 1, mock.MatchedBy(func(ops []models.BulkOperation) bool {
+		return len(ops) == 2 && ops[0].Op == "create" && ops[1].ID == 9
+	}), false).Return([]models.BulkResult{
+		{Index: 0, Op: "create", ID: 4, Task: &models.Task{ID: 4, UserID: 1, Title: "new"}},
+		{Index: 1, Op: "delete", ID: 9, Err: store.ErrNotFound},
+	}, nil).Once()
+
+	// ACT
+	w := postBulk(mockStore, `{"operations": [
+		{"op": "create", "title": "new"},
+		{"op": "update", "id": 3},
+		{"op": "delete", "id": 9}
+	]}`)
+
+	// ASSERT
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Atomic  bool                 `json:"atomic"`
+		Results []BulkResultResponse `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Results, 3)
+	assert.Equal(t, http.StatusCreated, resp.Results[0].Status)
+	assert.Equal(t, "new", resp.Results[0].Task.Title)
+	assert.Equal(t, http.StatusBadRequest, resp.Results[1].Status)
+	assert.Equal(t, "bulk.no_fields", resp.Results[1].Code)
+	assert.Equal(t, 2, resp.Results[2].Index, "store 的结果按原始下标放回")
+	assert.Equal(t, http.StatusNotFound, resp.Results[2].Status)
+	assert.Equal(t, "resource.not_found", resp.Results[2].Code)
+	mockStore.AssertExpectations(t)
+}
+
+// TestBulkTasks_Atomic 测试全有或全无模式：任一操作无效或失败时整个请求失败
+func TestBulkTasks_Atomic(t *testing.T) {
+	t.Run("校验失败时不访问 store", func(t *testing.T) {
+		mockStore := new(store.MockStore)
+		w := postBulk(mockStore, `{"atomic": true, "operations": [{"op": "create", "title": "a"}, {"op": "move", "id": 2}]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"bulk.invalid_operation"`)
+		mockStore.AssertNotCalled(t, "BulkApply", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("中间的操作失败时返回它的下标和原因", func(t *testing.T) {
+		mockStore := new(store.MockStore)
+		mockStore.On("BulkApply", mock.Anything, 1, mock.Anything, true).Return([]models.BulkResult{
+			{Index: 0, Op: "complete", ID: 1, Task: &models.Task{ID: 1, Done: true}},
+			{Index: 1, Op: "complete", ID: 2, Err: store.ErrVersionConflict},
+			{},
+		}, nil).Once()
+		w := postBulk(mockStore, `{"atomic": true, "operations": [
+			{"op": "complete", "id": 1}, {"op": "complete", "id": 2, "version": 3}, {"op": "complete", "id": 5}
+		]}`)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Equal(t, middleware.ProblemContentType, w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), `"code":"bulk.operation_failed"`)
+		assert.NotContains(t, w.Body.String(), `"results"`, "回滚后不返回已执行的操作")
+		mockStore.AssertExpectations(t)
+	})
+}
+
+// TestBulkTasks_ValidationProblem 测试校验失败时返回每个字段的 JSON Pointer 和规则
+func TestBulkTasks_ValidationProblem(t *testing.T) {
+	// ARRANGE
+	gin.SetMode(gin.TestMode)
+	mockStore := new(store.MockStore)
+	taskHandler := NewTaskHandler(mockStore, nil)
+
+	// ACT
+	router := gin.Default()
+	router.Use(middleware.RequestIDMiddleware(), middleware.ErrorMiddleware(), withUser(1))
+	router.POST("/tasks/bulk", taskHandler.BulkTasks)
+	body := `{"operations": [{"op": "create", "title": "ok"}, {"op": "explode"}]}`
+	req, _ := http.NewRequest(http.MethodPost, "/tasks/bulk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "en")
+	req.Header.Set("X-Request-ID", "req-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// ASSERT
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var problem middleware.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "bulk.invalid_body", problem.Code)
+	assert.Equal(t, "req-123", problem.Instance)
+	assert.Equal(t, []middleware.FieldError{{
+		Pointer: "/operations/1/op",
+		Rule:    "oneof",
+		Param:   "create update delete complete move",
+		Detail:  "must be one of: create update delete complete move",
+	}}, problem.Errors)
+	mockStore.AssertExpectations(t)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+// mentionPattern 匹配评论中的 @username
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@(\w+)`)
+
+// CommentHandler 包含任务评论和动态相关的 handler
+type CommentHandler struct {
+	Store store.Store
+}
+
+// NewCommentHandler 创建一个新的 CommentHandler
+func NewCommentHandler(s store.Store) *CommentHandler {
+	return &CommentHandler{Store: s}
+}
+
+// CommentRequest 定义创建/编辑评论的JSON结构，content 为Markdown
+type CommentRequest struct {
+	Content string `json:"content" binding:"required,max=10000"`
+}
+
+// parseMentions 提取评论中@到的用户名（去重）
+func parseMentions(content string) []models.Mention {
+	mentions := []models.Mention{}
+	seen := make(map[string]bool)
+	for _, m := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			mentions = append(mentions, models.Mention{Username: m[1]})
+		}
+	}
+	return mentions
+}
+
+// getOwnedTaskID 解析路径中的任务ID，并确认该任务属于当前用户
+func (h *CommentHandler) getOwnedTaskID(c *gin.Context) (taskID int, userID int, ok bool) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
+		return 0, 0, false
+	}
+	userID, ok = getUserIDFromContext(c)
+	if !ok {
+		return 0, 0, false
+	}
+	if _, err := h.Store.GetTaskByID(c.Request.Context(), taskID, userID); err != nil {
+		c.Error(err)
+		return 0, 0, false
+	}
+	return taskID, userID, true
+}
+
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	taskID, userID, ok := h.getOwnedTaskID(c)
+	if !ok {
+		return
+	}
+	var req CommentRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(invalidBody(apperrors.CodeInvalidBody, err))
+		return
+	}
+	comment := &models.Comment{
+		TaskID:   taskID,
+		UserID:   userID,
+		Content:  req.Content,
+		Mentions: parseMentions(req.Content),
+	}
+	if err := h.Store.CreateComment(c.Request.Context(), comment); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, newCommentResponse(comment))
+}
+
+func (h *CommentHandler) GetComments(c *gin.Context) {
+	taskID, _, ok := h.getOwnedTaskID(c)
+	if !ok {
+		return
+	}
+	page, pageSize, opts, ok := getPagination(c)
+	if !ok {
+		return
+	}
+	comments, total, err := h.Store.GetComments(c.Request.Context(), taskID, opts)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, pageResponse(newCommentResponses(comments), total, page, pageSize))
+}
+
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	taskID, userID, ok := h.getOwnedTaskID(c)
+	if !ok {
+		return
+	}
+	commentID, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeCommentInvalidID, err))
+		return
+	}
+	var req CommentRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(invalidBody(apperrors.CodeInvalidBody, err))
+		return
+	}
+	comment := &models.Comment{
+		ID:       commentID,
+		TaskID:   taskID,
+		UserID:   userID,
+		Content:  req.Content,
+		Mentions: parseMentions(req.Content),
+	}
+	if err := h.Store.UpdateComment(c.Request.Context(), comment); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newCommentResponse(comment))
+}
+
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	taskID, userID, ok := h.getOwnedTaskID(c)
+	if !ok {
+		return
+	}
+	commentID, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeCommentInvalidID, err))
+		return
+	}
+	if err := h.Store.DeleteComment(c.Request.Context(), commentID, taskID, userID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetActivity 返回评论和自动生成的变更记录合并后的动态流
+func (h *CommentHandler) GetActivity(c *gin.Context) {
+	taskID, _, ok := h.getOwnedTaskID(c)
+	if !ok {
+		return
+	}
+	page, pageSize, opts, ok := getPagination(c)
+	if !ok {
+		return
+	}
+	items, total, err := h.Store.GetActivityFeed(c.Request.Context(), taskID, opts)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, pageResponse(items, total, page, pageSize))
+}
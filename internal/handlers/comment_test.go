@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newCommentRouter(mockStore *store.MockStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	commentHandler := NewCommentHandler(mockStore)
+	router := gin.New()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
+	router.POST("/tasks/:id/comments", commentHandler.CreateComment)
+	router.GET("/tasks/:id/comments", commentHandler.GetComments)
+	router.PUT("/tasks/:id/comments/:comment_id", commentHandler.UpdateComment)
+	router.DELETE("/tasks/:id/comments/:comment_id", commentHandler.DeleteComment)
+	router.GET("/tasks/:id/activity", commentHandler.GetActivity)
+	return router
+}
+
+// TestParseMentions 测试提取评论中的@用户名，去掉重复的和邮箱地址
+func TestParseMentions(t *testing.T) {
+	mentions := parseMentions("@bob please review, cc @carol and @bob; mail alice@example.com")
+	assert.Equal(t, []models.Mention{{Username: "bob"}, {Username: "carol"}}, mentions)
+	assert.Empty(t, parseMentions("no mentions here"))
+}
+
+// TestCreateComment 测试创建评论时把@到的用户名交给 store 解析，返回解析后的用户
+func TestCreateComment(t *testing.T) {
+	// ARRANGE
+	mockStore := new(store.MockStore)
+	mockStore.On("GetTaskByID", mock.Anything, 5, 1).Return(&models.Task{ID: 5, UserID: 1}, nil)
+	mockStore.On("CreateComment", mock.Anything, mock.MatchedBy(func(comment *models.Comment) bool {
+		return comment.TaskID == 5 && comment.UserID == 1 && comment.Content == "ping @bob" &&
+			len(comment.Mentions) == 1 && comment.Mentions[0].Username == "bob"
+	})).Run(func(args mock.Arguments) {
+		comment := args.Get(1).(*models.Comment)
+		comment.ID = 11
+		comment.Username = "alice"
+		comment.Mentions = []models.Mention{{UserID: 2, Username: "bob"}}
+	}).Return(nil).Once()
+
+	// ACT
+	w := httptest.NewRecorder()
+	newCommentRouter(mockStore).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/tasks/5/comments", strings.NewReader(`{"content":"ping @bob"}`)))
+
+	// ASSERT
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":11`)
+	assert.Contains(t, w.Body.String(), `"mentions":[{"user_id":2,"username":"bob"}]`)
+	mockStore.AssertExpectations(t)
+}
+
+// TestUpdateAndDeleteComment 测试作者可以编辑和删除自己的评论，别人的评论返回404
+func TestUpdateAndDeleteComment(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"编辑自己的评论", http.MethodPut, "/tasks/5/comments/11", `{"content":"edited"}`, http.StatusOK},
+		{"编辑别人的评论", http.MethodPut, "/tasks/5/comments/12", `{"content":"edited"}`, http.StatusNotFound},
+		{"删除自己的评论", http.MethodDelete, "/tasks/5/comments/11", "", http.StatusNoContent},
+		{"删除别人的评论", http.MethodDelete, "/tasks/5/comments/12", "", http.StatusNotFound},
+		{"评论ID无效", http.MethodDelete, "/tasks/5/comments/x", "", http.StatusBadRequest},
+		{"别人的任务", http.MethodDelete, "/tasks/9/comments/11", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := new(store.MockStore)
+			mockStore.On("GetTaskByID", mock.Anything, 5, 1).Return(&models.Task{ID: 5, UserID: 1}, nil).Maybe()
+			mockStore.On("GetTaskByID", mock.Anything, 9, 1).Return(nil, store.ErrNotFound).Maybe()
+			//store 只修改 user_id 为作者本人的评论，其余返回 ErrNotFound
+			mockStore.On("UpdateComment", mock.Anything, mock.MatchedBy(func(c *models.Comment) bool { return c.ID == 11 && c.UserID == 1 })).Return(nil).Maybe()
+			mockStore.On("UpdateComment", mock.Anything, mock.MatchedBy(func(c *models.Comment) bool { return c.ID == 12 })).Return(store.ErrNotFound).Maybe()
+			mockStore.On("DeleteComment", mock.Anything, 11, 5, 1).Return(nil).Maybe()
+			mockStore.On("DeleteComment", mock.Anything, 12, 5, 1).Return(store.ErrNotFound).Maybe()
+
+			w := httptest.NewRecorder()
+			newCommentRouter(mockStore).ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+		})
+	}
+}
+
+// TestGetActivity 测试评论和变更记录合并后的动态流按分页参数查询
+func TestGetActivity(t *testing.T) {
+	// ARRANGE
+	mockStore := new(store.MockStore)
+	mockStore.On("GetTaskByID", mock.Anything, 5, 1).Return(&models.Task{ID: 5, UserID: 1}, nil)
+	content, action := "looks good", models.ActivityTaskCompleted
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	mockStore.On("GetActivityFeed", mock.Anything, 5, store.ListOptions{Limit: 2, Offset: 2}).Return([]models.FeedItem{
+		{Kind: models.FeedKindComment, ID: 11, UserID: 2, Username: "bob", Content: &content, CreatedAt: created},
+		{Kind: models.FeedKindEvent, ID: 3, UserID: 1, Username: "alice", Action: &action, CreatedAt: created},
+	}, 5, nil).Once()
+
+	// ACT
+	w := httptest.NewRecorder()
+	newCommentRouter(mockStore).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks/5/activity?page=2&page_size=2", nil))
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `"total":5`)
+	assert.Contains(t, body, `"page":2`)
+	assert.Contains(t, body, `"page_size":2`)
+	assert.Contains(t, body, `"kind":"comment"`)
+	assert.Contains(t, body, `"action":"task.completed"`)
+	mockStore.AssertExpectations(t)
+
+	w = httptest.NewRecorder()
+	newCommentRouter(mockStore).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks/5/activity?page_size=500", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
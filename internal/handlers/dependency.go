@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+// DependencyRequest 定义添加依赖的JSON结构，blocked_by 和 blocks 只能提供一个
+// blocked_by: 当前任务依赖该任务；blocks: 该任务依赖当前任务
+type DependencyRequest struct {
+	BlockedBy int `json:"blocked_by" binding:"min=0"`
+	Blocks    int `json:"blocks" binding:"min=0"`
+}
+
+// AddDependency 为任务添加前置或后续任务，形成循环时返回409
+func (h *TaskHandler) AddDependency(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
+		return
+	}
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	var req DependencyRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(invalidBody(apperrors.CodeInvalidBody, err))
+		return
+	}
+	if (req.BlockedBy == 0) == (req.Blocks == 0) {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeDependencyInvalidRequest, nil))
+		return
+	}
+	blockerID, blockedID := req.BlockedBy, id
+	if req.Blocks != 0 {
+		blockerID, blockedID = id, req.Blocks
+	}
+	dep, err := h.Store.AddDependency(c.Request.Context(), userID, blockerID, blockedID)
+	if errors.Is(err, store.ErrDependencyCycle) {
+		appErr := apperrors.NewConfilictError(apperrors.CodeDependencyCycle, err).With("blocker", blockerID).With("blocked", blockedID)
+		if blockerID == blockedID {
+			appErr = apperrors.NewConfilictError(apperrors.CodeDependencySelf, err)
+		}
+		c.Error(appErr)
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, newDependencyResponse(dep))
+}
+
+// RemoveDependency 删除当前任务与另一个任务之间的依赖
+func (h *TaskHandler) RemoveDependency(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
+		return
+	}
+	otherID, err := strconv.Atoi(c.Param("other_id"))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
+		return
+	}
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	if err := h.Store.RemoveDependency(c.Request.Context(), userID, id, otherID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetDependencies 返回任务的传递依赖图
+func (h *TaskHandler) GetDependencies(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
+		return
+	}
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	graph, err := h.Store.GetDependencyGraph(c.Request.Context(), id, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, DependencyGraphResponse{TaskID: graph.TaskID, Nodes: newTaskResponses(graph.Nodes), Edges: newDependencyResponses(graph.Edges)})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+// taskETag 用任务的版本号生成强 ETag
+func taskETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// getIfMatchVersion 解析 If-Match 头，返回客户端期望的版本号
+// 没有 If-Match 或值为 * 时返回0，表示不做版本检查
+// If-Match 可以是用逗号分隔的多个 ETag（RFC 9110），任意一个与当前版本一致即可，
+// 此时返回当前版本；都不一致时返回第一个，由 store 报告版本冲突
+func (h *TaskHandler) getIfMatchVersion(c *gin.Context, id int, userID int) (int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	var versions []int
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		// 弱 ETag 不能用于 If-Match 的强比较，一律视为不匹配
+		if strings.HasPrefix(tag, `W/"`) {
+			continue
+		}
+		if !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) || len(tag) < 2 {
+			c.Error(apperrors.NewPreconditionFailedError(apperrors.CodeInvalidIfMatch, nil))
+			return 0, false
+		}
+		version, err := strconv.Atoi(tag[1 : len(tag)-1])
+		if err != nil || version < 1 {
+			c.Error(apperrors.NewPreconditionFailedError(apperrors.CodeInvalidIfMatch, err))
+			return 0, false
+		}
+		versions = append(versions, version)
+	}
+	if len(versions) == 0 {
+		c.Error(apperrors.NewPreconditionFailedError(apperrors.CodeInvalidIfMatch, nil))
+		return 0, false
+	}
+	if len(versions) == 1 {
+		return versions[0], true
+	}
+	current, err := h.Store.GetTaskByID(c.Request.Context(), id, userID)
+	if err != nil {
+		c.Error(err)
+		return 0, false
+	}
+	if slices.Contains(versions, current.Version) {
+		return current.Version, true
+	}
+	return versions[0], true
+}
+
+// respondVersionConflict 在 If-Match 不满足时返回 412 和任务的当前内容
+func (h *TaskHandler) respondVersionConflict(c *gin.Context, err error, id int, userID int) {
+	if !errors.Is(err, store.ErrVersionConflict) {
+		c.Error(err)
+		return
+	}
+	current, getErr := h.Store.GetTaskByID(c.Request.Context(), id, userID)
+	if getErr != nil {
+		c.Error(getErr)
+		return
+	}
+	c.Header("ETag", taskETag(current.Version))
+	c.Error(apperrors.NewPreconditionFailedError(apperrors.CodeTaskVersionConflict, err).WithExtension("current", newTaskResponse(current)))
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/gin-gonic/gin"
+)
+
+// MoveRequest 定义移动任务请求的JSON结构
+// after_id 表示放在该任务之后，before_id 表示放在该任务之前，至少提供一个
+type MoveRequest struct {
+	BeforeID int `json:"before_id" binding:"min=0"`
+	AfterID  int `json:"after_id" binding:"min=0"`
+}
+
+// MoveTask 调整任务在手动排序中的位置，只修改被移动的任务
+func (h *TaskHandler) MoveTask(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
+		return
+	}
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	var req MoveRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(invalidBody(apperrors.CodeInvalidBody, err))
+		return
+	}
+	if req.BeforeID == 0 && req.AfterID == 0 {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeTaskMoveTargetRequired, nil))
+		return
+	}
+	version, ok := h.getIfMatchVersion(c, id, userID)
+	if !ok {
+		return
+	}
+	task, err := h.Store.MoveTask(c.Request.Context(), id, userID, version, req.BeforeID, req.AfterID)
+	if err != nil {
+		h.respondVersionConflict(c, err, id, userID)
+		return
+	}
+	c.Header("ETag", taskETag(task.Version))
+	c.JSON(http.StatusOK, newTaskResponse(task))
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// getPagination 从 ?page=&page_size= 中解析分页参数
+func getPagination(c *gin.Context) (page int, pageSize int, opts store.ListOptions, ok bool) {
+	page, pageSize = 1, defaultPageSize
+	var err error
+	if v := c.Query("page"); v != "" {
+		if page, err = strconv.Atoi(v); err != nil || page < 1 {
+			c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidPage, err))
+			return 0, 0, opts, false
+		}
+	}
+	if v := c.Query("page_size"); v != "" {
+		if pageSize, err = strconv.Atoi(v); err != nil || pageSize < 1 || pageSize > maxPageSize {
+			c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidPageSize, err))
+			return 0, 0, opts, false
+		}
+	}
+	opts = store.ListOptions{Limit: pageSize, Offset: (page - 1) * pageSize}
+	return page, pageSize, opts, true
+}
+
+// pageResponse 分页列表的统一响应格式
+func pageResponse(items interface{}, total int, page int, pageSize int) gin.H {
+	return gin.H{
+		"items":     items,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	}
+}
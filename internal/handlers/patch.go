@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json" // RFC 7396
+	jsonPatchContentType  = "application/json-patch+json"  // RFC 6902
+
+	// 没有 If-Match 时，版本冲突后重新读取并应用补丁的最大次数
+	maxPatchAttempts = 3
+)
+
+// applyTaskPatch 按 Content-Type 把补丁应用到任务的可修改字段上
+func applyTaskPatch(contentType string, task *models.Task, patch []byte) (*TaskRequest, error) {
+	doc, err := json.Marshal(TaskRequest{
+		Title:     task.Title,
+		Content:   task.Content,
+		Done:      task.Done,
+		DueAt:     task.DueAt,
+		Priority:  task.Priority,
+		Tags:      task.Tags,
+		ProjectID: task.ProjectID,
+		Status:    task.Status,
+	})
+	if err != nil {
+		return nil, apperrors.NewInternalServerError("", err)
+	}
+
+	var patched []byte
+	switch contentType {
+	case mergePatchContentType:
+		if patched, err = jsonpatch.MergePatch(doc, patch); err != nil {
+			return nil, apperrors.NewBadRequestError(apperrors.CodePatchInvalidMerge, err)
+		}
+	case jsonPatchContentType:
+		ops, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, apperrors.NewBadRequestError(apperrors.CodePatchInvalidJSON, err)
+		}
+		if patched, err = ops.Apply(doc); err != nil {
+			if errors.Is(err, jsonpatch.ErrTestFailed) {
+				return nil, apperrors.NewConfilictError(apperrors.CodePatchTestFailed, err)
+			}
+			return nil, apperrors.NewUnprocessableEntityError(apperrors.CodePatchApplyFailed, err)
+		}
+	default:
+		return nil, apperrors.NewUnsupportedMediaTypeError(apperrors.CodeUnsupportedMediaType, nil).With("types", mergePatchContentType+", "+jsonPatchContentType)
+	}
+
+	// 补丁不能引入只读字段（如 id、user_id）或未知字段
+	var result TaskRequest
+	if err := decodeStrict(patched, &result); err != nil {
+		return nil, apperrors.NewUnprocessableEntityError(apperrors.CodePatchReadonlyField, err)
+	}
+	if err := binding.Validator.ValidateStruct(&result); err != nil {
+		return nil, apperrors.NewUnprocessableEntityError(apperrors.CodePatchInvalidResult, err)
+	}
+	return &result, nil
+}
+
+// PatchTask 支持 JSON Merge Patch 和 JSON Patch 的部分更新，只持久化有变化的字段
+func (h *TaskHandler) PatchTask(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
+		return
+	}
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	contentType := c.ContentType()
+	if contentType != mergePatchContentType && contentType != jsonPatchContentType {
+		c.Error(apperrors.NewUnsupportedMediaTypeError(apperrors.CodeUnsupportedMediaType, nil).With("types", mergePatchContentType+", "+jsonPatchContentType))
+		return
+	}
+	body, err := readBody(c)
+	if err != nil {
+		c.Error(invalidBody(apperrors.CodeReadBodyFailed, err))
+		return
+	}
+	ifMatch, ok := h.getIfMatchVersion(c, id, userID)
+	if !ok {
+		return
+	}
+
+	unlock, ok := h.lockTask(c, id)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	ctx := c.Request.Context()
+	for attempt := 0; attempt < maxPatchAttempts; attempt++ {
+		current, err := h.Store.GetTaskByID(ctx, id, userID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if ifMatch != 0 && current.Version != ifMatch {
+			h.respondVersionConflict(c, store.ErrVersionConflict, id, userID)
+			return
+		}
+		doc, err := applyTaskPatch(contentType, current, body)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		task := *current
+		task.Title, task.Content, task.Done = doc.Title, doc.Content, doc.Done
+		task.DueAt, task.Priority, task.Tags = doc.DueAt, doc.Priority, doc.Tags
+		task.ProjectID, task.Status = doc.ProjectID, doc.Status
+		fields := models.ChangedFields(current, &task)
+		if len(fields) == 0 {
+			c.Header("ETag", taskETag(current.Version))
+			c.JSON(http.StatusOK, newTaskResponse(current))
+			return
+		}
+		err = h.Store.PatchTask(ctx, &task, fields)
+		if errors.Is(err, store.ErrVersionConflict) && ifMatch == 0 {
+			//补丁是基于旧版本计算的，重新读取后再试
+			continue
+		}
+		if err != nil {
+			h.respondVersionConflict(c, err, id, userID)
+			return
+		}
+		c.Header("ETag", taskETag(task.Version))
+		c.JSON(http.StatusOK, newTaskResponse(&task))
+		return
+	}
+	c.Error(apperrors.NewConfilictError(apperrors.CodeTaskBusy, store.ErrVersionConflict))
+}
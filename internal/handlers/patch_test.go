@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestApplyTaskPatch 测试两种补丁格式以及对只读字段和校验的处理
+func TestApplyTaskPatch(t *testing.T) {
+	task := &models.Task{ID: 1, Title: "Title", Content: "Content", Done: false}
+	patchedFields := func(doc *TaskRequest) []string {
+		patched := doc.toTask()
+		return models.ChangedFields(task, &patched)
+	}
+
+	// merge patch 只修改 done，标题和内容保持不变
+	doc, err := applyTaskPatch(mergePatchContentType, task, []byte(`{"done":true}`))
+	assert.NoError(t, err)
+	assert.Equal(t, TaskRequest{Title: "Title", Content: "Content", Done: true}, *doc)
+	assert.Equal(t, []string{"done"}, patchedFields(doc))
+
+	// merge patch 设置标签和优先级
+	doc, err = applyTaskPatch(mergePatchContentType, task, []byte(`{"tags":["backend"],"priority":3}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"priority", "tags"}, patchedFields(doc))
+
+	doc, err = applyTaskPatch(mergePatchContentType, task, []byte(`{"done":true}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"done"}, patchedFields(doc))
+
+	// json patch
+	doc, err = applyTaskPatch(jsonPatchContentType, task, []byte(`[{"op":"replace","path":"/title","value":"New"}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, "New", doc.Title)
+	assert.Equal(t, []string{"title"}, patchedFields(doc))
+
+	cases := []struct {
+		name        string
+		contentType string
+		patch       string
+		status      int
+	}{
+		{"只读字段", mergePatchContentType, `{"user_id":2}`, http.StatusUnprocessableEntity},
+		{"删除标题后校验失败", mergePatchContentType, `{"title":null}`, http.StatusUnprocessableEntity},
+		{"优先级超出范围", mergePatchContentType, `{"priority":9}`, http.StatusUnprocessableEntity},
+		{"test 操作未通过", jsonPatchContentType, `[{"op":"test","path":"/done","value":true}]`, http.StatusConflict},
+		{"格式错误", jsonPatchContentType, `{"op":"replace"}`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		_, err := applyTaskPatch(tc.contentType, task, []byte(tc.patch))
+		var appErr *apperrors.AppError
+		if assert.True(t, errors.As(err, &appErr), tc.name) {
+			assert.Equal(t, tc.status, appErr.Code, tc.name)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/i18n"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+// ProfileRequest 定义修改用户资料的JSON结构，只修改提供了的字段
+type ProfileRequest struct {
+	DisplayName *string `json:"display_name" binding:"omitempty,max=100"`
+	Timezone    *string `json:"timezone"`
+	Locale      *string `json:"locale"`
+	WeekStart   *int    `json:"week_start" binding:"omitempty,min=0,max=6"`
+	DefaultSort *string `json:"default_sort"`
+}
+
+// getProfile 读取当前用户的资料，日期相关的功能都按资料中的时区和每周第一天计算
+func getProfile(c *gin.Context, s store.Store) (*models.User, bool) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return nil, false
+	}
+	user, err := s.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+	return user, true
+}
+
+// GetMe 返回当前用户的资料
+func (h *UserHandler) GetMe(c *gin.Context) {
+	user, ok := getProfile(c, h.Store)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// PatchMe 修改当前用户的资料
+func (h *UserHandler) PatchMe(c *gin.Context) {
+	var req ProfileRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(invalidBody(apperrors.CodeInvalidBody, err))
+		return
+	}
+	user, ok := getProfile(c, h.Store)
+	if !ok {
+		return
+	}
+	if req.DisplayName != nil {
+		user.DisplayName = *req.DisplayName
+	}
+	if req.Timezone != nil {
+		if _, err := models.LoadTimezone(*req.Timezone); err != nil {
+			c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidTimezone, err))
+			return
+		}
+		user.Timezone = *req.Timezone
+	}
+	if req.Locale != nil {
+		if !i18n.Supported(*req.Locale) {
+			c.Error(apperrors.NewBadRequestError(apperrors.CodeUnsupportedLocale, nil).With("locale", *req.Locale))
+			return
+		}
+		user.Locale = *req.Locale
+	}
+	if req.WeekStart != nil {
+		user.WeekStart = *req.WeekStart
+	}
+	if req.DefaultSort != nil {
+		if _, ok := store.SortOrders[*req.DefaultSort]; !ok {
+			c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidSort, nil).With("sort", *req.DefaultSort))
+			return
+		}
+		user.DefaultSort = *req.DefaultSort
+	}
+	if err := h.Store.UpdateUserProfile(c.Request.Context(), user); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
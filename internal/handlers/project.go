@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+// ProjectHandler 包含项目、工作流和看板相关的 handler
+type ProjectHandler struct {
+	Store store.Store
+}
+
+// NewProjectHandler 创建一个新的 ProjectHandler
+func NewProjectHandler(s store.Store) *ProjectHandler {
+	return &ProjectHandler{Store: s}
+}
+
+// ProjectRequest 定义创建/修改项目的JSON结构，不提供工作流时使用默认工作流
+type ProjectRequest struct {
+	Name     string           `json:"name" binding:"required,max=100"`
+	Workflow *models.Workflow `json:"workflow"`
+}
+
+// bindProjectRequest 解析请求并检查工作流
+func bindProjectRequest(c *gin.Context) (*models.Project, bool) {
+	var req ProjectRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(invalidBody(apperrors.CodeInvalidBody, err))
+		return nil, false
+	}
+	workflow := models.DefaultWorkflow()
+	if req.Workflow != nil {
+		workflow = *req.Workflow
+	}
+	if err := workflow.Validate(); err != nil {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeProjectInvalidWorkflow, err).With("detail", err))
+		return nil, false
+	}
+	return &models.Project{Name: req.Name, Workflow: workflow}, true
+}
+
+func getProjectID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *ProjectHandler) GetProjects(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	projects, err := h.Store.GetProjects(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newProjectResponses(projects))
+}
+
+func (h *ProjectHandler) CreateProject(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	project, ok := bindProjectRequest(c)
+	if !ok {
+		return
+	}
+	project.UserID = userID
+	if err := h.Store.CreateProject(c.Request.Context(), project); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, newProjectResponse(project))
+}
+
+func (h *ProjectHandler) GetProject(c *gin.Context) {
+	id, ok := getProjectID(c)
+	if !ok {
+		return
+	}
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	project, err := h.Store.GetProject(c.Request.Context(), id, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newProjectResponse(project))
+}
+
+// UpdateProject 修改项目，仍有任务处于被移除的状态时返回409
+func (h *ProjectHandler) UpdateProject(c *gin.Context) {
+	id, ok := getProjectID(c)
+	if !ok {
+		return
+	}
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	project, ok := bindProjectRequest(c)
+	if !ok {
+		return
+	}
+	project.ID, project.UserID = id, userID
+	if err := h.Store.UpdateProject(c.Request.Context(), project); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newProjectResponse(project))
+}
+
+// DeleteProject 删除项目，项目中的任务保留并回到默认工作流
+func (h *ProjectHandler) DeleteProject(c *gin.Context) {
+	id, ok := getProjectID(c)
+	if !ok {
+		return
+	}
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	if err := h.Store.DeleteProject(c.Request.Context(), id, userID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetBoard 按工作流的状态把项目中的任务分组为看板的列
+func (h *ProjectHandler) GetBoard(c *gin.Context) {
+	id, ok := getProjectID(c)
+	if !ok {
+		return
+	}
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	project, err := h.Store.GetProject(ctx, id, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tasks, err := h.Store.GetProjectTasks(ctx, id, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"project": newProjectResponse(project), "columns": boardColumns(project.Workflow, tasks)})
+}
+
+// boardColumns 按工作流中状态的顺序生成看板的列，列内保持任务原有的顺序
+func boardColumns(workflow models.Workflow, tasks []models.Task) []BoardColumnResponse {
+	columns := make([]BoardColumnResponse, len(workflow.Statuses))
+	index := make(map[string]int, len(workflow.Statuses))
+	for i, status := range workflow.Statuses {
+		columns[i] = BoardColumnResponse{WorkflowStatus: status, Tasks: []TaskResponse{}}
+		index[status.Key] = i
+	}
+	for _, task := range tasks {
+		if i, ok := index[task.Status]; ok {
+			columns[i].Tasks = append(columns[i].Tasks, *newTaskResponse(&task))
+			columns[i].Count++
+		}
+	}
+	return columns
+}
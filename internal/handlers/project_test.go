@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newProjectRouter(mockStore *store.MockStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	projectHandler := NewProjectHandler(mockStore)
+	router := gin.New()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
+	router.POST("/projects", projectHandler.CreateProject)
+	router.GET("/projects/:id", projectHandler.GetProject)
+	router.PUT("/projects/:id", projectHandler.UpdateProject)
+	router.DELETE("/projects/:id", projectHandler.DeleteProject)
+	router.GET("/projects/:id/board", projectHandler.GetBoard)
+	return router
+}
+
+func serveProject(router *gin.Engine, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestCreateProject 测试不提供工作流时使用默认工作流，不合法的工作流返回400
+func TestCreateProject(t *testing.T) {
+	// ARRANGE
+	mockStore := new(store.MockStore)
+	mockStore.On("CreateProject", mock.Anything, mock.MatchedBy(func(p *models.Project) bool {
+		return p.UserID == 1 && p.Name == "Launch" && len(p.Workflow.Statuses) == 3
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Project).ID = 5
+	}).Return(nil).Once()
+	router := newProjectRouter(mockStore)
+
+	// ACT & ASSERT
+	w := serveProject(router, http.MethodPost, "/projects", `{"name":"Launch"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":5`)
+	assert.Contains(t, w.Body.String(), `"key":"in_progress"`)
+	assert.NotContains(t, w.Body.String(), "user_id", "不返回项目所有者")
+
+	w = serveProject(router, http.MethodPost, "/projects", `{"name":"Bad","workflow":{"statuses":[{"key":"todo","name":"To do","category":"todo","wip_limit":0}]}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"project.invalid_workflow"`)
+	mockStore.AssertExpectations(t)
+}
+
+// TestUpdateAndDeleteProject 测试修改和删除项目的各种结果
+func TestUpdateAndDeleteProject(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		setup  func(ms *store.MockStore)
+		status int
+		code   string
+	}{
+		{"修改成功", http.MethodPut, "/projects/5", `{"name":"Renamed"}`, func(ms *store.MockStore) {
+			ms.On("UpdateProject", mock.Anything, mock.MatchedBy(func(p *models.Project) bool {
+				return p.ID == 5 && p.UserID == 1 && p.Name == "Renamed"
+			})).Return(nil)
+		}, http.StatusOK, ""},
+		{"状态仍在使用", http.MethodPut, "/projects/5", `{"name":"Renamed"}`, func(ms *store.MockStore) {
+			ms.On("UpdateProject", mock.Anything, mock.Anything).Return(store.ErrStatusInUse)
+		}, http.StatusConflict, "project.status_in_use"},
+		{"删除成功", http.MethodDelete, "/projects/5", "", func(ms *store.MockStore) {
+			ms.On("DeleteProject", mock.Anything, 5, 1).Return(nil)
+		}, http.StatusNoContent, ""},
+		{"删除不存在的项目", http.MethodDelete, "/projects/6", "", func(ms *store.MockStore) {
+			ms.On("DeleteProject", mock.Anything, 6, 1).Return(store.ErrNotFound)
+		}, http.StatusNotFound, "resource.not_found"},
+		{"无效的ID", http.MethodGet, "/projects/abc", "", func(ms *store.MockStore) {}, http.StatusBadRequest, "request.invalid_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := new(store.MockStore)
+			tt.setup(mockStore)
+			w := serveProject(newProjectRouter(mockStore), tt.method, tt.target, tt.body)
+			assert.Equal(t, tt.status, w.Code)
+			if tt.code != "" {
+				assert.Contains(t, w.Body.String(), `"code":"`+tt.code+`"`)
+			}
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
+
+// TestBoardColumns 测试按工作流的顺序分列，列内保持原有顺序，不在工作流中的状态不显示
+func TestBoardColumns(t *testing.T) {
+	tasks := []models.Task{
+		{ID: 1, Status: "done"},
+		{ID: 2, Status: "todo"},
+		{ID: 3, Status: "archived"},
+		{ID: 4, Status: "todo"},
+	}
+
+	columns := boardColumns(models.DefaultWorkflow(), tasks)
+
+	assert.Len(t, columns, 3)
+	assert.Equal(t, []string{"todo", "in_progress", "done"}, []string{columns[0].Key, columns[1].Key, columns[2].Key})
+	assert.Equal(t, 2, columns[0].Count)
+	assert.Equal(t, []int{2, 4}, []int{columns[0].Tasks[0].ID, columns[0].Tasks[1].ID})
+	assert.Equal(t, 0, columns[1].Count)
+	assert.NotNil(t, columns[1].Tasks, "空列返回 [] 而不是 null")
+	assert.Equal(t, 1, columns[2].Count)
+}
+
+// TestGetBoard 测试看板返回项目和分好的列
+func TestGetBoard(t *testing.T) {
+	// ARRANGE
+	mockStore := new(store.MockStore)
+	mockStore.On("GetProject", mock.Anything, 5, 1).Return(&models.Project{ID: 5, UserID: 1, Name: "Launch", Workflow: models.DefaultWorkflow()}, nil)
+	mockStore.On("GetProjectTasks", mock.Anything, 5, 1).Return([]models.Task{{ID: 2, UserID: 1, Title: "write spec", Status: "in_progress"}}, nil)
+
+	// ACT
+	w := serveProject(newProjectRouter(mockStore), http.MethodGet, "/projects/5/board", "")
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"Launch"`)
+	assert.Contains(t, w.Body.String(), `"key":"in_progress","name":"In progress","category":"in_progress","count":1`)
+	mockStore.AssertExpectations(t)
+}
+
+// TestPatchTask_WIPLimitExceeded 测试移入已满的列时返回409
+func TestPatchTask_WIPLimitExceeded(t *testing.T) {
+	// ARRANGE
+	gin.SetMode(gin.TestMode)
+	mockStore := new(store.MockStore)
+	projectID := 5
+	mockStore.On("GetTaskByID", mock.Anything, 2, 1).Return(&models.Task{ID: 2, UserID: 1, Title: "write spec", ProjectID: &projectID, Status: "todo", Version: 1}, nil)
+	mockStore.On("PatchTask", mock.Anything, mock.MatchedBy(func(task *models.Task) bool {
+		return task.Status == "in_progress"
+	}), []string{"status"}).Return(store.ErrWIPLimitExceeded)
+	taskHandler := NewTaskHandler(mockStore, nil)
+	router := gin.New()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
+	router.PATCH("/tasks/:id", taskHandler.PatchTask)
+
+	// ACT
+	req := httptest.NewRequest(http.MethodPatch, "/tasks/2", strings.NewReader(`{"status":"in_progress"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// ASSERT
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, middleware.ProblemContentType, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"code":"project.wip_limit_exceeded"`)
+	mockStore.AssertExpectations(t)
+}
@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+)
+
+// TaskResponse API 返回的任务，与数据库模型分离：数据库中增加或修改列不会改变 API
+type TaskResponse struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Content     string     `json:"content"`
+	Done        bool       `json:"done"`
+	ProjectID   *int       `json:"project_id"`
+	Status      string     `json:"status"`
+	Priority    int        `json:"priority"`
+	Tags        []string   `json:"tags"`
+	DueAt       *time.Time `json:"due_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	Position    *string    `json:"position"`
+	IsBlocked   bool       `json:"is_blocked"`
+	Unblocked   []int      `json:"unblocked,omitempty"` //本次完成后不再被阻塞的任务
+	Version     int        `json:"version"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"` //只有回收站中的任务才有
+}
+
+func newTaskResponse(task *models.Task) *TaskResponse {
+	tags := []string(task.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	return &TaskResponse{
+		ID:          task.ID,
+		Title:       task.Title,
+		Content:     task.Content,
+		Done:        task.Done,
+		ProjectID:   task.ProjectID,
+		Status:      task.Status,
+		Priority:    task.Priority,
+		Tags:        tags,
+		DueAt:       task.DueAt,
+		CompletedAt: task.CompletedAt,
+		Position:    task.Position,
+		IsBlocked:   task.IsBlocked,
+		Unblocked:   task.Unblocked,
+		Version:     task.Version,
+		CreatedAt:   task.CreatedAt,
+		UpdatedAt:   task.UpdatedAt,
+		DeletedAt:   task.DeletedAt,
+	}
+}
+
+func newTaskResponses(tasks []models.Task) []TaskResponse {
+	result := make([]TaskResponse, len(tasks))
+	for i := range tasks {
+		result[i] = *newTaskResponse(&tasks[i])
+	}
+	return result
+}
+
+// UserResponse API 返回的用户资料，不包含密码哈希等内部字段
+type UserResponse struct {
+	ID          int       `json:"id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	Timezone    string    `json:"timezone"`
+	Locale      string    `json:"locale"`
+	WeekStart   int       `json:"week_start"`
+	DefaultSort string    `json:"default_sort"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func newUserResponse(user *models.User) *UserResponse {
+	return &UserResponse{
+		ID:          user.ID,
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Timezone:    user.Timezone,
+		Locale:      user.Locale,
+		WeekStart:   user.WeekStart,
+		DefaultSort: user.DefaultSort,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+	}
+}
+
+// BoardColumnResponse 看板中的一列
+type BoardColumnResponse struct {
+	models.WorkflowStatus
+	Count int            `json:"count"`
+	Tasks []TaskResponse `json:"tasks"`
+}
+
+// DependencyGraphResponse GET /tasks/:id/dependencies 返回的依赖图
+type DependencyGraphResponse struct {
+	TaskID int                  `json:"task_id"`
+	Nodes  []TaskResponse       `json:"nodes"`
+	Edges  []DependencyResponse `json:"edges"`
+}
+
+// SearchResultResponse 全文搜索的一条结果，高亮片段中匹配的词用 <mark></mark> 包裹
+type SearchResultResponse struct {
+	Task           TaskResponse `json:"task"`
+	Rank           float64      `json:"rank"`
+	TitleSnippet   string       `json:"title_snippet"`
+	ContentSnippet string       `json:"content_snippet"`
+	CommentSnippet *string      `json:"comment_snippet,omitempty"`
+}
+
+// BulkResultResponse 单个批量操作的执行结果
+type BulkResultResponse struct {
+	Index  int           `json:"index"`
+	Op     string        `json:"op"`
+	ID     int           `json:"id,omitempty"`
+	Status int           `json:"status"`
+	Task   *TaskResponse `json:"task,omitempty"`
+	Code   string        `json:"code,omitempty"` //稳定的错误码
+	Error  string        `json:"error,omitempty"`
+}
+
+// ProjectResponse API 返回的项目
+type ProjectResponse struct {
+	ID        int             `json:"id"`
+	Name      string          `json:"name"`
+	Workflow  models.Workflow `json:"workflow"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+func newProjectResponse(project *models.Project) *ProjectResponse {
+	return &ProjectResponse{
+		ID:        project.ID,
+		Name:      project.Name,
+		Workflow:  project.Workflow,
+		CreatedAt: project.CreatedAt,
+		UpdatedAt: project.UpdatedAt,
+	}
+}
+
+func newProjectResponses(projects []models.Project) []ProjectResponse {
+	result := make([]ProjectResponse, len(projects))
+	for i := range projects {
+		result[i] = *newProjectResponse(&projects[i])
+	}
+	return result
+}
+
+// CommentResponse API 返回的评论，user_id 和 username 是评论的作者
+type CommentResponse struct {
+	ID        int              `json:"id"`
+	TaskID    int              `json:"task_id"`
+	UserID    int              `json:"user_id"`
+	Username  string           `json:"username"`
+	Content   string           `json:"content"`
+	Mentions  []models.Mention `json:"mentions"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+func newCommentResponse(comment *models.Comment) *CommentResponse {
+	mentions := comment.Mentions
+	if mentions == nil {
+		mentions = []models.Mention{}
+	}
+	return &CommentResponse{
+		ID:        comment.ID,
+		TaskID:    comment.TaskID,
+		UserID:    comment.UserID,
+		Username:  comment.Username,
+		Content:   comment.Content,
+		Mentions:  mentions,
+		CreatedAt: comment.CreatedAt,
+		UpdatedAt: comment.UpdatedAt,
+	}
+}
+
+func newCommentResponses(comments []models.Comment) []CommentResponse {
+	result := make([]CommentResponse, len(comments))
+	for i := range comments {
+		result[i] = *newCommentResponse(&comments[i])
+	}
+	return result
+}
+
+// ViewResponse API 返回的视图，内置视图只有 key，没有 id 和时间
+type ViewResponse struct {
+	ID        int        `json:"id,omitempty"`
+	Key       string     `json:"key,omitempty"`
+	Name      string     `json:"name"`
+	Filter    string     `json:"filter"`
+	Sort      string     `json:"sort"`
+	Pinned    bool       `json:"pinned"`
+	Builtin   bool       `json:"builtin"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+func newViewResponse(view *models.SavedView) *ViewResponse {
+	resp := &ViewResponse{
+		ID:      view.ID,
+		Key:     view.Key,
+		Name:    view.Name,
+		Filter:  view.Filter,
+		Sort:    view.Sort,
+		Pinned:  view.Pinned,
+		Builtin: view.Builtin,
+	}
+	if !view.Builtin {
+		resp.CreatedAt, resp.UpdatedAt = &view.CreatedAt, &view.UpdatedAt
+	}
+	return resp
+}
+
+func newViewResponses(views []models.SavedView) []ViewResponse {
+	result := make([]ViewResponse, len(views))
+	for i := range views {
+		result[i] = *newViewResponse(&views[i])
+	}
+	return result
+}
+
+// TimeEntryResponse API 返回的时间记录
+type TimeEntryResponse struct {
+	ID              int        `json:"id"`
+	TaskID          int        `json:"task_id"`
+	StartedAt       time.Time  `json:"started_at"`
+	StoppedAt       *time.Time `json:"stopped_at"`
+	DurationSeconds int64      `json:"duration_seconds"` //运行中的计时器计算到当前时间
+	Note            string     `json:"note"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+func newTimeEntryResponse(entry *models.TimeEntry) *TimeEntryResponse {
+	if entry == nil {
+		return nil
+	}
+	return &TimeEntryResponse{
+		ID:              entry.ID,
+		TaskID:          entry.TaskID,
+		StartedAt:       entry.StartedAt,
+		StoppedAt:       entry.StoppedAt,
+		DurationSeconds: entry.DurationSeconds,
+		Note:            entry.Note,
+		CreatedAt:       entry.CreatedAt,
+	}
+}
+
+func newTimeEntryResponses(entries []models.TimeEntry) []TimeEntryResponse {
+	result := make([]TimeEntryResponse, len(entries))
+	for i := range entries {
+		result[i] = *newTimeEntryResponse(&entries[i])
+	}
+	return result
+}
+
+// RevisionResponse API 返回的任务历史版本
+type RevisionResponse struct {
+	TaskID        int        `json:"task_id"`
+	Revision      int        `json:"revision"`
+	Title         string     `json:"title"`
+	Content       string     `json:"content"`
+	Done          bool       `json:"done"`
+	DueAt         *time.Time `json:"due_at"`
+	Priority      int        `json:"priority"`
+	Tags          []string   `json:"tags"`
+	ProjectID     *int       `json:"project_id"`
+	Status        string     `json:"status"`
+	ChangedFields []string   `json:"changed_fields"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func newRevisionResponse(revision *models.TaskRevision) *RevisionResponse {
+	tags, changed := revision.Tags, revision.ChangedFields
+	if tags == nil {
+		tags = []string{}
+	}
+	if changed == nil {
+		changed = []string{}
+	}
+	return &RevisionResponse{
+		TaskID:        revision.TaskID,
+		Revision:      revision.Revision,
+		Title:         revision.Title,
+		Content:       revision.Content,
+		Done:          revision.Done,
+		DueAt:         revision.DueAt,
+		Priority:      revision.Priority,
+		Tags:          tags,
+		ProjectID:     revision.ProjectID,
+		Status:        revision.Status,
+		ChangedFields: changed,
+		CreatedAt:     revision.CreatedAt,
+	}
+}
+
+func newRevisionResponses(revisions []models.TaskRevision) []RevisionResponse {
+	result := make([]RevisionResponse, len(revisions))
+	for i := range revisions {
+		result[i] = *newRevisionResponse(&revisions[i])
+	}
+	return result
+}
+
+// DependencyResponse 一条依赖：blocker 完成之前 blocked 处于阻塞状态
+type DependencyResponse struct {
+	BlockerID int       `json:"blocker_id"`
+	BlockedID int       `json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newDependencyResponse(dep *models.Dependency) *DependencyResponse {
+	return &DependencyResponse{BlockerID: dep.BlockerID, BlockedID: dep.BlockedID, CreatedAt: dep.CreatedAt}
+}
+
+func newDependencyResponses(deps []models.Dependency) []DependencyResponse {
+	result := make([]DependencyResponse, len(deps))
+	for i := range deps {
+		result[i] = *newDependencyResponse(&deps[i])
+	}
+	return result
+}
+
+// StatsBucketResponse 一天或一周内创建和完成的任务数，date 为这一天或这一周的第一天
+type StatsBucketResponse struct {
+	Date      string `json:"date"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+}
+
+// StatsResponse GET /stats 返回的统计数据
+type StatsResponse struct {
+	From                     time.Time             `json:"from"`
+	To                       time.Time             `json:"to"`
+	Timezone                 string                `json:"timezone"`
+	Interval                 string                `json:"interval"`
+	Series                   []StatsBucketResponse `json:"series"`
+	Created                  int                   `json:"created"`
+	Completed                int                   `json:"completed"`
+	CompletionRate           float64               `json:"completion_rate"`
+	AvgTimeToCompleteSeconds *float64              `json:"avg_time_to_complete_seconds"` //没有完成任务时为空
+	CurrentStreak            int                   `json:"current_streak"`
+	LongestStreak            int                   `json:"longest_streak"`
+	Overdue                  int                   `json:"overdue"`
+	CompletedLate            int                   `json:"completed_late"`
+}
+
+func newStatsResponse(stats *models.Stats) *StatsResponse {
+	series := make([]StatsBucketResponse, len(stats.Series))
+	for i, b := range stats.Series {
+		series[i] = StatsBucketResponse{Date: b.Date, Created: b.Created, Completed: b.Completed}
+	}
+	return &StatsResponse{
+		From:                     stats.From,
+		To:                       stats.To,
+		Timezone:                 stats.Timezone,
+		Interval:                 stats.Interval,
+		Series:                   series,
+		Created:                  stats.Created,
+		Completed:                stats.Completed,
+		CompletionRate:           stats.CompletionRate,
+		AvgTimeToCompleteSeconds: stats.AvgTimeToCompleteSeconds,
+		CurrentStreak:            stats.CurrentStreak,
+		LongestStreak:            stats.LongestStreak,
+		Overdue:                  stats.Overdue,
+		CompletedLate:            stats.CompletedLate,
+	}
+}
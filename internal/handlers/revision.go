@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// getRevisionNumber 从路径或查询参数中解析版本号
+func getRevisionNumber(c *gin.Context, value string, name string) (int, bool) {
+	rev, err := strconv.Atoi(value)
+	if err != nil || rev < 1 {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeTaskInvalidRevision, err).With("field", name))
+		return 0, false
+	}
+	return rev, true
+}
+
+// GetTaskRevisions 返回任务的历史版本列表，最新的在前
+func (h *TaskHandler) GetTaskRevisions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
+		return
+	}
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	if _, err := h.Store.GetTaskByID(c.Request.Context(), id, userID); err != nil {
+		c.Error(err)
+		return
+	}
+	page, pageSize, opts, ok := getPagination(c)
+	if !ok {
+		return
+	}
+	revisions, total, err := h.Store.GetTaskRevisions(c.Request.Context(), id, opts)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, pageResponse(newRevisionResponses(revisions), total, page, pageSize))
+}
+
+// GetTaskRevision 返回单个历史版本
+func (h *TaskHandler) GetTaskRevision(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
+		return
+	}
+	rev, ok := getRevisionNumber(c, c.Param("rev"), "rev")
+	if !ok {
+		return
+	}
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	if _, err := h.Store.GetTaskByID(c.Request.Context(), id, userID); err != nil {
+		c.Error(err)
+		return
+	}
+	revision, err := h.Store.GetTaskRevision(c.Request.Context(), id, rev)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newRevisionResponse(revision))
+}
+
+// DiffTaskRevisions 比较 ?from= 和 ?to= 两个版本的字段差异
+func (h *TaskHandler) DiffTaskRevisions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
+		return
+	}
+	from, ok := getRevisionNumber(c, c.Query("from"), "from")
+	if !ok {
+		return
+	}
+	to, ok := getRevisionNumber(c, c.Query("to"), "to")
+	if !ok {
+		return
+	}
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	if _, err := h.Store.GetTaskByID(ctx, id, userID); err != nil {
+		c.Error(err)
+		return
+	}
+	fromRev, err := h.Store.GetTaskRevision(ctx, id, from)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	toRev, err := h.Store.GetTaskRevision(ctx, id, to)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":    from,
+		"to":      to,
+		"changes": fromRev.Diff(toRev),
+	})
+}
+
+// RestoreTaskRevision 把任务恢复到指定版本，恢复本身会产生一个新版本
+func (h *TaskHandler) RestoreTaskRevision(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
+		return
+	}
+	rev, ok := getRevisionNumber(c, c.Param("rev"), "rev")
+	if !ok {
+		return
+	}
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	//版本只来自 If-Match
+	version, ok := h.getIfMatchVersion(c, id, userID)
+	if !ok {
+		return
+	}
+
+	unlock, ok := h.lockTask(c, id)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	task := &models.Task{ID: id, UserID: userID, Version: version}
+	if err := h.Store.RestoreTaskRevision(c.Request.Context(), task, rev); err != nil {
+		h.respondVersionConflict(c, err, id, userID)
+		return
+	}
+	c.Header("ETag", taskETag(task.Version))
+	c.JSON(http.StatusOK, newTaskResponse(task))
+}
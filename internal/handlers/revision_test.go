@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newRevisionRouter(mockStore *store.MockStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	taskHandler := NewTaskHandler(mockStore, nil)
+	router := gin.New()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
+	router.GET("/tasks/:id/revisions", taskHandler.GetTaskRevisions)
+	router.GET("/tasks/:id/revisions/diff", taskHandler.DiffTaskRevisions)
+	router.GET("/tasks/:id/revisions/:rev", taskHandler.GetTaskRevision)
+	return router
+}
+
+// TestGetTaskRevisions 测试分页返回历史版本，分页参数传给 store
+func TestGetTaskRevisions(t *testing.T) {
+	// ARRANGE
+	mockStore := new(store.MockStore)
+	mockStore.On("GetTaskByID", mock.Anything, 5, 1).Return(&models.Task{ID: 5, UserID: 1}, nil)
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	mockStore.On("GetTaskRevisions", mock.Anything, 5, store.ListOptions{Limit: 2, Offset: 2}).Return([]models.TaskRevision{
+		{TaskID: 5, Revision: 2, Title: "second", ChangedFields: []string{"title"}, CreatedAt: created},
+		{TaskID: 5, Revision: 1, Title: "first", ChangedFields: []string{}, CreatedAt: created},
+	}, 4, nil)
+
+	// ACT
+	w := httptest.NewRecorder()
+	newRevisionRouter(mockStore).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks/5/revisions?page=2&page_size=2", nil))
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"total":4`)
+	assert.Contains(t, w.Body.String(), `"page":2`)
+	assert.Contains(t, w.Body.String(), `"revision":2`)
+	assert.Contains(t, w.Body.String(), `"changed_fields":["title"]`)
+	mockStore.AssertExpectations(t)
+}
+
+// TestGetTaskRevisions_Errors 测试别人的任务返回404，分页参数和版本号无效时返回400
+func TestGetTaskRevisions_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		status int
+		code   string
+	}{
+		{"别人的任务", "/tasks/9/revisions", http.StatusNotFound, "resource.not_found"},
+		{"页码无效", "/tasks/5/revisions?page=0", http.StatusBadRequest, "pagination.invalid_page"},
+		{"版本号无效", "/tasks/5/revisions/0", http.StatusBadRequest, "task.invalid_revision"},
+		{"版本不存在", "/tasks/5/revisions/8", http.StatusNotFound, "resource.not_found"},
+		{"缺少 from", "/tasks/5/revisions/diff?to=2", http.StatusBadRequest, "task.invalid_revision"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := new(store.MockStore)
+			mockStore.On("GetTaskByID", mock.Anything, 9, 1).Return(nil, store.ErrNotFound).Maybe()
+			mockStore.On("GetTaskByID", mock.Anything, 5, 1).Return(&models.Task{ID: 5, UserID: 1}, nil).Maybe()
+			mockStore.On("GetTaskRevision", mock.Anything, 5, 8).Return(nil, store.ErrNotFound).Maybe()
+
+			w := httptest.NewRecorder()
+			newRevisionRouter(mockStore).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Contains(t, w.Body.String(), `"code":"`+tt.code+`"`)
+			mockStore.AssertNotCalled(t, "GetTaskRevisions", mock.Anything, 9, mock.Anything)
+		})
+	}
+}
+
+// TestDiffTaskRevisions 测试两个版本之间的字段差异
+func TestDiffTaskRevisions(t *testing.T) {
+	// ARRANGE
+	mockStore := new(store.MockStore)
+	mockStore.On("GetTaskByID", mock.Anything, 5, 1).Return(&models.Task{ID: 5, UserID: 1}, nil)
+	mockStore.On("GetTaskRevision", mock.Anything, 5, 1).Return(&models.TaskRevision{TaskID: 5, Revision: 1, Title: "a", Content: "same"}, nil)
+	mockStore.On("GetTaskRevision", mock.Anything, 5, 3).Return(&models.TaskRevision{TaskID: 5, Revision: 3, Title: "b", Content: "same", Done: true}, nil)
+
+	// ACT
+	w := httptest.NewRecorder()
+	newRevisionRouter(mockStore).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks/5/revisions/diff?from=1&to=3", nil))
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"from": 1,
+		"to": 3,
+		"changes": [
+			{"field": "title", "from": "a", "to": "b"},
+			{"field": "done", "from": false, "to": true}
+		]
+	}`, w.Body.String())
+	mockStore.AssertExpectations(t)
+}
+
+func newRestoreRouter(mockStore *store.MockStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	taskHandler := NewTaskHandler(mockStore, nil)
+	router := gin.New()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
+	router.POST("/tasks/:id/revisions/:rev/restore", taskHandler.RestoreTaskRevision)
+	return router
+}
+
+// TestRestoreTaskRevision 测试恢复时把 If-Match 中的版本交给 store，返回恢复后的任务和新的 ETag
+func TestRestoreTaskRevision(t *testing.T) {
+	// ARRANGE
+	mockStore := new(store.MockStore)
+	mockStore.On("RestoreTaskRevision", mock.Anything, mock.MatchedBy(func(task *models.Task) bool {
+		return task.ID == 5 && task.UserID == 1 && task.Version == 4
+	}), 2).Run(func(args mock.Arguments) {
+		task := args.Get(1).(*models.Task)
+		task.Title, task.Priority, task.Tags, task.Version = "same", models.PriorityUrgent, []string{"work", "q1"}, 5
+	}).Return(nil).Once()
+
+	// ACT
+	req := httptest.NewRequest(http.MethodPost, "/tasks/5/revisions/2/restore", nil)
+	req.Header.Set("If-Match", `"4"`)
+	w := httptest.NewRecorder()
+	newRestoreRouter(mockStore).ServeHTTP(w, req)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `"5"`, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), `"priority":4`)
+	assert.Contains(t, w.Body.String(), `"tags":["work","q1"]`)
+	mockStore.AssertExpectations(t)
+}
+
+// TestRestoreTaskRevision_Errors 测试 If-Match 不满足时返回412和当前内容，版本不存在时返回404
+func TestRestoreTaskRevision_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		ifMatch string
+		status  int
+		code    string
+	}{
+		{"版本冲突", "/tasks/5/revisions/2/restore", `"3"`, http.StatusPreconditionFailed, "task.version_conflict"},
+		{"If-Match 无效", "/tasks/5/revisions/2/restore", `W/"3"`, http.StatusPreconditionFailed, "precondition.invalid_if_match"},
+		{"版本不存在", "/tasks/5/revisions/8/restore", "", http.StatusNotFound, "resource.not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := new(store.MockStore)
+			mockStore.On("RestoreTaskRevision", mock.Anything, mock.Anything, 2).Return(store.ErrVersionConflict).Maybe()
+			mockStore.On("RestoreTaskRevision", mock.Anything, mock.Anything, 8).Return(store.ErrNotFound).Maybe()
+			mockStore.On("GetTaskByID", mock.Anything, 5, 1).Return(&models.Task{ID: 5, UserID: 1, Title: "current", Version: 4}, nil).Maybe()
+
+			req := httptest.NewRequest(http.MethodPost, tt.target, nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			newRestoreRouter(mockStore).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Contains(t, w.Body.String(), `"code":"`+tt.code+`"`)
+			if tt.status == http.StatusPreconditionFailed && tt.ifMatch == `"3"` {
+				assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+				assert.Contains(t, w.Body.String(), `"title":"current"`)
+			}
+		})
+	}
+}
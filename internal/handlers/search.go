@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/gin-gonic/gin"
+)
+
+// SearchTasks 全文搜索任务标题、内容和评论
+// 支持前缀匹配（repo 可以匹配 report）和双引号短语（"release notes"）
+func (h *TaskHandler) SearchTasks(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeSearchQueryRequired, nil))
+		return
+	}
+	page, pageSize, opts, ok := getPagination(c)
+	if !ok {
+		return
+	}
+	results, total, err := h.Store.SearchTasks(c.Request.Context(), userID, q, opts)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	items := make([]SearchResultResponse, len(results))
+	for i, r := range results {
+		items[i] = SearchResultResponse{
+			Task:           *newTaskResponse(&r.Task),
+			Rank:           r.Rank,
+			TitleSnippet:   r.TitleSnippet,
+			ContentSnippet: r.ContentSnippet,
+			CommentSnippet: r.CommentSnippet,
+		}
+	}
+	c.JSON(http.StatusOK, pageResponse(items, total, page, pageSize))
+}
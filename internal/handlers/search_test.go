@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newSearchRouter(mockStore *store.MockStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	taskHandler := NewTaskHandler(mockStore, nil)
+	router := gin.New()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
+	router.GET("/tasks/search", taskHandler.SearchTasks)
+	return router
+}
+
+// TestSearchTasks 测试搜索词和分页参数交给 store，评论中的匹配片段出现在结果中
+func TestSearchTasks(t *testing.T) {
+	// ARRANGE
+	mockStore := new(store.MockStore)
+	comment := "see the <mark>report</mark>"
+	mockStore.On("SearchTasks", mock.Anything, 1, "report", store.ListOptions{Limit: 2, Offset: 2}).Return([]models.SearchResult{
+		{Task: models.Task{ID: 3, UserID: 1, Title: "Quarterly report", Version: 1}, Rank: 0.5,
+			TitleSnippet: "Quarterly <mark>report</mark>"},
+		{Task: models.Task{ID: 4, UserID: 1, Title: "Draft", Version: 1}, Rank: 0.1,
+			TitleSnippet: "Draft", CommentSnippet: &comment},
+	}, 5, nil).Once()
+
+	// ACT
+	w := httptest.NewRecorder()
+	newSearchRouter(mockStore).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks/search?q=+report+&page=2&page_size=2", nil))
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Items []SearchResultResponse `json:"items"`
+		Total int                    `json:"total"`
+		Page  int                    `json:"page"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 5, resp.Total)
+	assert.Equal(t, 2, resp.Page)
+	require.Len(t, resp.Items, 2)
+	assert.Equal(t, 3, resp.Items[0].Task.ID)
+	assert.Equal(t, "Quarterly <mark>report</mark>", resp.Items[0].TitleSnippet)
+	assert.Nil(t, resp.Items[0].CommentSnippet)
+	require.NotNil(t, resp.Items[1].CommentSnippet)
+	assert.Equal(t, comment, *resp.Items[1].CommentSnippet)
+	mockStore.AssertExpectations(t)
+}
+
+// TestSearchTasks_BadRequest 测试缺少搜索词或分页参数无效时返回400，不查询 store
+func TestSearchTasks_BadRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		code   string
+	}{
+		{"缺少搜索词", "/tasks/search", "task.search_query_required"},
+		{"搜索词为空白", "/tasks/search?q=+++", "task.search_query_required"},
+		{"页码无效", "/tasks/search?q=report&page=0", "pagination.invalid_page"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := new(store.MockStore)
+			w := httptest.NewRecorder()
+			newSearchRouter(mockStore).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), `"code":"`+tt.code+`"`)
+			mockStore.AssertNotCalled(t, "SearchTasks", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+// StatsHandler 包含统计相关的 handler
+type StatsHandler struct {
+	Store store.Store
+}
+
+// NewStatsHandler 创建一个新的 StatsHandler
+func NewStatsHandler(s store.Store) *StatsHandler {
+	return &StatsHandler{Store: s}
+}
+
+// GetStats 返回时间范围内的完成情况，支持 ?from=&to=&tz=&interval=day|week
+func (h *StatsHandler) GetStats(c *gin.Context) {
+	user, ok := getProfile(c, h.Store)
+	if !ok {
+		return
+	}
+	interval := c.DefaultQuery("interval", models.StatsByDay)
+	maxDays := maxDayStatsDays
+	switch interval {
+	case models.StatsByDay:
+	case models.StatsByWeek:
+		maxDays = maxWeekStatsDays
+	default:
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidInterval, nil))
+		return
+	}
+	from, to, loc, ok := getTimeRange(c, user.Location(), maxDays)
+	if !ok {
+		return
+	}
+	stats, err := h.Store.GetStats(c.Request.Context(), user.ID, store.StatsQuery{
+		From: from, To: to, Interval: interval, Location: loc, WeekStart: time.Weekday(user.WeekStart),
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newStatsResponse(stats))
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestGetStats_Range 测试统计的时间范围按 interval 限制最长天数，时区必须是 IANA 时区名
+func TestGetStats_Range(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		code   string
+	}{
+		{"按天一整年", "?from=2024-01-01&to=2024-12-31", http.StatusOK, ""},
+		{"按天超过366天", "?from=2024-01-01&to=2025-01-01", http.StatusBadRequest, "time.invalid_range"},
+		{"按周五年", "?from=2020-01-01&to=2024-12-31&interval=week", http.StatusOK, ""},
+		{"按周超过五年", "?from=2019-01-01&to=2024-12-31&interval=week", http.StatusBadRequest, "time.invalid_range"},
+		{"全部日期", "?from=0001-01-01&to=9999-12-31", http.StatusBadRequest, "time.invalid_range"},
+		{"from 晚于 to", "?from=2024-02-01&to=2024-01-01", http.StatusBadRequest, "time.invalid_range"},
+		{"服务器时区", "?tz=Local", http.StatusBadRequest, "time.invalid_tz"},
+		{"时区名大小写不同", "?tz=utc", http.StatusBadRequest, "time.invalid_tz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// ARRANGE
+			gin.SetMode(gin.TestMode)
+			mockStore := new(store.MockStore)
+			mockStore.On("GetUserByID", mock.Anything, 1).Return(&models.User{ID: 1, Timezone: "UTC", WeekStart: 1}, nil)
+			mockStore.On("GetStats", mock.Anything, 1, mock.Anything).Return(&models.Stats{}, nil).Maybe()
+			statsHandler := NewStatsHandler(mockStore)
+			router := gin.New()
+			router.Use(middleware.ErrorMiddleware(), withUser(1))
+			router.GET("/stats", statsHandler.GetStats)
+
+			// ACT
+			req := httptest.NewRequest(http.MethodGet, "/stats"+tt.query, nil)
+			req.Header.Set("Accept-Language", "en")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// ASSERT
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+			if tt.status == http.StatusBadRequest {
+				assert.Contains(t, w.Body.String(), `"code":"`+tt.code+`"`)
+				mockStore.AssertNotCalled(t, "GetStats", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
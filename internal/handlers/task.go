@@ -12,6 +12,7 @@ import (
 	
 
 	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/filter"
 	"github.com/HywlEch/Todo_list/internal/models"
 	"github.com/HywlEch/Todo_list/internal/store"
 	"github.com/gin-gonic/gin"
@@ -31,27 +32,56 @@ func NewTaskHandler(s store.Store, rs *redsync.Redsync) *TaskHandler {
 	}
 }
 
+// TaskRequest 定义创建/替换任务的JSON结构，也是 PATCH 时补丁作用的文档
+// 只包含客户端可以写入的字段，id、user_id、version 等只读字段出现在请求体中时返回400
+type TaskRequest struct {
+	Title     string     `json:"title" binding:"required,notblank,max=200"`
+	Content   string     `json:"content" binding:"max=20000"`
+	Done      bool       `json:"done"`
+	DueAt     *time.Time `json:"due_at"`
+	Priority  int        `json:"priority" binding:"min=0,max=4"`
+	Tags      []string   `json:"tags" binding:"max=20,dive,notblank,max=50"`
+	ProjectID *int       `json:"project_id" binding:"omitempty,min=1"`
+	Status    string     `json:"status" binding:"max=32"`
+}
+
+// toTask 转换为任务模型
+func (r *TaskRequest) toTask() models.Task {
+	return models.Task{
+		Title:     r.Title,
+		Content:   r.Content,
+		Done:      r.Done,
+		DueAt:     r.DueAt,
+		Priority:  r.Priority,
+		Tags:      r.Tags,
+		ProjectID: r.ProjectID,
+		Status:    r.Status,
+	}
+}
+
 //辅助函数 从Gin上下文中安全的获取userID
 func getUserIDFromContext(c *gin.Context)(int, bool){
-	userIDAny, ok := c.Get("userID")
+	//AuthMiddleware 以 "user_id" 为键写入上下文
+	userIDAny, ok := c.Get("user_id")
 	if !ok {
-		c.Error(apperrors.NewUnauthorizedError("用户ID未找到", nil))
+		c.Error(apperrors.NewUnauthorizedError(apperrors.CodeAuthUnauthenticated, nil))
 		return 0, false
 	}
-	userID, OK := userIDAny.(int)
+	userID, ok := userIDAny.(int)
 	if !ok {
-		c.Error(apperrors.NewInternalServerError("ID类型错误", nil))
+		c.Error(apperrors.NewInternalServerError("", nil))
 		return 0, false
 	}
-	return userID, OK
+	return userID, true
 }
 
 func (h *TaskHandler) CreateTask(c *gin.Context) {
-	var task models.Task
-	if err := c.ShouldBindJSON(&task); err != nil {
-		c.Error(apperrors.NewBadRequestError("输入格式错误", err))
+	var req TaskRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(invalidBody(apperrors.CodeInvalidBody, err))
 		return
 	}
+	task := req.toTask()
 	userID, ok := getUserIDFromContext(c)
 	if !ok {
 		return
@@ -65,26 +95,50 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 	go h.sendNotification(&task)
 
 	log.Printf("Created task with ID %d", task.ID)
-	c.JSON(http.StatusCreated, task)
+	c.JSON(http.StatusCreated, newTaskResponse(&task))
 }
 
+// GetTasks 返回任务列表，支持 ?filter= 和 ?sort=，没有 sort 时使用用户资料中的默认排序
 func (h *TaskHandler) GetTasks(c *gin.Context) {
-	userID, ok := getUserIDFromContext(c)
+	user, ok := getProfile(c, h.Store)
 	if !ok {
 		return
 	}
-	tasks, err := h.Store.GetTasks(c.Request.Context(),userID)
+	q := store.TaskQuery{Sort: c.DefaultQuery("sort", user.DefaultSort)}
+	if _, ok := store.SortOrders[q.Sort]; !ok {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidSort, nil).With("sort", q.Sort))
+		return
+	}
+	//带 ?filter= 时使用过滤查询语言，相对时间按用户的时区计算
+	if expr := c.Query("filter"); expr != "" {
+		node, err := filter.ParseWithWeekStart(expr, user.Now(), time.Weekday(user.WeekStart))
+		if err != nil {
+			c.Error(filter.AppError(err))
+			return
+		}
+		q.Filter = node
+	}
+	if q.Filter != nil || q.Sort != "manual" {
+		tasks, err := h.Store.QueryTasks(c.Request.Context(), user.ID, q)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, newTaskResponses(tasks))
+		return
+	}
+	tasks, err := h.Store.GetTasks(c.Request.Context(), user.ID)
 	if err != nil {
 		c.Error(err)
 		return
 	}
-	c.JSON(http.StatusOK, tasks)
+	c.JSON(http.StatusOK, newTaskResponses(tasks))
 }
 
 func (h *TaskHandler) GetTaskByID(c *gin.Context) { 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.Error(apperrors.NewBadRequestError("id格式错误", err))
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
 		return
 	}
 	userID, ok := getUserIDFromContext(c)
@@ -96,64 +150,92 @@ func (h *TaskHandler) GetTaskByID(c *gin.Context) {
 		c.Error(err)
 		return
 	}
-	c.JSON(http.StatusOK, task)
+	etag := taskETag(task.Version)
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, newTaskResponse(task))
 }
 
 func (h *TaskHandler) UpdateTask(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.Error(apperrors.NewBadRequestError("ID不合理", err))
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
 		return
 	}
 	userID, ok := getUserIDFromContext(c)
 	if !ok {
 		return
 	}
-	var task models.Task
-	if err := c.ShouldBindJSON(&task); err != nil { 
-		c.Error(apperrors.NewBadRequestError("不合理得输入", err))
+	var req TaskRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(invalidBody(apperrors.CodeInvalidBody, err))
 		return
 	}
+	task := req.toTask()
 	task.ID = id
 	task.UserID = userID
+	//版本只来自 If-Match
+	if task.Version, ok = h.getIfMatchVersion(c, id, userID); !ok {
+		return
+	}
 
 	//添加分布式锁
-	ctx := c.Request.Context()
+	unlock, ok := h.lockTask(c, id)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	if err := h.Store.UpdateTask(c.Request.Context(), &task); err != nil { 
+		h.respondVersionConflict(c, err, id, userID)
+		return
+	}
+	c.Header("ETag", taskETag(task.Version))
+	c.JSON(http.StatusOK, newTaskResponse(&task))
+}
+
+// lockTask 获取任务的分布式锁，返回用于释放锁的函数
+// 并发写入已由版本号保护，未配置 Redsync 时不加锁
+func (h *TaskHandler) lockTask(c *gin.Context, id int) (unlock func(), ok bool) {
+	if h.Redsync == nil {
+		return func() {}, true
+	}
 	mutexName := fmt.Sprintf("lock:task:%d", id)
 	mutex := h.Redsync.NewMutex(mutexName, redsync.WithTries(3), redsync.WithRetryDelay(200*time.Millisecond))
-	if err := mutex.LockContext(ctx); err != nil {
+	if err := mutex.LockContext(c.Request.Context()); err != nil {
 		log.Printf("获取锁失败: %v", err)
-		c.Error(apperrors.NewInternalServerError("请稍后重试", err))
-		return
+		c.Error(apperrors.NewInternalServerError(apperrors.CodeRetryLater, err))
+		return nil, false
 	}
 	log.Printf("获取锁成功")
-	defer func() {
+	return func() {
 		if ok, err := mutex.Unlock(); !ok || err != nil { 
 			log.Printf("释放锁失败: %v", err)
 		}else {
 			log.Printf("释放锁成功")
 		}
-	}()
-
-	if err := h.Store.UpdateTask(c.Request.Context(), &task); err != nil { 
-		c.Error(err)
-		return
-	}
-	c.JSON(http.StatusOK, task)
+	}, true
 }
 
 func (h *TaskHandler) DeleteTask(c *gin.Context) { 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.Error(apperrors.NewBadRequestError("ID格式错误", err))
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
 		return
 	}
 	userID, ok := getUserIDFromContext(c)
 	if !ok {
 		return
 	}
-	if err := h.Store.DeleteTask(c.Request.Context(), id, userID); err != nil { 
-		c.Error(err)
+	version, ok := h.getIfMatchVersion(c, id, userID)
+	if !ok {
+		return
+	}
+	if err := h.Store.DeleteTask(c.Request.Context(), id, userID, version); err != nil { 
+		h.respondVersionConflict(c, err, id, userID)
 		return
 	}
 	//删除成功返回204
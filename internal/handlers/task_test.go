@@ -5,15 +5,26 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/HywlEch/Todo_list/internal/middleware"
 	"github.com/HywlEch/Todo_list/internal/models"
 	"github.com/HywlEch/Todo_list/internal/store"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
+// withUser 模拟 AuthMiddleware，把用户ID写入上下文
+func withUser(userID int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Next()
+	}
+}
+
 // TestGetTaskByID_Success 测试获取单个任务的“成功”路径
 func TestGetTaskByID_Success(t *testing.T) {
 	// --- ARRANGE (准备) ---
@@ -31,8 +42,8 @@ func TestGetTaskByID_Success(t *testing.T) {
 		UpdatedAt: time.Now(),
 	}
 	// 4. “教” mockStore 如何行动：
-	// 当 GetTaskByID 方法被以参数 `1` 调用时，返回 `mockTask` 并且不返回错误 (nil)
-	mockStore.On("GetTaskByID", 1).Return(mockTask, nil)
+	// 当 GetTaskByID 方法被以任务ID `1`、用户ID `1` 调用时，返回 `mockTask` 并且不返回错误 (nil)
+	mockStore.On("GetTaskByID", mock.Anything, 1, 1).Return(mockTask, nil)
 
 	// 5. 用我们的 mock store 创建 handler
 	taskHandler := NewTaskHandler(mockStore, nil)
@@ -40,6 +51,7 @@ func TestGetTaskByID_Success(t *testing.T) {
 	// --- ACT (执行) ---
 	// 1. 设置路由
 	router := gin.Default()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
 	router.GET("/tasks/:id", taskHandler.GetTaskByID)
 
 	// 2. 创建一个假的 HTTP 请求
@@ -71,10 +83,11 @@ func TestGetTaskByID_NotFound(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockStore := new(store.MockStore)
 
-	mockStore.On("GetTaskByID", 2).Return(nil, store.ErrNotFound)
+	mockStore.On("GetTaskByID", mock.Anything, 2, 1).Return(nil, store.ErrNotFound)
 	taskHandler := NewTaskHandler(mockStore, nil)
 	// ACT
 	router := gin.Default()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
 	router.GET("/tasks/:id", taskHandler.GetTaskByID)
 	req, _ := http.NewRequest(http.MethodGet, "/tasks/2", nil)
 	w := httptest.NewRecorder()
@@ -84,4 +97,167 @@ func TestGetTaskByID_NotFound(t *testing.T) {
 	// 断言 HTTP 状态码是 404 Not Found
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	mockStore.AssertExpectations(t)
-}
\ No newline at end of file
+}
+// TestGetTaskByID_NotFoundLocalized 测试没有 Accept-Language 时按用户资料中的语言返回错误消息和错误码
+func TestGetTaskByID_NotFoundLocalized(t *testing.T) {
+	// ARRANGE
+	gin.SetMode(gin.TestMode)
+	mockStore := new(store.MockStore)
+
+	mockStore.On("GetTaskByID", mock.Anything, 2, 1).Return(nil, store.ErrNotFound)
+	mockStore.On("GetUserByID", mock.Anything, 1).Return(&models.User{ID: 1, Locale: "en"}, nil)
+	taskHandler := NewTaskHandler(mockStore, nil)
+	// ACT
+	router := gin.Default()
+	router.Use(middleware.LocaleMiddleware(mockStore), middleware.ErrorMiddleware(), withUser(1))
+	router.GET("/tasks/:id", taskHandler.GetTaskByID)
+	req, _ := http.NewRequest(http.MethodGet, "/tasks/2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// ASSERT
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, middleware.ProblemContentType, w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "/problems/resource.not_found",
+		"title": "Not Found",
+		"status": 404,
+		"detail": "Resource not found",
+		"code": "resource.not_found"
+	}`, w.Body.String())
+	mockStore.AssertExpectations(t)
+}
+
+// TestUpdateTask_PreconditionFailed 测试 If-Match 版本过期时返回 412 和当前内容
+func TestUpdateTask_PreconditionFailed(t *testing.T) {
+	// ARRANGE
+	gin.SetMode(gin.TestMode)
+	mockStore := new(store.MockStore)
+
+	current := &models.Task{ID: 3, Title: "Newer Title", UserID: 1, Version: 5}
+	mockStore.On("UpdateTask", mock.Anything, mock.MatchedBy(func(task *models.Task) bool {
+		return task.ID == 3 && task.Version == 4
+	})).Return(store.ErrVersionConflict)
+	mockStore.On("GetTaskByID", mock.Anything, 3, 1).Return(current, nil)
+	taskHandler := NewTaskHandler(mockStore, nil)
+
+	// ACT
+	router := gin.Default()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
+	router.PUT("/tasks/:id", taskHandler.UpdateTask)
+	req, _ := http.NewRequest(http.MethodPut, "/tasks/3", strings.NewReader(`{"title":"Stale Title"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"4"`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// ASSERT
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, `"5"`, w.Header().Get("ETag"))
+	var body struct {
+		Current models.Task `json:"current"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "Newer Title", body.Current.Title)
+	mockStore.AssertExpectations(t)
+}
+
+// TestUpdateTask_IfMatchList 测试 If-Match 中有多个 ETag 时任意一个与当前版本一致即可
+func TestUpdateTask_IfMatchList(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		status  int
+		version int
+	}{
+		{"其中一个匹配", `"3", "5"`, http.StatusOK, 5},
+		{"跳过弱 ETag", `W/"5", "5"`, http.StatusOK, 5},
+		{"都不匹配", `"3", "4"`, http.StatusPreconditionFailed, 3},
+		{"只有弱 ETag", `W/"5"`, http.StatusPreconditionFailed, 0},
+		{"格式错误", `"5", 6`, http.StatusPreconditionFailed, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			mockStore := new(store.MockStore)
+			mockStore.On("GetTaskByID", mock.Anything, 3, 1).Return(&models.Task{ID: 3, UserID: 1, Version: 5}, nil).Maybe()
+			mockStore.On("UpdateTask", mock.Anything, mock.MatchedBy(func(task *models.Task) bool { return task.Version == 5 })).Return(nil).Maybe()
+			mockStore.On("UpdateTask", mock.Anything, mock.Anything).Return(store.ErrVersionConflict).Maybe()
+			router := gin.New()
+			router.Use(middleware.ErrorMiddleware(), withUser(1))
+			router.PUT("/tasks/:id", NewTaskHandler(mockStore, nil).UpdateTask)
+
+			req := httptest.NewRequest(http.MethodPut, "/tasks/3", strings.NewReader(`{"title":"t"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", tt.ifMatch)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+			if tt.version == 0 {
+				mockStore.AssertNotCalled(t, "UpdateTask", mock.Anything, mock.Anything)
+			} else {
+				mockStore.AssertCalled(t, "UpdateTask", mock.Anything, mock.MatchedBy(func(task *models.Task) bool { return task.Version == tt.version }))
+			}
+		})
+	}
+}
+
+// TestAddDependency_Cycle 测试添加会形成循环的依赖时返回 409
+func TestAddDependency_Cycle(t *testing.T) {
+	// ARRANGE
+	gin.SetMode(gin.TestMode)
+	mockStore := new(store.MockStore)
+
+	// 任务2依赖任务1：{"blocks":2} 表示任务2依赖当前任务1
+	mockStore.On("AddDependency", mock.Anything, 1, 1, 2).Return(nil, store.ErrDependencyCycle)
+	taskHandler := NewTaskHandler(mockStore, nil)
+
+	// ACT
+	router := gin.Default()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
+	router.POST("/tasks/:id/dependencies", taskHandler.AddDependency)
+	req, _ := http.NewRequest(http.MethodPost, "/tasks/1/dependencies", strings.NewReader(`{"blocks":2}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// ASSERT
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "循环")
+	mockStore.AssertExpectations(t)
+}
+
+// TestCreateTask_Validation 测试请求体中的只读字段和空白标题被拒绝，且不会写入数据库
+func TestCreateTask_Validation(t *testing.T) {
+	// ARRANGE
+	gin.SetMode(gin.TestMode)
+	mockStore := new(store.MockStore)
+	taskHandler := NewTaskHandler(mockStore, nil)
+	router := gin.Default()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
+	router.POST("/tasks", taskHandler.CreateTask)
+
+	cases := []struct {
+		body     string
+		expected middleware.FieldError
+	}{
+		{`{"title": "ok", "user_id": 2}`, middleware.FieldError{Pointer: "/user_id", Rule: "readonly", Detail: "is read-only or unknown and cannot be written"}},
+		{`{"title": "   "}`, middleware.FieldError{Pointer: "/title", Rule: "notblank", Detail: "must not be blank"}},
+		{`{"title": "ok", "tags": ["a", ""]}`, middleware.FieldError{Pointer: "/tags/1", Rule: "notblank", Detail: "must not be blank"}},
+	}
+	for _, tc := range cases {
+		// ACT
+		req, _ := http.NewRequest(http.MethodPost, "/tasks", strings.NewReader(tc.body))
+		req.Header.Set("Accept-Language", "en")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// ASSERT
+		assert.Equal(t, http.StatusBadRequest, w.Code, tc.body)
+		var problem middleware.Problem
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, []middleware.FieldError{tc.expected}, problem.Errors, tc.body)
+	}
+	mockStore.AssertNotCalled(t, "CreateTask", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redsync/redsync/v4"
+)
+
+// TimerHandler 包含计时器和时间记录相关的 handler
+type TimerHandler struct {
+	Store   store.Store
+	Redsync *redsync.Redsync
+}
+
+// NewTimerHandler 创建一个新的 TimerHandler
+func NewTimerHandler(s store.Store, rs *redsync.Redsync) *TimerHandler {
+	return &TimerHandler{Store: s, Redsync: rs}
+}
+
+// StartTimerRequest 定义开始计时的JSON结构
+type StartTimerRequest struct {
+	TaskID int    `json:"task_id" binding:"required,min=1"`
+	Note   string `json:"note" binding:"max=1000"`
+}
+
+// StopTimerRequest 定义停止计时的JSON结构，note 不为空时覆盖开始时的备注
+type StopTimerRequest struct {
+	Note *string `json:"note" binding:"omitempty,max=1000"`
+}
+
+// lockTimer 获取用户计时器的分布式锁，保证同一用户同时只有一个开始/停止操作
+// 数据库的唯一索引是最后一道保护，未配置 Redsync 时只依赖它
+func (h *TimerHandler) lockTimer(c *gin.Context, userID int) (unlock func(), ok bool) {
+	if h.Redsync == nil {
+		return func() {}, true
+	}
+	mutex := h.Redsync.NewMutex(fmt.Sprintf("lock:timer:%d", userID), redsync.WithTries(3), redsync.WithRetryDelay(200*time.Millisecond))
+	if err := mutex.LockContext(c.Request.Context()); err != nil {
+		c.Error(apperrors.NewConfilictError(apperrors.CodeTimerBusy, err))
+		return nil, false
+	}
+	return func() {
+		if ok, err := mutex.Unlock(); !ok || err != nil {
+			log.Printf("释放计时器锁失败: %v", err)
+		}
+	}, true
+}
+
+// StartTimer 为任务开始计时，已有正在运行的计时器时返回409和该计时器
+func (h *TimerHandler) StartTimer(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	var req StartTimerRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(invalidBody(apperrors.CodeInvalidBody, err))
+		return
+	}
+	unlock, ok := h.lockTimer(c, userID)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	ctx := c.Request.Context()
+	running, err := h.Store.GetRunningTimer(ctx, userID)
+	if err == nil {
+		c.Error(apperrors.NewConfilictError(apperrors.CodeTimerRunning, nil).WithExtension("running", newTimeEntryResponse(running)))
+		return
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		c.Error(err)
+		return
+	}
+	entry := &models.TimeEntry{TaskID: req.TaskID, UserID: userID, Note: req.Note}
+	if err := h.Store.StartTimer(ctx, entry); err != nil {
+		if errors.Is(err, store.ErrTimerRunning) {
+			c.Error(apperrors.NewConfilictError(apperrors.CodeTimerRunning, err))
+			return
+		}
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, newTimeEntryResponse(entry))
+}
+
+// StopTimer 停止当前用户正在运行的计时器
+func (h *TimerHandler) StopTimer(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	var req StopTimerRequest
+	if c.Request.ContentLength != 0 {
+		if err := bindJSON(c, &req); err != nil {
+			c.Error(invalidBody(apperrors.CodeInvalidBody, err))
+			return
+		}
+	}
+	unlock, ok := h.lockTimer(c, userID)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	entry, err := h.Store.StopTimer(c.Request.Context(), userID, req.Note)
+	if errors.Is(err, store.ErrNotFound) {
+		c.Error(apperrors.NewNotFoundError(apperrors.CodeTimerNotRunning, err))
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newTimeEntryResponse(entry))
+}
+
+// GetRunningTimer 返回当前用户正在运行的计时器，没有时 running 为 null
+func (h *TimerHandler) GetRunningTimer(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	entry, err := h.Store.GetRunningTimer(c.Request.Context(), userID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"running": newTimeEntryResponse(entry)})
+}
+
+// GetTimeEntries 分页返回时间记录，支持 ?task_id=&from=&to=
+func (h *TimerHandler) GetTimeEntries(c *gin.Context) {
+	user, ok := getProfile(c, h.Store)
+	if !ok {
+		return
+	}
+	from, to, _, ok := getTimeRange(c, user.Location(), maxRangeDays)
+	if !ok {
+		return
+	}
+	q := store.TimeEntryQuery{From: from, To: to}
+	if v := c.Query("task_id"); v != "" {
+		taskID, err := strconv.Atoi(v)
+		if err != nil || taskID < 1 {
+			c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidTaskID, err))
+			return
+		}
+		q.TaskID = taskID
+	}
+	page, pageSize, opts, ok := getPagination(c)
+	if !ok {
+		return
+	}
+	entries, total, err := h.Store.GetTimeEntries(c.Request.Context(), user.ID, q, opts)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, pageResponse(newTimeEntryResponses(entries), total, page, pageSize))
+}
+
+// GetTimeReport 按天、项目或标签汇总时间，?format=csv 时返回 CSV 文件
+func (h *TimerHandler) GetTimeReport(c *gin.Context) {
+	user, ok := getProfile(c, h.Store)
+	if !ok {
+		return
+	}
+	from, to, loc, ok := getTimeRange(c, user.Location(), maxRangeDays)
+	if !ok {
+		return
+	}
+	groupBy := c.DefaultQuery("group_by", models.ReportByDay)
+	switch groupBy {
+	case models.ReportByDay, models.ReportByProject, models.ReportByTag:
+	default:
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidGroupBy, nil))
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidFormat, nil))
+		return
+	}
+
+	rows, err := h.Store.GetTimeReport(c.Request.Context(), user.ID, from, to, groupBy, loc.String())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var totalSeconds int64
+	for _, r := range rows {
+		totalSeconds += r.Seconds
+	}
+	if format == "csv" {
+		filename := fmt.Sprintf("time-report-%s-%s.csv", groupBy, from.Format("20060102"))
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := writeTimeReportCSV(c.Writer, groupBy, rows, totalSeconds); err != nil {
+			log.Printf("写入CSV失败: %v", err)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":          from,
+		"to":            to,
+		"group_by":      groupBy,
+		"rows":          rows,
+		"total_seconds": totalSeconds,
+	})
+}
+
+// writeTimeReportCSV 以 CSV 输出报表，最后一行为合计
+func writeTimeReportCSV(w http.ResponseWriter, groupBy string, rows []models.TimeReportRow, totalSeconds int64) error {
+	cw := csv.NewWriter(w)
+	hours := func(seconds int64) string { return strconv.FormatFloat(float64(seconds)/3600, 'f', 2, 64) }
+	if err := cw.Write([]string{groupBy, "label", "seconds", "hours", "entries"}); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		record := []string{r.Key, r.Label, strconv.FormatInt(r.Seconds, 10), hours(r.Seconds), strconv.Itoa(r.Entries)}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := cw.Write([]string{"total", "", strconv.FormatInt(totalSeconds, 10), hours(totalSeconds), ""}); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestGetTimeReport_CSV 测试按标签汇总的报表以 CSV 输出，日期范围包含 to 这一整天
+func TestGetTimeReport_CSV(t *testing.T) {
+	// ARRANGE
+	gin.SetMode(gin.TestMode)
+	mockStore := new(store.MockStore)
+
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	rows := []models.TimeReportRow{
+		{Key: "backend", Label: "backend", Seconds: 5400, Entries: 2},
+		{Key: "client-a", Label: "client-a", Seconds: 1800, Entries: 1},
+	}
+	mockStore.On("GetUserByID", mock.Anything, 1).Return(&models.User{ID: 1, Timezone: "UTC", WeekStart: 1}, nil)
+	mockStore.On("GetTimeReport", mock.Anything, 1, from, to, models.ReportByTag, "UTC").Return(rows, nil)
+	timerHandler := NewTimerHandler(mockStore, nil)
+
+	// ACT
+	router := gin.Default()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
+	router.GET("/time-entries/report", timerHandler.GetTimeReport)
+	req, _ := http.NewRequest(http.MethodGet, "/time-entries/report?from=2024-03-01&to=2024-03-31&group_by=tag&format=csv", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "tag,label,seconds,hours,entries\n"+
+		"backend,backend,5400,1.50,2\n"+
+		"client-a,client-a,1800,0.50,1\n"+
+		"total,,7200,2.00,\n", w.Body.String())
+	mockStore.AssertExpectations(t)
+}
+
+// TestGetTimeReport_ProfileTimezone 测试没有 ?tz= 时按用户资料中的时区解析日期
+func TestGetTimeReport_ProfileTimezone(t *testing.T) {
+	// ARRANGE
+	gin.SetMode(gin.TestMode)
+	mockStore := new(store.MockStore)
+
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	assert.NoError(t, err)
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, loc)
+	to := time.Date(2024, 3, 2, 0, 0, 0, 0, loc)
+	mockStore.On("GetUserByID", mock.Anything, 1).Return(&models.User{ID: 1, Timezone: "Asia/Shanghai"}, nil)
+	mockStore.On("GetTimeReport", mock.Anything, 1, mock.MatchedBy(from.Equal), mock.MatchedBy(to.Equal), models.ReportByDay, "Asia/Shanghai").
+		Return([]models.TimeReportRow{}, nil)
+	timerHandler := NewTimerHandler(mockStore, nil)
+
+	// ACT
+	router := gin.Default()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
+	router.GET("/time-entries/report", timerHandler.GetTimeReport)
+	req, _ := http.NewRequest(http.MethodGet, "/time-entries/report?from=2024-03-01&to=2024-03-01", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockStore.AssertExpectations(t)
+}
+
+func newTimerRouter(mockStore *store.MockStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	timerHandler := NewTimerHandler(mockStore, nil)
+	router := gin.New()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
+	router.POST("/timer/start", timerHandler.StartTimer)
+	router.POST("/timer/stop", timerHandler.StopTimer)
+	return router
+}
+
+func serveTimer(router *gin.Engine, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestStartTimer_AlreadyRunning 测试已有正在运行的计时器时返回409和该计时器，不再开始新的计时
+func TestStartTimer_AlreadyRunning(t *testing.T) {
+	// ARRANGE
+	mockStore := new(store.MockStore)
+	running := &models.TimeEntry{ID: 8, TaskID: 2, UserID: 1, StartedAt: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+	mockStore.On("GetRunningTimer", mock.Anything, 1).Return(running, nil)
+
+	// ACT
+	w := serveTimer(newTimerRouter(mockStore), "/timer/start", `{"task_id":3}`)
+
+	// ASSERT
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"timer.already_running"`)
+	assert.Contains(t, w.Body.String(), `"running":{"id":8`)
+	mockStore.AssertNotCalled(t, "StartTimer", mock.Anything, mock.Anything)
+}
+
+// TestStartTimer_Race 测试并发开始时数据库的唯一索引拦下第二个计时器
+func TestStartTimer_Race(t *testing.T) {
+	// ARRANGE
+	mockStore := new(store.MockStore)
+	mockStore.On("GetRunningTimer", mock.Anything, 1).Return(nil, store.ErrNotFound)
+	mockStore.On("StartTimer", mock.Anything, mock.Anything).Return(store.ErrTimerRunning)
+
+	// ACT
+	w := serveTimer(newTimerRouter(mockStore), "/timer/start", `{"task_id":3}`)
+
+	// ASSERT
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"timer.already_running"`)
+	mockStore.AssertExpectations(t)
+}
+
+// TestStopTimer 测试停止计时返回记录的时长，没有正在运行的计时器时返回404
+func TestStopTimer(t *testing.T) {
+	// ARRANGE
+	mockStore := new(store.MockStore)
+	started := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	stopped := started.Add(90 * time.Minute)
+	note := "reviewed PR"
+	mockStore.On("StopTimer", mock.Anything, 1, &note).Return(&models.TimeEntry{
+		ID: 8, TaskID: 2, UserID: 1, StartedAt: started, StoppedAt: &stopped, DurationSeconds: 5400, Note: note,
+	}, nil).Once()
+	mockStore.On("StopTimer", mock.Anything, 1, (*string)(nil)).Return(nil, store.ErrNotFound).Once()
+	router := newTimerRouter(mockStore)
+
+	// ACT & ASSERT
+	w := serveTimer(router, "/timer/stop", `{"note":"reviewed PR"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"stopped_at":"2024-03-01T10:30:00Z"`)
+	assert.Contains(t, w.Body.String(), `"duration_seconds":5400`)
+	assert.NotContains(t, w.Body.String(), "user_id")
+
+	w = serveTimer(router, "/timer/stop", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"timer.not_running"`)
+	mockStore.AssertExpectations(t)
+}
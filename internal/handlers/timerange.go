@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultRangeDays = 30
+
+// 时间范围的最长天数，统计结果每天或每周一项，范围过大时生成的数据和缓存都会很大
+const (
+	maxDayStatsDays  = 366      //按天统计
+	maxWeekStatsDays = 5 * 366  //按周统计
+	maxRangeDays     = 10 * 366 //时间记录和报表
+)
+
+// parseDate 解析 YYYY-MM-DD（loc 时区的零点）或 RFC3339 格式的时间
+func parseDate(value string, loc *time.Location) (time.Time, bool) {
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// getTimeRange 从 ?from=&to=&tz= 中解析时间范围 [from, to)，范围不能超过 maxDays 天
+// 没有 tz 时使用 loc（用户资料中的时区）；to 为日期时包含这一整天；默认是截至今天的最近30天
+func getTimeRange(c *gin.Context, loc *time.Location, maxDays int) (from time.Time, to time.Time, _ *time.Location, ok bool) {
+	if tz := c.Query("tz"); tz != "" {
+		var err error
+		if loc, err = models.LoadTimezone(tz); err != nil {
+			c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidTZ, err))
+			return from, to, nil, false
+		}
+	}
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	from, to = today.AddDate(0, 0, -defaultRangeDays+1), today.AddDate(0, 0, 1)
+	if v := c.Query("from"); v != "" {
+		if from, ok = parseDate(v, loc); !ok {
+			c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidFrom, nil))
+			return from, to, nil, false
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, ok = parseDate(v, loc); !ok {
+			c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidTo, nil))
+			return from, to, nil, false
+		}
+		if len(v) == len("2006-01-02") {
+			to = to.AddDate(0, 0, 1)
+		}
+	}
+	if !from.Before(to) || from.AddDate(0, 0, maxDays).Before(to) {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidRange, nil).With("max_days", maxDays))
+		return from, to, nil, false
+	}
+	return from, to, loc, true
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/gin-gonic/gin"
+)
+
+// GetTrash 返回回收站中的任务
+func (h *TaskHandler) GetTrash(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	tasks, err := h.Store.GetTrash(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newTaskResponses(tasks))
+}
+
+// RestoreTask 把任务从回收站恢复
+func (h *TaskHandler) RestoreTask(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
+		return
+	}
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	if err := h.Store.RestoreTask(ctx, id, userID); err != nil {
+		c.Error(err)
+		return
+	}
+	task, err := h.Store.GetTaskByID(ctx, id, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newTaskResponse(task))
+}
+
+// PurgeTask 彻底删除回收站中的任务，不可恢复
+func (h *TaskHandler) PurgeTask(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
+		return
+	}
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	if err := h.Store.PurgeTask(c.Request.Context(), id, userID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTrashRouter(mockStore *store.MockStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	taskHandler := NewTaskHandler(mockStore, nil)
+	router := gin.New()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
+	router.DELETE("/tasks/:id", taskHandler.DeleteTask)
+	router.GET("/trash", taskHandler.GetTrash)
+	router.POST("/trash/:id/restore", taskHandler.RestoreTask)
+	router.DELETE("/trash/:id", taskHandler.PurgeTask)
+	return router
+}
+
+// TestTrash_DeleteAndRestore 测试删除的任务进入回收站，恢复后返回任务
+func TestTrash_DeleteAndRestore(t *testing.T) {
+	// ARRANGE
+	mockStore := new(store.MockStore)
+	deletedAt := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	mockStore.On("DeleteTask", mock.Anything, 3, 1, 0).Return(nil).Once()
+	mockStore.On("GetTrash", mock.Anything, 1).Return([]models.Task{{ID: 3, UserID: 1, Title: "old", DeletedAt: &deletedAt}}, nil).Once()
+	mockStore.On("RestoreTask", mock.Anything, 3, 1).Return(nil).Once()
+	mockStore.On("GetTaskByID", mock.Anything, 3, 1).Return(&models.Task{ID: 3, UserID: 1, Title: "old"}, nil).Once()
+	router := newTrashRouter(mockStore)
+
+	// ACT & ASSERT
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/tasks/3", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/trash", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":3`)
+	assert.Contains(t, w.Body.String(), `"deleted_at":"2026-01-02T00:00:00Z"`)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/trash/3/restore", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"title":"old"`)
+	mockStore.AssertExpectations(t)
+}
+
+// TestTrash_Errors 测试恢复或彻底删除不在回收站中的任务时返回404
+func TestTrash_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		status int
+	}{
+		{"恢复不存在的任务", http.MethodPost, "/trash/8/restore", http.StatusNotFound},
+		{"彻底删除不存在的任务", http.MethodDelete, "/trash/8", http.StatusNotFound},
+		{"彻底删除", http.MethodDelete, "/trash/3", http.StatusNoContent},
+		{"ID无效", http.MethodDelete, "/trash/x", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := new(store.MockStore)
+			mockStore.On("RestoreTask", mock.Anything, 8, 1).Return(store.ErrNotFound).Maybe()
+			mockStore.On("PurgeTask", mock.Anything, 8, 1).Return(store.ErrNotFound).Maybe()
+			mockStore.On("PurgeTask", mock.Anything, 3, 1).Return(nil).Maybe()
+
+			w := httptest.NewRecorder()
+			newTrashRouter(mockStore).ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+			assert.Equal(t, tt.status, w.Code)
+			mockStore.AssertNotCalled(t, "GetTaskByID", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
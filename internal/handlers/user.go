@@ -12,6 +12,9 @@ import (
 	"github.com/HywlEch/Todo_list/internal/store"
 	"github.com/HywlEch/Todo_list/internal/config"
 	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/password"
+	"github.com/HywlEch/Todo_list/internal/i18n"
+	"github.com/HywlEch/Todo_list/internal/middleware"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -19,24 +22,32 @@ import (
 type UserHandler struct {
 	Store store.Store
 	JWTConfig config.JWTConfig
+	PasswordPolicy *password.Policy //注册时检查密码强度
 }
 //NewUserHandler 创建一个userHandler
-func NewUserHandler(s store.Store, jwtCfg config.JWTConfig)*UserHandler{
+func NewUserHandler(s store.Store, jwtCfg config.JWTConfig, policy *password.Policy)*UserHandler{
 	return &UserHandler{Store: s,
-		JWTConfig: jwtCfg}
+		JWTConfig: jwtCfg,
+		PasswordPolicy: policy}
 }
 
 //RegisterReqest 定义注册请求得JSON结构
+//用户名只能包含字母、数字、_、. 和 -，密码强度由 PasswordPolicy 检查
 type RegisterRequest struct {
-	Username string `json:"username" binding:"required"`
+	Username string `json:"username" binding:"required,min=3,max=32,username"`
 	Password string `json:"password" binding:"required"`
 }
 
 //Regiester处理用户注册
 func (h *UserHandler)Regiester(c *gin.Context){
 	var req RegisterRequest
-	if err :=  c.ShouldBindJSON(&req); err != nil {
-		c.Error(apperrors.NewBadRequestError("不合理得输入", err))
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(invalidBody(apperrors.CodeInvalidBody, err))
+		return
+	}
+	if violations := h.PasswordPolicy.Check(req.Password, req.Username); len(violations) > 0 {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeWeakPassword, violations))
+		return
 	}
 	user := &models.User{
 		Username: req.Username,
@@ -47,7 +58,7 @@ func (h *UserHandler)Regiester(c *gin.Context){
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "用户创建成功",
+	c.JSON(http.StatusCreated, gin.H{"message": i18n.Translate(middleware.Locale(c), "user.created", nil),
 		"userid": user.ID})
 	
 }
@@ -66,13 +77,14 @@ type LoginResponse struct {
 //处理用户登录
 func (h *UserHandler)Login(c *gin.Context){
 	var req LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.Error(apperrors.NewBadRequestError("不合理得输入", err))
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(invalidBody(apperrors.CodeInvalidBody, err))
+		return
 	}
 	user, err := h.Store.GetUserByUsername(c.Request.Context(),req.Username) 
 	if err != nil { 
 		if errors.Is(err, store.ErrNotFound) {
-			c.Error(apperrors.NewUnauthorizedError("用户不存在", err))
+			c.Error(apperrors.NewUnauthorizedError(apperrors.CodeAuthInvalidCredentials, err))
 		}else {
 			c.Error(err)
 		}
@@ -81,13 +93,13 @@ func (h *UserHandler)Login(c *gin.Context){
 
 	//验证密码
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		c.Error(apperrors.NewUnauthorizedError("密码错误", err))
+		c.Error(apperrors.NewUnauthorizedError(apperrors.CodeAuthInvalidCredentials, err))
 		return
 	}
 	//密码验证成功，生成JWT
 	token, err := h.generateJWT(user.ID)
 	if err != nil {
-		c.Error(apperrors.NewInternalServerError("生成JWT失败", err))
+		c.Error(apperrors.NewInternalServerError("", err))
 		return
 	}
 	c.JSON(http.StatusOK, LoginResponse{Token: token})
@@ -99,10 +111,10 @@ func (h *UserHandler)generateJWT(userID int) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID,
 		"exp": time.Now().Add(time.Hour*time.Duration(h.JWTConfig.ExpiresInHours)).Unix(),
-		"iat": time.Now().Unix, 
+		"iat": time.Now().Unix(),
 	}
-	//创建token
-	token := jwt.NewWithClaims(jwt.SigningMethodES256,claims)
+	//创建token，AuthMiddleware 只接受 HMAC 签名
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256,claims)
 	//使用我们的密钥签名
 	tokenString, err := token.SignedString([]byte(h.JWTConfig.Secret))
 	if err != nil {
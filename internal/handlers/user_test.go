@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/HywlEch/Todo_list/internal/config"
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestLogin_TokenAccepted 测试登录返回的 token 能通过 AuthMiddleware
+func TestLogin_TokenAccepted(t *testing.T) {
+	// ARRANGE
+	gin.SetMode(gin.TestMode)
+	mockStore := new(store.MockStore)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	mockStore.On("GetUserByUsername", mock.Anything, "alice").Return(&models.User{ID: 7, Username: "alice", PasswordHash: string(hash)}, nil)
+	userHandler := NewUserHandler(mockStore, config.JWTConfig{Secret: "test-secret", ExpiresInHours: 1}, nil)
+
+	// ACT
+	router := gin.New()
+	router.Use(middleware.ErrorMiddleware())
+	router.POST("/login", userHandler.Login)
+	router.GET("/me", middleware.AuthMiddleware("test-secret"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetInt("user_id")})
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"username":"alice","password":"secret123"}`)))
+
+	// ASSERT
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp LoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(resp.Token, claims, func(*jwt.Token) (interface{}, error) { return []byte("test-secret"), nil })
+	require.NoError(t, err)
+	assert.Equal(t, jwt.SigningMethodHS256.Alg(), token.Method.Alg())
+	assert.IsType(t, float64(0), claims["iat"], "iat 是数字时间戳")
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+resp.Token)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"user_id":7}`, w.Body.String())
+	mockStore.AssertExpectations(t)
+}
+
+// TestLogin_InvalidBody 测试请求体无效时返回400，不再查询用户
+func TestLogin_InvalidBody(t *testing.T) {
+	// ARRANGE
+	gin.SetMode(gin.TestMode)
+	mockStore := new(store.MockStore)
+	userHandler := NewUserHandler(mockStore, config.JWTConfig{Secret: "test-secret", ExpiresInHours: 1}, nil)
+
+	// ACT
+	router := gin.New()
+	router.Use(middleware.ErrorMiddleware())
+	router.POST("/login", userHandler.Login)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"username":""}`)))
+
+	// ASSERT
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockStore.AssertNotCalled(t, "GetUserByUsername", mock.Anything, mock.Anything)
+}
+
+// TestPatchMe_Timezone 测试时区必须是 IANA 时区名，"Local" 会让之后的统计查询失败
+func TestPatchMe_Timezone(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		status   int
+	}{
+		{"IANA 时区", "Asia/Shanghai", http.StatusOK},
+		{"服务器时区", "Local", http.StatusBadRequest},
+		{"空字符串", "", http.StatusBadRequest},
+		{"未知时区", "Mars/Olympus", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// ARRANGE
+			gin.SetMode(gin.TestMode)
+			mockStore := new(store.MockStore)
+			mockStore.On("GetUserByID", mock.Anything, 1).Return(&models.User{ID: 1, Timezone: "UTC"}, nil)
+			mockStore.On("UpdateUserProfile", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
+				return u.Timezone == tt.timezone
+			})).Return(nil).Maybe()
+			userHandler := NewUserHandler(mockStore, config.JWTConfig{Secret: "test-secret"}, nil)
+			router := gin.New()
+			router.Use(middleware.ErrorMiddleware(), withUser(1))
+			router.PATCH("/me", userHandler.PatchMe)
+
+			// ACT
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/me", strings.NewReader(`{"timezone":"`+tt.timezone+`"}`)))
+
+			// ASSERT
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+			if tt.status == http.StatusBadRequest {
+				assert.Contains(t, w.Body.String(), `"code":"profile.invalid_timezone"`)
+				mockStore.AssertNotCalled(t, "UpdateUserProfile", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+func init() {
+	//自定义的校验规则，在 binding 标签中使用
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+			return strings.TrimSpace(fl.Field().String()) != ""
+		})
+		v.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+			return usernamePattern.MatchString(fl.Field().String())
+		})
+	}
+}
+
+// maxBodyBytes JSON 请求体的大小上限，与 IdempotencyMiddleware 相同
+const maxBodyBytes = middleware.MaxBodyBytes
+
+// readBody 读取请求体，超过 maxBodyBytes 时返回413的 *apperrors.AppError
+func readBody(c *gin.Context) ([]byte, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes)
+	body, err := io.ReadAll(c.Request.Body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, apperrors.NewRequestEntityTooLargeError(apperrors.CodeBodyTooLarge, err).With("limit", tooLarge.Limit)
+	}
+	return body, err
+}
+
+// bindJSON 解析请求体并校验，请求体中包含结构体以外的字段（例如只读的 id、user_id）时返回错误
+func bindJSON(c *gin.Context, obj any) error {
+	body, err := readBody(c)
+	if err != nil {
+		return err
+	}
+	if err := decodeStrict(body, obj); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// invalidBody 把 bindJSON 的错误转换为400，请求体过大时保留413
+func invalidBody(errorCode string, err error) *apperrors.AppError {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return apperrors.NewBadRequestError(errorCode, err)
+}
+
+// decodeStrict 解析JSON，字段类型错误或遇到未知字段时返回 apperrors.FieldViolations
+func decodeStrict(data []byte, obj any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(obj)
+	if err == nil {
+		return nil
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return apperrors.FieldViolations{{Field: typeErr.Field, Rule: "type", Param: typeErr.Type.String()}}
+	}
+	if field, ok := unknownField(err); ok {
+		return apperrors.FieldViolations{{Field: field, Rule: "readonly"}}
+	}
+	if errors.Is(err, io.EOF) {
+		return errors.New("empty request body")
+	}
+	return err
+}
+
+// unknownField encoding/json 没有为未知字段提供错误类型，只能从错误信息中取出字段名
+func unknownField(err error) (string, bool) {
+	const prefix = `json: unknown field "`
+	if !strings.HasPrefix(err.Error(), prefix) {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(err.Error(), prefix), `"`), true
+}
+
+// bindingParam 返回结构体字段的 binding tag 中某条规则的参数，例如 max=500 中的500
+func bindingParam(v any, field string, rule string) int {
+	f, ok := reflect.TypeOf(v).FieldByName(field)
+	if ok {
+		for _, r := range strings.Split(f.Tag.Get("binding"), ",") {
+			if name, param, _ := strings.Cut(r, "="); name == rule {
+				if n, err := strconv.Atoi(param); err == nil {
+					return n
+				}
+			}
+		}
+	}
+	panic(fmt.Sprintf("handlers: %T.%s has no binding rule %s=<int>", v, field, rule))
+}
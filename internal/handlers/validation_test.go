@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/config"
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/password"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDecodeStrict 测试类型错误和未知字段转换为字段错误
+func TestDecodeStrict(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want apperrors.FieldViolations
+	}{
+		{"类型错误", `{"title":"x","priority":"high"}`, apperrors.FieldViolations{{Field: "priority", Rule: "type", Param: "int"}}},
+		{"嵌套字段的类型错误", `{"operations":[{"op":"delete","id":"7"}]}`, apperrors.FieldViolations{{Field: "operations.0.id", Rule: "type", Param: "int"}}},
+		{"只读字段", `{"title":"x","user_id":2}`, apperrors.FieldViolations{{Field: "user_id", Rule: "readonly"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var obj any = &TaskRequest{}
+			if strings.Contains(tt.body, "operations") {
+				obj = &BulkRequest{}
+			}
+			err := decodeStrict([]byte(tt.body), obj)
+			assert.Equal(t, tt.want, err)
+		})
+	}
+
+	err := decodeStrict(nil, &TaskRequest{})
+	assert.EqualError(t, err, "empty request body")
+}
+
+// TestCreateTask_BodyTooLarge 测试请求体超过上限时返回413，不调用 store
+func TestCreateTask_BodyTooLarge(t *testing.T) {
+	// ARRANGE
+	gin.SetMode(gin.TestMode)
+	mockStore := new(store.MockStore)
+	taskHandler := NewTaskHandler(mockStore, nil)
+	router := gin.New()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
+	router.POST("/tasks", taskHandler.CreateTask)
+	body := `{"title":"x","content":"` + strings.Repeat("a", maxBodyBytes) + `"}`
+
+	// ACT
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "en")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// ASSERT
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, middleware.ProblemContentType, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"code":"request.body_too_large"`)
+	assert.Contains(t, w.Body.String(), "must not exceed 1048576 bytes")
+	mockStore.AssertNotCalled(t, "CreateTask", mock.Anything, mock.Anything)
+}
+
+// TestJSONBodies 测试所有接收 JSON 的接口都经过 bindJSON：只读字段返回字段错误，过大的请求体返回413
+func TestJSONBodies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockStore := new(store.MockStore)
+	mockStore.On("GetTaskByID", mock.Anything, 7, 1).Return(&models.Task{ID: 7, UserID: 1, Title: "x"}, nil)
+	taskHandler := NewTaskHandler(mockStore, nil)
+	userHandler := NewUserHandler(mockStore, config.JWTConfig{Secret: "test-secret"}, nil)
+	router := gin.New()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
+	router.POST("/login", userHandler.Login)
+	router.PATCH("/me", userHandler.PatchMe)
+	router.POST("/projects", NewProjectHandler(mockStore).CreateProject)
+	router.POST("/views", NewViewHandler(mockStore).CreateView)
+	router.POST("/tasks/:id/comments", NewCommentHandler(mockStore).CreateComment)
+	router.POST("/tasks/:id/move", taskHandler.MoveTask)
+	router.POST("/tasks/:id/dependencies", taskHandler.AddDependency)
+	router.POST("/timer/start", NewTimerHandler(mockStore, nil).StartTimer)
+
+	targets := []string{"/login", "/me", "/projects", "/views", "/tasks/7/comments", "/tasks/7/move", "/tasks/7/dependencies", "/timer/start"}
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		pointer string
+	}{
+		{"只读字段", `{"user_id":2}`, http.StatusBadRequest, `"pointer":"/user_id"`},
+		{"请求体过大", `{"name":"` + strings.Repeat("a", maxBodyBytes) + `"}`, http.StatusRequestEntityTooLarge, `"code":"request.body_too_large"`},
+	}
+	for _, target := range targets {
+		for _, tt := range tests {
+			t.Run(target+" "+tt.name, func(t *testing.T) {
+				method := http.MethodPost
+				if target == "/me" {
+					method = http.MethodPatch
+				}
+				req := httptest.NewRequest(method, target, strings.NewReader(tt.body))
+				req.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				assert.Equal(t, tt.status, w.Code, w.Body.String())
+				assert.Contains(t, w.Body.String(), tt.pointer)
+			})
+		}
+	}
+
+	//评论的内容与任务的内容一样有长度上限
+	req := httptest.NewRequest(http.MethodPost, "/tasks/7/comments", strings.NewReader(`{"content":"`+strings.Repeat("a", 10001)+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"pointer":"/content"`)
+	mockStore.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything)
+}
+
+// TestLocalizedDetails 测试过滤表达式、工作流和版本号的错误都按请求的语言显示，不夹带中文
+func TestLocalizedDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockStore := new(store.MockStore)
+	mockStore.On("GetUserByID", mock.Anything, 1).Return(&models.User{ID: 1, Timezone: "UTC", DefaultSort: "manual"}, nil)
+	mockStore.On("CreateUser", mock.Anything, mock.Anything).Return(nil)
+	policy, err := password.NewPolicy(config.PasswordConfig{})
+	require.NoError(t, err)
+	taskHandler := NewTaskHandler(mockStore, nil)
+	router := gin.New()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
+	router.POST("/register", NewUserHandler(mockStore, config.JWTConfig{Secret: "test-secret"}, policy).Regiester)
+	router.GET("/tasks", taskHandler.GetTasks)
+	router.GET("/tasks/:id/revisions/:rev", taskHandler.GetTaskRevision)
+	router.POST("/projects", NewProjectHandler(mockStore).CreateProject)
+	router.POST("/views", NewViewHandler(mockStore).CreateView)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		want   string
+	}{
+		{"未知字段", http.MethodGet, "/tasks?filter=owner%3Ame", "", http.StatusBadRequest, `Invalid filter: near character 1: unknown field \"owner\"`},
+		{"缺少括号", http.MethodGet, "/tasks?filter=(done%3Afalse", "", http.StatusBadRequest, "Invalid filter: near character 12: missing ')'"},
+		{"过长的过滤条件", http.MethodGet, "/tasks?filter=" + strings.Repeat("a", 3000), "", http.StatusBadRequest, "must not exceed 2048 characters"},
+		{"视图的过滤条件", http.MethodPost, "/views", `{"name":"v","filter":"due<someday"}`, http.StatusBadRequest, `cannot parse time \"someday\"`},
+		{"工作流", http.MethodPost, "/projects", `{"name":"p","workflow":{"statuses":[{"key":"todo","name":"To do","category":"todo"}]}}`, http.StatusBadRequest, "Invalid workflow: the workflow needs at least one todo and one done status"},
+		{"版本号", http.MethodGet, "/tasks/1/revisions/abc", "", http.StatusBadRequest, "Invalid rev"},
+		{"注册", http.MethodPost, "/register", `{"username":"alice","password":"correct horse battery"}`, http.StatusCreated, `"message":"User created"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept-Language", "en")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+			assert.Contains(t, w.Body.String(), tt.want)
+			assert.NotRegexp(t, `\p{Han}`, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/filter"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+// builtinViews 内置的智能列表，所有用户都有，不能修改或删除
+var builtinViews = []models.SavedView{
+	{Key: "today", Name: "Today", Filter: "done:false AND due:today", Sort: "due_asc"},
+	{Key: "upcoming", Name: "Upcoming", Filter: "done:false AND due>=tomorrow AND due<today+8d", Sort: "due_asc"},
+	{Key: "overdue", Name: "Overdue", Filter: "done:false AND due<now", Sort: "due_asc"},
+	{Key: "no-due-date", Name: "No due date", Filter: "done:false AND due:none", Sort: "created_desc"},
+	{Key: "completed-this-week", Name: "Completed this week", Filter: "done:true AND completed>=week", Sort: "completed_desc"},
+}
+
+func init() {
+	for i := range builtinViews {
+		builtinViews[i].Builtin = true
+	}
+}
+
+// ViewHandler 包含保存的搜索（智能列表）相关的 handler
+type ViewHandler struct {
+	Store store.Store
+}
+
+// NewViewHandler 创建一个新的 ViewHandler
+func NewViewHandler(s store.Store) *ViewHandler {
+	return &ViewHandler{Store: s}
+}
+
+// ViewRequest 定义创建/修改视图的JSON结构
+type ViewRequest struct {
+	Name   string `json:"name" binding:"required,max=100"`
+	Filter string `json:"filter" binding:"required,max=2048"` //与 filter.MaxLength 相同
+	Sort   string `json:"sort"`
+	Pinned bool   `json:"pinned"`
+}
+
+// bindViewRequest 解析请求并检查过滤表达式和排序方式
+func bindViewRequest(c *gin.Context) (*ViewRequest, bool) {
+	var req ViewRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(invalidBody(apperrors.CodeInvalidBody, err))
+		return nil, false
+	}
+	if _, err := filter.Parse(req.Filter, time.Now()); err != nil {
+		c.Error(filter.AppError(err))
+		return nil, false
+	}
+	if req.Sort == "" {
+		req.Sort = "manual"
+	}
+	if _, ok := store.SortOrders[req.Sort]; !ok {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidSort, nil).With("sort", req.Sort))
+		return nil, false
+	}
+	return &req, true
+}
+
+// findView 按路径参数查找视图，参数可以是内置视图的 key 或保存的视图的 ID
+func (h *ViewHandler) findView(c *gin.Context, userID int) (*models.SavedView, bool) {
+	param := c.Param("id")
+	for i := range builtinViews {
+		if builtinViews[i].Key == param {
+			view := builtinViews[i]
+			return &view, true
+		}
+	}
+	id, err := strconv.Atoi(param)
+	if err != nil {
+		c.Error(apperrors.NewNotFoundError(apperrors.CodeViewNotFound, err))
+		return nil, false
+	}
+	view, err := h.Store.GetView(c.Request.Context(), id, userID)
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+	return view, true
+}
+
+// getSavedViewID 修改和删除只针对用户自己保存的视图
+func getSavedViewID(c *gin.Context) (int, bool) {
+	param := c.Param("id")
+	for _, v := range builtinViews {
+		if v.Key == param {
+			c.Error(apperrors.NewBadRequestError(apperrors.CodeViewBuiltinReadonly, nil))
+			return 0, false
+		}
+	}
+	id, err := strconv.Atoi(param)
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
+		return 0, false
+	}
+	return id, true
+}
+
+// GetViews 返回内置视图和用户保存的视图
+func (h *ViewHandler) GetViews(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	saved, err := h.Store.GetViews(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"builtin": newViewResponses(builtinViews), "saved": newViewResponses(saved)})
+}
+
+func (h *ViewHandler) CreateView(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	req, ok := bindViewRequest(c)
+	if !ok {
+		return
+	}
+	view := &models.SavedView{UserID: userID, Name: req.Name, Filter: req.Filter, Sort: req.Sort, Pinned: req.Pinned}
+	if err := h.Store.CreateView(c.Request.Context(), view); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, newViewResponse(view))
+}
+
+func (h *ViewHandler) GetView(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	view, ok := h.findView(c, userID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, newViewResponse(view))
+}
+
+func (h *ViewHandler) UpdateView(c *gin.Context) {
+	id, ok := getSavedViewID(c)
+	if !ok {
+		return
+	}
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	req, ok := bindViewRequest(c)
+	if !ok {
+		return
+	}
+	view := &models.SavedView{ID: id, UserID: userID, Name: req.Name, Filter: req.Filter, Sort: req.Sort, Pinned: req.Pinned}
+	if err := h.Store.UpdateView(c.Request.Context(), view); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, newViewResponse(view))
+}
+
+func (h *ViewHandler) DeleteView(c *gin.Context) {
+	id, ok := getSavedViewID(c)
+	if !ok {
+		return
+	}
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	if err := h.Store.DeleteView(c.Request.Context(), id, userID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetViewTasks 实时执行视图保存的查询
+func (h *ViewHandler) GetViewTasks(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return
+	}
+	view, ok := h.findView(c, userID)
+	if !ok {
+		return
+	}
+	// 相对时间（today、now+7d）每次都按用户时区的当前时间重新计算
+	user, ok := getProfile(c, h.Store)
+	if !ok {
+		return
+	}
+	node, err := filter.ParseWithWeekStart(view.Filter, user.Now(), time.Weekday(user.WeekStart))
+	if err != nil {
+		c.Error(apperrors.NewInternalServerError(apperrors.CodeViewInvalidFilter, err))
+		return
+	}
+	tasks, err := h.Store.QueryTasks(c.Request.Context(), userID, store.TaskQuery{Filter: node, Sort: view.Sort})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"view": newViewResponse(view), "tasks": newTaskResponses(tasks)})
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newViewRouter(mockStore *store.MockStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	viewHandler := NewViewHandler(mockStore)
+	router := gin.New()
+	router.Use(middleware.ErrorMiddleware(), withUser(1))
+	router.GET("/views", viewHandler.GetViews)
+	router.POST("/views", viewHandler.CreateView)
+	router.GET("/views/:id", viewHandler.GetView)
+	router.PUT("/views/:id", viewHandler.UpdateView)
+	router.DELETE("/views/:id", viewHandler.DeleteView)
+	router.GET("/views/:id/tasks", viewHandler.GetViewTasks)
+	return router
+}
+
+func serveView(router *gin.Engine, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestCreateView 测试创建视图时检查过滤表达式和排序方式，排序默认为 manual
+func TestCreateView(t *testing.T) {
+	// ARRANGE
+	mockStore := new(store.MockStore)
+	mockStore.On("CreateView", mock.Anything, mock.MatchedBy(func(v *models.SavedView) bool {
+		return v.UserID == 1 && v.Name == "Work" && v.Filter == "tag:work" && v.Sort == "manual" && v.Pinned
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.SavedView).ID = 4
+	}).Return(nil).Once()
+	router := newViewRouter(mockStore)
+
+	// ACT & ASSERT
+	w := serveView(router, http.MethodPost, "/views", `{"name":"Work","filter":"tag:work","pinned":true}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":4`)
+
+	w = serveView(router, http.MethodPost, "/views", `{"name":"Bad","filter":"due<<today"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"task.invalid_filter"`)
+
+	//保存的视图每次打开都会重新解析，过长的表达式不能保存
+	w = serveView(router, http.MethodPost, "/views", `{"name":"Long","filter":"`+strings.Repeat("NOT ", 1000)+`done:true"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `/filter`)
+
+	w = serveView(router, http.MethodPost, "/views", `{"name":"Bad","filter":"done:false","sort":"random"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"task.invalid_sort"`)
+	mockStore.AssertExpectations(t)
+}
+
+// TestGetViews 测试列表中同时返回内置视图和保存的视图
+func TestGetViews(t *testing.T) {
+	// ARRANGE
+	mockStore := new(store.MockStore)
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	mockStore.On("GetViews", mock.Anything, 1).Return([]models.SavedView{{ID: 4, UserID: 1, Name: "Work", Filter: "tag:work", Sort: "manual", CreatedAt: created, UpdatedAt: created}}, nil)
+
+	// ACT
+	w := serveView(newViewRouter(mockStore), http.MethodGet, "/views", "")
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"key":"today"`)
+	assert.Contains(t, w.Body.String(), `"builtin":true`)
+	assert.Contains(t, w.Body.String(), `"name":"Work"`)
+	assert.NotContains(t, w.Body.String(), "user_id")
+	assert.NotContains(t, w.Body.String(), "0001-01-01", "内置视图没有时间")
+	assert.Contains(t, w.Body.String(), `"created_at":"2024-03-01T09:00:00Z"`)
+	mockStore.AssertExpectations(t)
+}
+
+// TestBuiltinViews_Readonly 测试内置视图不能修改或删除，未知的 key 返回404
+func TestBuiltinViews_Readonly(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		code   string
+	}{
+		{"修改内置视图", http.MethodPut, "/views/today", `{"name":"x","filter":"done:false"}`, http.StatusBadRequest, "view.builtin_readonly"},
+		{"删除内置视图", http.MethodDelete, "/views/overdue", "", http.StatusBadRequest, "view.builtin_readonly"},
+		{"未知的 key", http.MethodGet, "/views/someday", "", http.StatusNotFound, "view.not_found"},
+		{"别人的视图", http.MethodDelete, "/views/9", "", http.StatusNotFound, "resource.not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := new(store.MockStore)
+			mockStore.On("DeleteView", mock.Anything, 9, 1).Return(store.ErrNotFound).Maybe()
+			w := serveView(newViewRouter(mockStore), tt.method, tt.target, tt.body)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Contains(t, w.Body.String(), `"code":"`+tt.code+`"`)
+		})
+	}
+}
+
+// TestGetViewTasks 测试执行内置视图的查询，使用视图的排序方式
+func TestGetViewTasks(t *testing.T) {
+	// ARRANGE
+	mockStore := new(store.MockStore)
+	mockStore.On("GetUserByID", mock.Anything, 1).Return(&models.User{ID: 1, Timezone: "Asia/Shanghai", WeekStart: 1}, nil)
+	mockStore.On("QueryTasks", mock.Anything, 1, mock.MatchedBy(func(q store.TaskQuery) bool {
+		return q.Filter != nil && q.Sort == "due_asc"
+	})).Return([]models.Task{{ID: 2, UserID: 1, Title: "due today"}}, nil).Once()
+
+	// ACT
+	w := serveView(newViewRouter(mockStore), http.MethodGet, "/views/today/tasks", "")
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"key":"today"`)
+	assert.Contains(t, w.Body.String(), `"title":"due today"`)
+	mockStore.AssertExpectations(t)
+}
@@ -0,0 +1,80 @@
+// Package i18n 提供错误消息等文本的多语言目录
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale 没有匹配的语言时使用的默认语言
+const DefaultLocale = "zh-CN"
+
+// Locales 支持的语言，第一个是默认语言
+var Locales = []string{DefaultLocale, "en"}
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// catalogs 语言 -> 消息 key -> 消息模板，模板中的 {name} 会被替换为参数
+var catalogs = map[string]map[string]string{}
+
+var matcher language.Matcher
+
+func init() {
+	tags := make([]language.Tag, len(Locales))
+	for i, locale := range Locales {
+		data, err := localeFS.ReadFile(path.Join("locales", locale+".json"))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: missing catalog for %s: %v", locale, err))
+		}
+		catalog := map[string]string{}
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog for %s: %v", locale, err))
+		}
+		catalogs[locale] = catalog
+		tags[i] = language.MustParse(locale)
+	}
+	matcher = language.NewMatcher(tags)
+}
+
+// Supported 判断是否支持该语言
+func Supported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// Match 从 Accept-Language 头中选出最合适的语言，没有可接受的语言时返回空字符串
+func Match(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return ""
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return ""
+	}
+	return Locales[index]
+}
+
+// Translate 返回 key 在 locale 中的消息，缺少时依次回退到默认语言和 key 本身
+func Translate(locale string, key string, params map[string]string) string {
+	message, ok := catalogs[locale][key]
+	if !ok {
+		if message, ok = catalogs[DefaultLocale][key]; !ok {
+			return key
+		}
+	}
+	if len(params) == 0 {
+		return message
+	}
+	replacements := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		replacements = append(replacements, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(replacements...).Replace(message)
+}
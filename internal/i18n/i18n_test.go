@@ -0,0 +1,46 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCatalogsComplete 测试每个错误码在所有语言中都有消息
+func TestCatalogsComplete(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "../apperrors/codes.go", nil, 0)
+	assert.NoError(t, err)
+	var codes []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			code, _ := strconv.Unquote(lit.Value)
+			codes = append(codes, code)
+		}
+		return true
+	})
+	assert.NotEmpty(t, codes)
+	for _, locale := range Locales {
+		for _, code := range codes {
+			assert.Contains(t, catalogs[locale], code, "%s 缺少 %s", locale, code)
+		}
+		assert.Len(t, catalogs[locale], len(catalogs[DefaultLocale]), "%s 与默认语言的消息数量不一致", locale)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	assert.Equal(t, "en", Match("en-US,en;q=0.9"))
+	assert.Equal(t, "zh-CN", Match("zh"))
+	assert.Equal(t, "en", Match("fr;q=0.9, en;q=0.5"))
+	assert.Equal(t, "", Match("fr"))
+	assert.Equal(t, "", Match(""))
+}
+
+func TestTranslate(t *testing.T) {
+	assert.Equal(t, "Unsupported sort order: foo", Translate("en", "task.invalid_sort", map[string]string{"sort": "foo"}))
+	assert.Equal(t, "不支持的排序方式: foo", Translate("fr", "task.invalid_sort", map[string]string{"sort": "foo"}))
+	assert.Equal(t, "no.such_code", Translate("en", "no.such_code", nil))
+}
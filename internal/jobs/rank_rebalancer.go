@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/config"
+	"github.com/HywlEch/Todo_list/internal/store"
+)
+
+const (
+	defaultMaxKeyLength      = 16
+	defaultRebalanceInterval = 30 * time.Minute
+)
+
+// RankRebalancer 定期给排序键过长或缺失的列表重新分配均匀分布的短键
+type RankRebalancer struct {
+	Store        store.Store
+	MaxKeyLength int
+	Interval     time.Duration
+}
+
+// NewRankRebalancer 根据配置创建 RankRebalancer，未配置的项使用默认值
+func NewRankRebalancer(s store.Store, cfg config.RankingConfig) *RankRebalancer {
+	maxKeyLength := cfg.MaxKeyLength
+	if maxKeyLength <= 0 {
+		maxKeyLength = defaultMaxKeyLength
+	}
+	interval := time.Duration(cfg.RebalanceIntervalMinutes) * time.Minute
+	if cfg.RebalanceIntervalMinutes <= 0 {
+		interval = defaultRebalanceInterval
+	}
+	return &RankRebalancer{Store: s, MaxKeyLength: maxKeyLength, Interval: interval}
+}
+
+// Run 阻塞运行，直到 ctx 被取消
+func (r *RankRebalancer) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	r.rebalance(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[RankRebalancer]stopped")
+			return
+		case <-ticker.C:
+			r.rebalance(ctx)
+		}
+	}
+}
+
+func (r *RankRebalancer) rebalance(ctx context.Context) {
+	rebalanced, err := r.Store.RebalancePositions(ctx, r.MaxKeyLength)
+	if err != nil {
+		log.Printf("[RankRebalancer]Error: %v", err)
+	}
+	if len(rebalanced) > 0 {
+		log.Printf("[RankRebalancer]rebalanced %d lists", len(rebalanced))
+	}
+}
@@ -0,0 +1,63 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/config"
+	"github.com/HywlEch/Todo_list/internal/store"
+)
+
+const (
+	defaultRetentionDays = 30
+	defaultPurgeInterval = 1 * time.Hour
+)
+
+// TrashPurger 定期彻底删除回收站中超过保留期的任务
+type TrashPurger struct {
+	Store     store.Store
+	Retention time.Duration
+	Interval  time.Duration
+}
+
+// NewTrashPurger 根据配置创建 TrashPurger，未配置的项使用默认值
+func NewTrashPurger(s store.Store, cfg config.TrashConfig) *TrashPurger {
+	retention := time.Duration(cfg.RetentionDays) * 24 * time.Hour
+	if cfg.RetentionDays <= 0 {
+		retention = defaultRetentionDays * 24 * time.Hour
+	}
+	interval := time.Duration(cfg.PurgeIntervalMinutes) * time.Minute
+	if cfg.PurgeIntervalMinutes <= 0 {
+		interval = defaultPurgeInterval
+	}
+	return &TrashPurger{Store: s, Retention: retention, Interval: interval}
+}
+
+// Run 阻塞运行，直到 ctx 被取消
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	p.purge(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[TrashPurger]stopped")
+			return
+		case <-ticker.C:
+			p.purge(ctx)
+		}
+	}
+}
+
+func (p *TrashPurger) purge(ctx context.Context) {
+	n, err := p.Store.PurgeTrash(ctx, time.Now().Add(-p.Retention))
+	if err != nil {
+		log.Printf("[TrashPurger]Error: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("[TrashPurger]purged %d tasks", n)
+	}
+}
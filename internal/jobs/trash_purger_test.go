@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/config"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewTrashPurger_Defaults(t *testing.T) {
+	p := NewTrashPurger(nil, config.TrashConfig{})
+	assert.Equal(t, 30*24*time.Hour, p.Retention)
+	assert.Equal(t, time.Hour, p.Interval)
+
+	p = NewTrashPurger(nil, config.TrashConfig{RetentionDays: 7, PurgeIntervalMinutes: 5})
+	assert.Equal(t, 7*24*time.Hour, p.Retention)
+	assert.Equal(t, 5*time.Minute, p.Interval)
+}
+
+// TestTrashPurger_Run 启动时先清理一次，只删除超过保留期的任务，ctx 取消后退出
+func TestTrashPurger_Run(t *testing.T) {
+	mockStore := store.NewMockStore()
+	purged := make(chan time.Time, 10)
+	mockStore.On("PurgeTrash", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		purged <- args.Get(1).(time.Time)
+	}).Return(int64(2), nil)
+	p := &TrashPurger{Store: mockStore, Retention: 48 * time.Hour, Interval: 10 * time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx)
+		close(done)
+	}()
+
+	cutoff := <-purged
+	assert.WithinDuration(t, time.Now().Add(-48*time.Hour), cutoff, time.Minute)
+	<-purged //按 Interval 定期执行
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run 没有在 ctx 取消后退出")
+	}
+}
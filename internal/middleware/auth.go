@@ -1,9 +1,9 @@
 package middleware
 import (
 	"fmt"
-	"net/http"
 	"strings"
 
+	"github.com/HywlEch/Todo_list/internal/apperrors"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 
@@ -15,13 +15,13 @@ func AuthMiddleware(jwtSecret string)gin.HandlerFunc{
 		//从请求头中获取Authorization字段
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized,gin.H{"error":"缺少Authorization header 字段"})
+			abortUnauthorized(c, apperrors.CodeAuthMissingHeader, nil)
 			return
 		}
 		//验证格式
 		parts := strings.Split(authHeader, " ")
 		if len(parts) !=2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header 格式必须为 'Bearer（持有人）<token>'"})
+			abortUnauthorized(c, apperrors.CodeAuthInvalidHeader, nil)
 			return
 		}
 		tokenString := parts[1]
@@ -34,7 +34,7 @@ func AuthMiddleware(jwtSecret string)gin.HandlerFunc{
 			return []byte(jwtSecret), nil
 		})
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error":"验证token失败"+err.Error()})
+			abortUnauthorized(c, apperrors.CodeAuthInvalidToken, err)
 			return
 		}
 		//从token中提取Claims
@@ -42,7 +42,7 @@ func AuthMiddleware(jwtSecret string)gin.HandlerFunc{
 			//提取用户ID并存入上下文
 			userIDFloat, ok := claims["user_id"].(float64)//JWT库默认吧数字转换成浮点数
 			if !ok {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "无效的用户ID"})
+				abortUnauthorized(c, apperrors.CodeAuthInvalidToken, nil)
 				return
 			}
 			userID := int(userIDFloat)
@@ -52,8 +52,15 @@ func AuthMiddleware(jwtSecret string)gin.HandlerFunc{
 			//放行请求
 			c.Next()
 		}else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "INvalid token claims"})
+			abortUnauthorized(c, apperrors.CodeAuthInvalidToken, nil)
 			return
 		}
 	}
-}
\ No newline at end of file
+}
+
+// abortUnauthorized 中止请求，由 ErrorMiddleware 返回401
+func abortUnauthorized(c *gin.Context, errorCode string, err error) {
+	c.Header("WWW-Authenticate", `Bearer realm="api"`)
+	c.Error(apperrors.NewUnauthorizedError(errorCode, err))
+	c.Abort()
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecation API 版本的弃用信息，零值表示未弃用
+type Deprecation struct {
+	Deprecated time.Time //开始弃用的时间，可以是将来的时间
+	Sunset     time.Time //停止服务的时间，零值表示还没有确定
+	Successor  string    //替代版本的路径前缀，例如 /api/v2
+}
+
+// APIVersionMiddleware 在响应中标明 API 版本
+// 已弃用的版本返回 Deprecation（RFC 9745）、Sunset（RFC 8594）和指向替代版本的 Link，过了停止服务的时间后返回410
+// prefix 是该版本的路由前缀，替代版本的链接由 Successor 加上去掉前缀后的路径组成
+func APIVersionMiddleware(prefix, version string, dep Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("API-Version", version)
+		if dep.Deprecated.IsZero() {
+			c.Next()
+			return
+		}
+		c.Header("Deprecation", "@"+strconv.FormatInt(dep.Deprecated.Unix(), 10))
+		if !dep.Sunset.IsZero() {
+			c.Header("Sunset", dep.Sunset.UTC().Format(http.TimeFormat))
+		}
+		successor := ""
+		if dep.Successor != "" {
+			successor = dep.Successor + strings.TrimPrefix(c.Request.URL.Path, prefix)
+			c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+		}
+		if !dep.Sunset.IsZero() && !time.Now().Before(dep.Sunset) {
+			c.Error(apperrors.NewGoneError(apperrors.CodeAPISunset, nil).With("successor", successor))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
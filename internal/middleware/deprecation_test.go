@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newVersionRouter(dep Deprecation) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorMiddleware())
+	group := router.Group("/api/v1", APIVersionMiddleware("/api/v1", "v1", dep))
+	group.GET("/tasks/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func TestAPIVersionMiddleware(t *testing.T) {
+	deprecated := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("未弃用时只返回版本", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newVersionRouter(Deprecation{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/tasks/1", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "v1", w.Header().Get("API-Version"))
+		assert.Empty(t, w.Header().Get("Deprecation"))
+	})
+
+	t.Run("已弃用", func(t *testing.T) {
+		sunset := time.Now().Add(24 * time.Hour)
+		w := httptest.NewRecorder()
+		router := newVersionRouter(Deprecation{Deprecated: deprecated, Sunset: sunset, Successor: "/api/v2"})
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/tasks/1", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "@1767225600", w.Header().Get("Deprecation"))
+		assert.Equal(t, sunset.UTC().Format(http.TimeFormat), w.Header().Get("Sunset"))
+		assert.Equal(t, `</api/v2/tasks/1>; rel="successor-version"`, w.Header().Get("Link"))
+	})
+
+	t.Run("停止服务后返回410", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router := newVersionRouter(Deprecation{Deprecated: deprecated, Sunset: time.Now().Add(-time.Hour), Successor: "/api/v2"})
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/tasks/1", nil))
+		assert.Equal(t, http.StatusGone, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"api.sunset"`)
+		assert.Contains(t, w.Body.String(), "/api/v2/tasks/1")
+	})
+}
@@ -1,53 +1,93 @@
 package middleware
 
 import (
+	"context"
 	"errors"
-	"net/http"
+	"fmt"
 	"log"
+	"net/http"
 
-	"github.com/HywlEch/Todo_list/internal/store"
 	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/i18n"
+	"github.com/HywlEch/Todo_list/internal/store"
 	"github.com/gin-gonic/gin"
 )
 
-//ErrorMiddleware 全局错误中间件
-func ErrorMiddleware() gin.HandlerFunc { 
-	return func(c *gin.Context){
+// storeErrors store 的哨兵错误对应的HTTP状态码和错误码
+var storeErrors = []struct {
+	err       error
+	status    int
+	errorCode string
+}{
+	{store.ErrNotFound, http.StatusNotFound, apperrors.CodeNotFound},
+	{store.ErrUserExists, http.StatusConflict, apperrors.CodeUserExists},
+	{store.ErrVersionConflict, http.StatusPreconditionFailed, apperrors.CodeTaskVersionConflict},
+	{store.ErrInvalidMove, http.StatusBadRequest, apperrors.CodeTaskInvalidMove},
+	{store.ErrUnknownProject, http.StatusUnprocessableEntity, apperrors.CodeProjectUnknown},
+	{store.ErrInvalidStatus, http.StatusUnprocessableEntity, apperrors.CodeTaskInvalidStatus},
+	{store.ErrTransitionNotAllowed, http.StatusConflict, apperrors.CodeTaskTransitionNotAllowed},
+	{store.ErrWIPLimitExceeded, http.StatusConflict, apperrors.CodeProjectWIPLimitExceeded},
+	{store.ErrStatusInUse, http.StatusConflict, apperrors.CodeProjectStatusInUse},
+	{store.ErrTimerRunning, http.StatusConflict, apperrors.CodeTimerRunning},
+}
+
+// ToAppError 把错误转换为 AppError，store 的哨兵错误使用对应的错误码，其他错误视为500
+func ToAppError(err error) *apperrors.AppError {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return apperrors.NewGatewayTimeoutError("", err)
+	}
+	for _, e := range storeErrors {
+		if errors.Is(err, e.err) {
+			return apperrors.NewAppError(e.status, e.errorCode, err)
+		}
+	}
+	return apperrors.NewInternalServerError("", err)
+}
+
+// Message 按请求的语言生成错误消息
+func Message(c *gin.Context, appErr *apperrors.AppError) string {
+	var params map[string]string
+	if len(appErr.Params) > 0 {
+		params = make(map[string]string, len(appErr.Params))
+		for name, value := range appErr.Params {
+			if nested, ok := value.(*apperrors.AppError); ok {
+				params[name] = Message(c, nested)
+			} else {
+				params[name] = fmt.Sprint(value)
+			}
+		}
+	}
+	return i18n.Translate(Locale(c), appErr.ErrorCode, params)
+}
+
+// ErrorMiddleware 全局错误中间件
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
 		//首先执行链路中的下一个handler
 		c.Next()
 
 		//c.Error()是一个[]*gin.Error
-		if len(c.Errors) == 0 { 
+		if len(c.Errors) == 0 {
 			return
 		}
 
 		err := c.Errors[0].Err
-
-		//定义默认的错误响应
-		httpCode := http.StatusInternalServerError
-		jsonResponse := gin.H{"errors": "Internal Server Error"}
-
-		var appErr *apperrors.AppError
-		if errors.As(err, &appErr) { 
-			httpCode = appErr.Code
-			jsonResponse = gin.H{"errors": appErr.Message}
-		}else if errors.Is(err, store.ErrNotFound) { 
-			httpCode = http.StatusNotFound
-			jsonResponse = gin.H{"errors": "Not Found"}
-		}else if errors.Is(err, store.ErrUserExists) { 
-			httpCode = http.StatusConflict
-			jsonResponse = gin.H{"errors": "User Already Exists"}
-		}
+		appErr := ToAppError(err)
+		httpCode := appErr.Code
 
 		//记录日志 500错误需要记录完整得错误信息，而4XX错误只需要info级别
-		if httpCode >=500 {
+		if httpCode >= 500 {
 			log.Printf("Internal Server Error: %v\nFull error chain: %+v", err, err)
-		}else {
+		} else {
 			log.Printf("Client Error(%d): %v", httpCode, err)
 		}
-		//返回json响应
-	if !c.Writer.Written() {
-		c.AbortWithStatusJSON(httpCode, jsonResponse)
-	}
+		//返回 RFC 9457 application/problem+json 响应
+		if !c.Writer.Written() {
+			AbortWithProblem(c, appErr)
+		}
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	idempotencyHeader     = "Idempotency-Key"
+	idempotencyTTL        = 24 * time.Hour   //保存响应的时间
+	idempotencyLockTTL    = 30 * time.Second //处理中请求的锁，防止进程崩溃后永久占用
+	idempotencyMaxKeySize = 255
+)
+
+// MaxBodyBytes 请求体的大小上限，handlers 解析 JSON 时使用同样的值
+const MaxBodyBytes = 1 << 20
+
+// replayedHeaders 重放时除了 Content-Type 之外还要返回的响应头
+var replayedHeaders = []string{"ETag", "Location"}
+
+// releaseLockScript 只删除自己持有的锁
+// 锁过期后可能已被其他请求拿到，直接 DEL 会把别人的锁删掉
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// idempotencyRecord 保存在redis中的响应
+type idempotencyRecord struct {
+	Fingerprint string            `json:"fingerprint"`
+	Status      int               `json:"status"`
+	ContentType string            `json:"content_type"`
+	Header      map[string]string `json:"header,omitempty"`
+	Body        []byte            `json:"body"`
+}
+
+// bodyRecorder 在写给客户端的同时记录响应体
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware 对带 Idempotency-Key 的 POST/PUT/PATCH/DELETE 请求，
+// 24小时内的重试直接返回第一次的响应；同一个key但请求体不同时返回422
+// 需要放在 AuthMiddleware 之后，key 按用户隔离；redisClient 为空时不做处理
+func IdempotencyMiddleware(redisClient *redis.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyHeader)
+		method := c.Request.Method
+		if redisClient == nil || key == "" || (method != http.MethodPost && method != http.MethodPut && method != http.MethodPatch && method != http.MethodDelete) {
+			c.Next()
+			return
+		}
+		if len(key) > idempotencyMaxKeySize {
+			c.Error(apperrors.NewBadRequestError(apperrors.CodeIdempotencyKeyTooLong, nil))
+			c.Abort()
+			return
+		}
+
+		//需要读出整个请求体计算指纹，先限制大小
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxBodyBytes)
+		body, err := io.ReadAll(c.Request.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.Error(apperrors.NewRequestEntityTooLargeError(apperrors.CodeBodyTooLarge, err).With("limit", tooLarge.Limit))
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.Error(apperrors.NewBadRequestError(apperrors.CodeReadBodyFailed, err))
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		//查询参数也会改变请求的含义，一起计入指纹
+		sum := sha256.Sum256(append([]byte(method+" "+c.Request.URL.Path+"?"+c.Request.URL.RawQuery+"\n"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		ctx := c.Request.Context()
+		recordKey := fmt.Sprintf("idempotency:%v:%s", c.MustGet("user_id"), key)
+		lockKey := recordKey + ":lock"
+
+		if replayIdempotentResponse(c, redisClient, recordKey, fingerprint) {
+			return
+		}
+		//同一个key的并发请求只允许一个进入处理，锁的值是本次请求的随机令牌
+		token := make([]byte, 16)
+		if _, err := rand.Read(token); err != nil {
+			c.Error(apperrors.NewInternalServerError("", err))
+			c.Abort()
+			return
+		}
+		lockValue := hex.EncodeToString(token)
+		locked, err := redisClient.SetNX(ctx, lockKey, lockValue, idempotencyLockTTL).Result()
+		if err != nil {
+			log.Println("redis setnx error:", err)
+			c.Next()
+			return
+		}
+		if !locked {
+			//拿锁期间第一个请求可能已经完成
+			if replayIdempotentResponse(c, redisClient, recordKey, fingerprint) {
+				return
+			}
+			c.Error(apperrors.NewConfilictError(apperrors.CodeIdempotencyInProgress, nil))
+			c.Abort()
+			return
+		}
+		//请求超时后ctx已取消，释放锁使用独立的context
+		defer func() {
+			if err := releaseLockScript.Run(context.Background(), redisClient, []string{lockKey}, lockValue).Err(); err != nil {
+				log.Println("redis release idempotency lock error:", err)
+			}
+		}()
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		//失败的请求不保存，客户端可以用同一个key重试
+		status := c.Writer.Status()
+		if len(c.Errors) > 0 || status >= http.StatusInternalServerError {
+			return
+		}
+		header := map[string]string{}
+		for _, name := range replayedHeaders {
+			if value := c.Writer.Header().Get(name); value != "" {
+				header[name] = value
+			}
+		}
+		record, err := json.Marshal(idempotencyRecord{
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: c.Writer.Header().Get("Content-Type"),
+			Header:      header,
+			Body:        recorder.body.Bytes(),
+		})
+		if err != nil {
+			log.Println("idempotency marshal error:", err)
+			return
+		}
+		if err := redisClient.Set(ctx, recordKey, record, idempotencyTTL).Err(); err != nil {
+			log.Println("redis set idempotency record error:", err)
+		}
+	}
+}
+
+// replayIdempotentResponse 如果已经保存过响应则直接返回它，返回值表示请求是否已被处理
+func replayIdempotentResponse(c *gin.Context, redisClient *redis.Client, recordKey string, fingerprint string) bool {
+	val, err := redisClient.Get(c.Request.Context(), recordKey).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Println("redis get idempotency record error:", err)
+		}
+		return false
+	}
+	var record idempotencyRecord
+	if err := json.Unmarshal(val, &record); err != nil {
+		log.Println("idempotency unmarshal error:", err)
+		return false
+	}
+	if record.Fingerprint != fingerprint {
+		c.Error(apperrors.NewUnprocessableEntityError(apperrors.CodeIdempotencyKeyReused, nil))
+		c.Abort()
+		return true
+	}
+	c.Header("Idempotent-Replayed", "true")
+	for name, value := range record.Header {
+		c.Header(name, value)
+	}
+	if len(record.Body) == 0 {
+		c.AbortWithStatus(record.Status)
+		return true
+	}
+	c.Data(record.Status, record.ContentType, record.Body)
+	c.Abort()
+	return true
+}
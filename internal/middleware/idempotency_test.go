@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testLockKey = "idempotency:1:k1:lock"
+
+// newIdempotencyRouter handler 每次执行都会调用 handle，返回执行次数
+func newIdempotencyRouter(t *testing.T, handle func(c *gin.Context)) (*gin.Engine, *miniredis.Miniredis, *int) {
+	gin.SetMode(gin.TestMode)
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	calls := 0
+	var mu sync.Mutex
+	router := gin.New()
+	router.Use(ErrorMiddleware(), func(c *gin.Context) { c.Set("user_id", 1) }, IdempotencyMiddleware(client))
+	router.POST("/tasks", func(c *gin.Context) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		handle(c)
+	})
+	return router, mr, &calls
+}
+
+func postIdempotent(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", "k1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_Replay(t *testing.T) {
+	router, mr, calls := newIdempotencyRouter(t, func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	first := postIdempotent(router, `{"title":"a"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	second := postIdempotent(router, `{"title":"a"}`)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, 1, *calls, "重试不再执行 handler")
+	assert.False(t, mr.Exists(testLockKey), "处理完成后释放锁")
+}
+
+func TestIdempotency_KeyReusedWithDifferentBody(t *testing.T) {
+	router, _, calls := newIdempotencyRouter(t, func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	postIdempotent(router, `{"title":"a"}`)
+	w := postIdempotent(router, `{"title":"b"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"code":"idempotency.key_reused"`)
+	assert.Equal(t, 1, *calls)
+}
+
+func TestIdempotency_KeyReusedWithDifferentQuery(t *testing.T) {
+	router, _, calls := newIdempotencyRouter(t, func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	postIdempotent(router, `{"title":"a"}`)
+	req := httptest.NewRequest(http.MethodPost, "/tasks?atomic=true", strings.NewReader(`{"title":"a"}`))
+	req.Header.Set("Idempotency-Key", "k1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"idempotency.key_reused"`)
+	assert.Equal(t, 1, *calls)
+}
+
+func TestIdempotency_InFlight(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	router, _, calls := newIdempotencyRouter(t, func(c *gin.Context) {
+		close(entered)
+		<-release
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- postIdempotent(router, `{"title":"a"}`) }()
+	<-entered
+	w := postIdempotent(router, `{"title":"a"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"idempotency.in_progress"`)
+
+	close(release)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+	assert.Equal(t, 1, *calls)
+}
+
+// TestIdempotency_ExpiredLockNotReleased 锁过期后被其他请求拿到时，慢请求结束时不能删除别人的锁
+func TestIdempotency_ExpiredLockNotReleased(t *testing.T) {
+	var mr *miniredis.Miniredis
+	router, mr, _ := newIdempotencyRouter(t, func(c *gin.Context) {
+		mr.FastForward(idempotencyLockTTL)
+		require.False(t, mr.Exists(testLockKey))
+		require.NoError(t, mr.Set(testLockKey, "other-request"))
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	assert.Equal(t, http.StatusCreated, postIdempotent(router, `{"title":"a"}`).Code)
+	value, err := mr.Get(testLockKey)
+	require.NoError(t, err)
+	assert.Equal(t, "other-request", value)
+}
+
+// TestIdempotency_FailureNotStored 失败的请求不保存响应，可以用同一个key重试
+func TestIdempotency_FailureNotStored(t *testing.T) {
+	fail := true
+	router, _, calls := newIdempotencyRouter(t, func(c *gin.Context) {
+		if fail {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, postIdempotent(router, `{}`).Code)
+	fail = false
+	w := postIdempotent(router, `{}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 2, *calls)
+}
+
+// TestIdempotency_ReplayHeaders 重放时返回第一次响应的 ETag 和 Location
+func TestIdempotency_ReplayHeaders(t *testing.T) {
+	router, _, calls := newIdempotencyRouter(t, func(c *gin.Context) {
+		c.Header("ETag", `"3"`)
+		c.Header("Location", "/api/v1/tasks/1")
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	postIdempotent(router, `{"title":"a"}`)
+	w := postIdempotent(router, `{"title":"a"}`)
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	assert.Equal(t, "/api/v1/tasks/1", w.Header().Get("Location"))
+	assert.Equal(t, 1, *calls)
+}
+
+// TestIdempotency_BodyTooLarge 计算指纹前限制请求体的大小
+func TestIdempotency_BodyTooLarge(t *testing.T) {
+	router, mr, calls := newIdempotencyRouter(t, func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	w := postIdempotent(router, `{"title":"`+strings.Repeat("a", MaxBodyBytes)+`"}`)
+	assertProblem(t, w, http.StatusRequestEntityTooLarge, "request.body_too_large")
+	assert.Equal(t, 0, *calls)
+	assert.False(t, mr.Exists(testLockKey))
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"github.com/HywlEch/Todo_list/internal/i18n"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	localeKey      = "locale"
+	localeStoreKey = "locale_store"
+)
+
+// LocaleMiddleware 让 Locale 可以读取已登录用户资料中的语言
+// 资料只在需要时（例如返回错误时）才读取，不会给每个请求增加一次查询
+func LocaleMiddleware(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(localeStoreKey, s)
+		c.Next()
+	}
+}
+
+// Locale 返回请求使用的语言：Accept-Language 中支持的语言优先，其次是已登录用户资料中的语言，最后是默认语言
+func Locale(c *gin.Context) string {
+	if locale := c.GetString(localeKey); locale != "" {
+		return locale
+	}
+	if locale := i18n.Match(c.GetHeader("Accept-Language")); locale != "" {
+		c.Set(localeKey, locale)
+		return locale
+	}
+	//AuthMiddleware 以 "user_id" 为键写入上下文，未登录时不缓存结果
+	userID := c.GetInt("user_id")
+	s, ok := c.Value(localeStoreKey).(store.Store)
+	if userID == 0 || !ok {
+		return i18n.DefaultLocale
+	}
+	locale := i18n.DefaultLocale
+	if user, err := s.GetUserByID(c.Request.Context(), userID); err == nil && i18n.Supported(user.Locale) {
+		locale = user.Locale
+	}
+	c.Set(localeKey, locale)
+	return locale
+}
@@ -32,11 +32,12 @@ func Logger() gin.HandlerFunc{
 		clientIP := c.ClientIP()
 
 		// 格式化日志输出
-		log.Printf("[GIN] %v | %3d | %13v | %15s | %-7s %s",
+		log.Printf("[GIN] %v | %3d | %13v | %15s | %s | %-7s %s",
 			endTime.Format("2006/01/02 - 15:04:05"),
 			statusCode,
 			latencyTime,
 			clientIP,
+			RequestID(c),
 			reqMethod,
 			reqURI,
 		)
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/i18n"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// ProblemContentType RFC 9457 错误响应的媒体类型
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix 问题类型的 URI 前缀，后面跟错误码
+const problemTypePrefix = "/problems/"
+
+// Problem RFC 9457 错误响应
+// Code 和 Errors 是扩展成员，Extensions 中的成员也会平铺到顶层
+type Problem struct {
+	Type       string         `json:"type"`
+	Title      string         `json:"title"`
+	Status     int            `json:"status"`
+	Detail     string         `json:"detail,omitempty"`
+	Instance   string         `json:"instance,omitempty"` //请求ID
+	Code       string         `json:"code"`
+	Errors     []FieldError   `json:"errors,omitempty"`
+	Extensions map[string]any `json:"-"`
+}
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Pointer string `json:"pointer"` //JSON Pointer，例如 /operations/0/title
+	Rule    string `json:"rule"`    //校验规则，例如 required、max
+	Param   string `json:"param,omitempty"`
+	Detail  string `json:"detail"`
+}
+
+// MarshalJSON 把扩展成员和标准成员输出到同一个对象中，标准成员优先
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	data, err := json.Marshal(problem(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+	members := make(map[string]any, len(p.Extensions)+8)
+	for name, value := range p.Extensions {
+		members[name] = value
+	}
+	var standard map[string]json.RawMessage
+	if err := json.Unmarshal(data, &standard); err != nil {
+		return nil, err
+	}
+	for name, value := range standard {
+		members[name] = value
+	}
+	return json.Marshal(members)
+}
+
+func init() {
+	//让校验错误中的字段名与 JSON 中的名称一致
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// NewProblem 按请求的语言生成错误响应
+func NewProblem(c *gin.Context, appErr *apperrors.AppError) *Problem {
+	locale := Locale(c)
+	return &Problem{
+		Type:       problemTypePrefix + appErr.ErrorCode,
+		Title:      http.StatusText(appErr.Code),
+		Status:     appErr.Code,
+		Detail:     Message(c, appErr),
+		Instance:   RequestID(c),
+		Code:       appErr.ErrorCode,
+		Errors:     fieldErrors(appErr.Err, locale),
+		Extensions: appErr.Extensions,
+	}
+}
+
+// AbortWithProblem 中止请求并返回 application/problem+json
+func AbortWithProblem(c *gin.Context, appErr *apperrors.AppError) {
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(appErr.Code, NewProblem(c, appErr))
+}
+
+var indexPattern = regexp.MustCompile(`\[([^\]]*)\]`)
+
+// fieldErrors 把 validator 的校验错误和 JSON 的类型错误转换为字段错误
+func fieldErrors(err error, locale string) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		result := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			//Namespace 形如 BulkRequest.operations[0].title，去掉最外层的结构体名
+			namespace := fe.Namespace()
+			if i := strings.Index(namespace, "."); i >= 0 {
+				namespace = namespace[i+1:]
+			}
+			namespace = indexPattern.ReplaceAllString(namespace, ".$1")
+			result = append(result, FieldError{
+				Pointer: jsonPointer(strings.Split(namespace, ".")),
+				Rule:    fe.Tag(),
+				Param:   fe.Param(),
+				Detail:  ruleMessage(locale, fe.Tag(), fe.Param(), fe.Kind()),
+			})
+		}
+		return result
+	}
+	var violations apperrors.FieldViolations
+	if errors.As(err, &violations) {
+		result := make([]FieldError, 0, len(violations))
+		for _, v := range violations {
+			result = append(result, FieldError{
+				Pointer: jsonPointer(strings.Split(v.Field, ".")),
+				Rule:    v.Rule,
+				Param:   v.Param,
+				Detail:  ruleMessage(locale, v.Rule, v.Param, reflect.Invalid),
+			})
+		}
+		return result
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		param := typeErr.Type.String()
+		return []FieldError{{
+			Pointer: jsonPointer(strings.Split(typeErr.Field, ".")),
+			Rule:    "type",
+			Param:   param,
+			Detail:  ruleMessage(locale, "type", param, reflect.Invalid),
+		}}
+	}
+	return nil
+}
+
+// jsonPointer 按 RFC 6901 拼接 JSON Pointer
+func jsonPointer(tokens []string) string {
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString("/")
+		b.WriteString(escaper.Replace(token))
+	}
+	return b.String()
+}
+
+// ruleMessage 校验规则的本地化说明，字符串和数组的 min/max 指长度
+func ruleMessage(locale string, rule string, param string, kind reflect.Kind) string {
+	key := "validation." + rule
+	switch kind {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		if rule == "min" || rule == "max" {
+			key += "_length"
+		}
+	}
+	params := map[string]string{"param": param}
+	if message := i18n.Translate(locale, key, params); message != key {
+		return message
+	}
+	return i18n.Translate(locale, "validation.invalid", params)
+}
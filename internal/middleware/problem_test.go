@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertProblem 检查响应是 problem+json，并且 type 和 code 与错误码一致
+func assertProblem(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	assert.Equal(t, status, w.Code)
+	assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+	var problem Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "/problems/"+code, problem.Type)
+	assert.Equal(t, code, problem.Code)
+	assert.Equal(t, status, problem.Status)
+	assert.NotEmpty(t, problem.Detail)
+}
+
+// TestAuthMiddleware_Problem 缺少或无效的令牌返回401 problem+json
+func TestAuthMiddleware_Problem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorMiddleware(), AuthMiddleware("test-secret"))
+	router.GET("/tasks", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name   string
+		header string
+		code   string
+	}{
+		{"缺少 Authorization", "", "auth.missing_header"},
+		{"不是 Bearer", "Basic abc", "auth.invalid_header"},
+		{"无效的令牌", "Bearer not-a-jwt", "auth.invalid_token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assertProblem(t, w, http.StatusUnauthorized, tt.code)
+			assert.Equal(t, `Bearer realm="api"`, w.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
+
+// TestRateLimitMiddleware_Problem 超过限制后返回429 problem+json 和 Retry-After
+func TestRateLimitMiddleware_Problem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	router := gin.New()
+	router.Use(ErrorMiddleware(), RateLimitMiddleware(client))
+	router.GET("/tasks", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	require.NoError(t, mr.Set("rate_limit:192.0.2.1", "100"))
+	mr.SetTTL("rate_limit:192.0.2.1", 30*time.Second)
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assertProblem(t, w, http.StatusTooManyRequests, "rate_limit.exceeded")
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+}
+
+// TestTimeoutMiddleware_Problem handler 超时且没有写出响应时返回504 problem+json
+func TestTimeoutMiddleware_Problem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorMiddleware(), TimeoutMiddleware(10*time.Millisecond))
+	router.GET("/stats", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.Error(c.Request.Context().Err())
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stats", nil))
+
+	assertProblem(t, w, http.StatusGatewayTimeout, "request.timeout")
+}
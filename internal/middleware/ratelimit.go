@@ -1,10 +1,12 @@
 package middleware
 
 import (
-	"net/http"
+	"math"
+	"strconv"
 	"time"
 	"log"
 
+	"github.com/HywlEch/Todo_list/internal/apperrors"
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 
@@ -15,8 +17,13 @@ const (
 	rateLimitMax    = 100 //周期最大请求数
 )
 
+// RateLimitMiddleware 按IP限制请求频率，redisClient 为空时不限制
 func RateLimitMiddleware(redisClient *redis.Client)gin.HandlerFunc{
 	return func(c *gin.Context){
+		if redisClient == nil {
+			c.Next()
+			return
+		}
 		ctx := c.Request.Context()
 
 		ip := c.ClientIP()
@@ -33,7 +40,13 @@ func RateLimitMiddleware(redisClient *redis.Client)gin.HandlerFunc{
 			redisClient.Expire(ctx, key, rateLimitPeriod)
 		}
 		if count > rateLimitMax{
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error":"访问过于频繁"})
+			//由 ErrorMiddleware 返回429，Retry-After 告诉客户端多久之后重试
+			if ttl, err := redisClient.TTL(ctx, key).Result(); err == nil && ttl > 0 {
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(ttl.Seconds()))))
+			}
+			c.Error(apperrors.NewTooManyRequestsError(apperrors.CodeRateLimited, nil))
+			c.Abort()
+			return
 		}
 		c.Next()
 	}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRedisMiddlewares_NilClient 没有配置 Redis 时限流和幂等中间件直接放行
+func TestRedisMiddlewares_NilClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorMiddleware(), RateLimitMiddleware(nil), IdempotencyMiddleware(nil))
+	calls := 0
+	router.POST("/tasks", func(c *gin.Context) {
+		calls++
+		c.Status(http.StatusCreated)
+	})
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{}`))
+		req.Header.Set("Idempotency-Key", "k1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+	assert.Equal(t, 2, calls, "没有 Redis 时不记录响应，每次都执行 handler")
+}
@@ -2,9 +2,9 @@ package middleware
 
 import (
 	"context"
-	"net/http"
 	"time"
 
+	"github.com/HywlEch/Todo_list/internal/apperrors"
 	"github.com/gin-gonic/gin"
 )
 
@@ -20,9 +20,14 @@ func TimeoutMiddleware(timeout time.Duration)gin.HandlerFunc{
 		c.Next()
 
 		//c.Next()执行完毕之后，检查context是否超时
-		if ctx.Err() == context.DeadlineExceeded{
-			//如果是，设置504GatewayTimeout状态码
-			c.Writer.WriteHeader(http.StatusGatewayTimeout)
+		if ctx.Err() == context.DeadlineExceeded && !c.Writer.Written() {
+			//如果是，由 ErrorMiddleware 返回504，handler 返回的错误作为原因记录在日志中
+			var cause error = ctx.Err()
+			if last := c.Errors.Last(); last != nil {
+				cause = last.Err
+			}
+			c.Errors = c.Errors[:0]
+			c.Error(apperrors.NewGatewayTimeoutError(apperrors.CodeTimeout, cause))
 		}
 	}
 }
\ No newline at end of file
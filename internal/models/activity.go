@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// 任务动态的动作类型
+const (
+	ActivityTaskCreated    = "task.created"
+	ActivityTitleChanged   = "task.title_changed"
+	ActivityContentChanged = "task.content_changed"
+	ActivityTaskCompleted  = "task.completed"
+	ActivityTaskReopened   = "task.reopened"
+	ActivityTaskTrashed    = "task.trashed"
+	ActivityTaskRestored   = "task.restored"
+	ActivityStatusChanged  = "task.status_changed"
+	ActivityTaskUnblocked  = "task.unblocked"
+)
+
+// 动态流中条目的类型
+const (
+	FeedKindComment = "comment"
+	FeedKindEvent   = "event"
+)
+
+// ActivityEvent 自动生成的任务变更记录
+type ActivityEvent struct {
+	ID        int       `json:"id" db:"id"`
+	TaskID    int       `json:"task_id" db:"task_id"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	Action    string    `json:"action" db:"action"`
+	OldValue  *string   `json:"old_value,omitempty" db:"old_value"`
+	NewValue  *string   `json:"new_value,omitempty" db:"new_value"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// FeedItem GET /tasks/:id/activity 返回的条目，评论和变更记录合并在一起
+type FeedItem struct {
+	Kind      string    `json:"kind" db:"kind"`
+	ID        int       `json:"id" db:"id"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	Username  string    `json:"username" db:"username"`
+	Content   *string   `json:"content,omitempty" db:"content"`
+	Action    *string   `json:"action,omitempty" db:"action"`
+	OldValue  *string   `json:"old_value,omitempty" db:"old_value"`
+	NewValue  *string   `json:"new_value,omitempty" db:"new_value"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
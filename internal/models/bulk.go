@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// 批量操作类型
+const (
+	BulkOpCreate   = "create"
+	BulkOpUpdate   = "update"
+	BulkOpDelete   = "delete"
+	BulkOpComplete = "complete"
+	BulkOpMove     = "move"
+)
+
+// BulkOperation POST /tasks/bulk 中的一个操作
+type BulkOperation struct {
+	Op        string          `json:"op" binding:"required,oneof=create update delete complete move"`
+	ID        int             `json:"id"`
+	Version   int             `json:"version"` //期望的版本号，0表示不检查
+	Title     *string         `json:"title" binding:"omitempty,notblank,max=200"`
+	Content   *string         `json:"content" binding:"omitempty,max=20000"`
+	Done      *bool           `json:"done"`
+	DueAt     Null[time.Time] `json:"due_at"` //update 操作中为 null 时清空截止时间
+	Priority  *int            `json:"priority" binding:"omitempty,min=0,max=4"`
+	Tags      *[]string       `json:"tags" binding:"omitempty,max=20,dive,notblank,max=50"`
+	ProjectID Null[int]       `json:"project_id"` //update 操作中为 null 时移出项目
+	Status    *string         `json:"status" binding:"omitempty,max=32"`
+	BeforeID  int             `json:"before_id"` //move 操作：移动到该任务之前
+	AfterID   int             `json:"after_id"`  //move 操作：移动到该任务之后
+}
+
+// BulkResult 单个批量操作的执行结果，Err 为空表示成功
+type BulkResult struct {
+	Index int
+	Op    string
+	ID    int
+	Task  *Task
+	Err   error
+}
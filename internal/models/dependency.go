@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Dependency 任务之间的依赖：BlockerID 完成之前 BlockedID 处于阻塞状态
+type Dependency struct {
+	BlockerID int       `json:"blocker_id" db:"blocker_id"`
+	BlockedID int       `json:"blocked_id" db:"blocked_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// DependencyGraph GET /tasks/:id/dependencies 返回的依赖图
+// 包含该任务直接或间接依赖的任务，以及直接或间接依赖它的任务
+type DependencyGraph struct {
+	TaskID int          `json:"task_id"`
+	Nodes  []Task       `json:"nodes"`
+	Edges  []Dependency `json:"edges"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Null 部分更新中可以清空的字段，区分 JSON 中没有该字段、值为 null 和具体的值
+// Set 为 true 表示请求中出现了该字段，此时 Value 为 nil 表示 null
+type Null[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (n *Null[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	n.Value = nil
+	if string(data) == "null" {
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	n.Value = &v
+	return nil
+}
+
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.Value)
+}
+
+// ElemType 返回 *T，OpenAPI 文档中把该字段描述为可以为 null 的 T
+func (Null[T]) ElemType() reflect.Type {
+	return reflect.TypeOf((*T)(nil))
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+)
+
+// 工作流状态的类别，done 类别的状态对应任务的 done=true
+const (
+	StatusCategoryTodo       = "todo"
+	StatusCategoryInProgress = "in_progress"
+	StatusCategoryDone       = "done"
+)
+
+// Project 项目，每个项目有自己的工作流
+type Project struct {
+	ID        int       `json:"id" db:"id"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	Workflow  Workflow  `json:"workflow" db:"workflow"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WorkflowStatus 工作流中的一个状态，也是看板中的一列
+type WorkflowStatus struct {
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	WIPLimit *int   `json:"wip_limit,omitempty"` //该列最多容纳的任务数，为空表示不限制
+}
+
+// StatusTransition 允许的状态转换
+type StatusTransition struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Workflow 状态按看板中列的顺序排列
+// Transitions 为空表示任意两个状态之间都可以转换
+type Workflow struct {
+	Statuses    []WorkflowStatus   `json:"statuses"`
+	Transitions []StatusTransition `json:"transitions,omitempty"`
+}
+
+// DefaultWorkflow 不属于任何项目的任务使用的工作流
+func DefaultWorkflow() Workflow {
+	return Workflow{Statuses: []WorkflowStatus{
+		{Key: "todo", Name: "To do", Category: StatusCategoryTodo},
+		{Key: "in_progress", Name: "In progress", Category: StatusCategoryInProgress},
+		{Key: "done", Name: "Done", Category: StatusCategoryDone},
+	}}
+}
+
+var statusKeyPattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// Validate 检查工作流是否完整：至少有一个待办类和一个完成类的状态，转换只引用存在的状态
+// 返回的错误是 *apperrors.AppError，作为 project.invalid_workflow 的 detail
+func (w Workflow) Validate() error {
+	seen := make(map[string]bool, len(w.Statuses))
+	hasTodo, hasDone := false, false
+	for _, s := range w.Statuses {
+		if !statusKeyPattern.MatchString(s.Key) {
+			return apperrors.NewBadRequestError(apperrors.CodeWorkflowInvalidKey, nil).With("key", s.Key)
+		}
+		if seen[s.Key] {
+			return apperrors.NewBadRequestError(apperrors.CodeWorkflowDuplicateKey, nil).With("key", s.Key)
+		}
+		seen[s.Key] = true
+		switch s.Category {
+		case StatusCategoryTodo:
+			hasTodo = true
+		case StatusCategoryDone:
+			hasDone = true
+		case StatusCategoryInProgress:
+		default:
+			return apperrors.NewBadRequestError(apperrors.CodeWorkflowInvalidCategory, nil).With("key", s.Key)
+		}
+		if s.WIPLimit != nil && *s.WIPLimit < 1 {
+			return apperrors.NewBadRequestError(apperrors.CodeWorkflowInvalidWIPLimit, nil).With("key", s.Key)
+		}
+	}
+	if !hasTodo || !hasDone {
+		return apperrors.NewBadRequestError(apperrors.CodeWorkflowMissingCategory, nil)
+	}
+	for _, t := range w.Transitions {
+		if !seen[t.From] || !seen[t.To] {
+			return apperrors.NewBadRequestError(apperrors.CodeWorkflowUnknownTransition, nil).With("from", t.From).With("to", t.To)
+		}
+	}
+	return nil
+}
+
+// Status 按 key 查找状态
+func (w Workflow) Status(key string) (WorkflowStatus, bool) {
+	for _, s := range w.Statuses {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return WorkflowStatus{}, false
+}
+
+// CanTransition 判断是否允许从 from 转换到 to
+func (w Workflow) CanTransition(from, to string) bool {
+	if from == to || len(w.Transitions) == 0 {
+		return true
+	}
+	for _, t := range w.Transitions {
+		if t.From == from && t.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusFor 返回与 done 对应的第一个状态：done 为 true 时是第一个完成类状态，否则是第一个待办类状态
+func (w Workflow) StatusFor(done bool) string {
+	category := StatusCategoryTodo
+	if done {
+		category = StatusCategoryDone
+	}
+	for _, s := range w.Statuses {
+		if s.Category == category {
+			return s.Key
+		}
+	}
+	return ""
+}
+
+// Value 以 JSON 保存到数据库
+func (w Workflow) Value() (driver.Value, error) {
+	return json.Marshal(w)
+}
+
+// Scan 从数据库的 JSON 列读取
+func (w *Workflow) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, w)
+	case string:
+		return json.Unmarshal([]byte(v), w)
+	}
+	return fmt.Errorf("models: cannot scan %T into Workflow", src)
+}
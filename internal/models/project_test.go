@@ -0,0 +1,49 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWorkflow 测试工作流的校验、状态转换和 done 对应的状态
+func TestWorkflow(t *testing.T) {
+	limit := 2
+	workflow := Workflow{
+		Statuses: []WorkflowStatus{
+			{Key: "backlog", Name: "Backlog", Category: StatusCategoryTodo},
+			{Key: "doing", Name: "Doing", Category: StatusCategoryInProgress, WIPLimit: &limit},
+			{Key: "review", Name: "In review", Category: StatusCategoryInProgress},
+			{Key: "shipped", Name: "Shipped", Category: StatusCategoryDone},
+		},
+		Transitions: []StatusTransition{
+			{From: "backlog", To: "doing"},
+			{From: "doing", To: "review"},
+			{From: "review", To: "shipped"},
+		},
+	}
+	assert.NoError(t, workflow.Validate())
+	assert.NoError(t, DefaultWorkflow().Validate())
+
+	assert.True(t, workflow.CanTransition("backlog", "doing"))
+	assert.True(t, workflow.CanTransition("review", "review"))
+	assert.False(t, workflow.CanTransition("backlog", "shipped"))
+	assert.True(t, DefaultWorkflow().CanTransition("todo", "done"))
+
+	assert.Equal(t, "shipped", workflow.StatusFor(true))
+	assert.Equal(t, "backlog", workflow.StatusFor(false))
+
+	invalid := []Workflow{
+		{Statuses: []WorkflowStatus{{Key: "todo", Category: StatusCategoryTodo}}},
+		{Statuses: []WorkflowStatus{{Key: "Todo", Category: StatusCategoryTodo}, {Key: "done", Category: StatusCategoryDone}}},
+		{Statuses: []WorkflowStatus{{Key: "todo", Category: StatusCategoryTodo}, {Key: "todo", Category: StatusCategoryDone}}},
+		{Statuses: []WorkflowStatus{{Key: "todo", Category: "blocked"}, {Key: "done", Category: StatusCategoryDone}}},
+		{
+			Statuses:    DefaultWorkflow().Statuses,
+			Transitions: []StatusTransition{{From: "todo", To: "archived"}},
+		},
+	}
+	for i, w := range invalid {
+		assert.Error(t, w.Validate(), "case %d", i)
+	}
+}
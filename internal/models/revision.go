@@ -0,0 +1,112 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// TaskRevision 任务的一个历史版本，保存任务所有可修改字段的快照
+type TaskRevision struct {
+	ID            int        `json:"id" db:"id"`
+	TaskID        int        `json:"task_id" db:"task_id"`
+	Revision      int        `json:"revision" db:"revision"`
+	UserID        int        `json:"user_id" db:"user_id"`
+	Title         string     `json:"title" db:"title"`
+	Content       string     `json:"content" db:"content"`
+	Done          bool       `json:"done" db:"done"`
+	DueAt         *time.Time `json:"due_at" db:"due_at"`
+	Priority      int        `json:"priority" db:"priority"`
+	Tags          []string   `json:"tags" db:"-"`
+	ProjectID     *int       `json:"project_id" db:"project_id"`
+	Status        string     `json:"status" db:"status"`
+	ChangedFields []string   `json:"changed_fields" db:"-"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// RevisionOf 返回任务当前字段的快照
+func RevisionOf(task *Task) *TaskRevision {
+	return &TaskRevision{
+		TaskID:    task.ID,
+		UserID:    task.UserID,
+		Title:     task.Title,
+		Content:   task.Content,
+		Done:      task.Done,
+		DueAt:     task.DueAt,
+		Priority:  task.Priority,
+		Tags:      []string(task.Tags),
+		ProjectID: task.ProjectID,
+		Status:    task.Status,
+	}
+}
+
+// ApplyTo 把快照中的字段写回任务
+func (r *TaskRevision) ApplyTo(task *Task) {
+	task.Title = r.Title
+	task.Content = r.Content
+	task.Done = r.Done
+	task.DueAt = r.DueAt
+	task.Priority = r.Priority
+	task.Tags = slices.Clone(r.Tags)
+	task.ProjectID = r.ProjectID
+	task.Status = r.Status
+}
+
+// FieldChange 两个版本之间某个字段的差异
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// Diff 返回从 r 到 other 的字段级差异
+func (r *TaskRevision) Diff(other *TaskRevision) []FieldChange {
+	changes := []FieldChange{}
+	if r.Title != other.Title {
+		changes = append(changes, FieldChange{Field: "title", From: r.Title, To: other.Title})
+	}
+	if r.Content != other.Content {
+		changes = append(changes, FieldChange{Field: "content", From: r.Content, To: other.Content})
+	}
+	if r.Done != other.Done {
+		changes = append(changes, FieldChange{Field: "done", From: r.Done, To: other.Done})
+	}
+	if !sameTime(r.DueAt, other.DueAt) {
+		changes = append(changes, FieldChange{Field: "due_at", From: r.DueAt, To: other.DueAt})
+	}
+	if r.Priority != other.Priority {
+		changes = append(changes, FieldChange{Field: "priority", From: r.Priority, To: other.Priority})
+	}
+	if !slices.Equal(r.Tags, other.Tags) {
+		changes = append(changes, FieldChange{Field: "tags", From: r.Tags, To: other.Tags})
+	}
+	if !sameInt(r.ProjectID, other.ProjectID) {
+		changes = append(changes, FieldChange{Field: "project_id", From: r.ProjectID, To: other.ProjectID})
+	}
+	if r.Status != other.Status {
+		changes = append(changes, FieldChange{Field: "status", From: r.Status, To: other.Status})
+	}
+	return changes
+}
+
+// ChangedFields 返回从 old 到 updated 发生变化的可修改字段名，顺序与 Diff 相同
+func ChangedFields(old, updated *Task) []string {
+	fields := []string{}
+	for _, change := range RevisionOf(old).Diff(RevisionOf(updated)) {
+		fields = append(fields, change.Field)
+	}
+	return fields
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func sameInt(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
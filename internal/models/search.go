@@ -0,0 +1,10 @@
+package models
+
+// SearchResult 全文搜索的一条结果，高亮片段是转义后的 HTML，匹配的词用 <mark></mark> 包裹
+type SearchResult struct {
+	Task           Task    `json:"task"`
+	Rank           float64 `json:"rank"`
+	TitleSnippet   string  `json:"title_snippet"`
+	ContentSnippet string  `json:"content_snippet"`
+	CommentSnippet *string `json:"comment_snippet,omitempty"`
+}
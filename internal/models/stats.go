@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// 统计的时间粒度
+const (
+	StatsByDay  = "day"
+	StatsByWeek = "week"
+)
+
+// StatsBucket 一天或一周内创建和完成的任务数，Date 为这一天，或按用户资料中每周第一天计算的这一周的第一天
+type StatsBucket struct {
+	Date      string `json:"date"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+}
+
+// Stats GET /stats 返回的统计数据
+type Stats struct {
+	From     time.Time     `json:"from"`
+	To       time.Time     `json:"to"`
+	Timezone string        `json:"timezone"`
+	Interval string        `json:"interval"`
+	Series   []StatsBucket `json:"series"`
+
+	Created   int `json:"created"`   //范围内创建的任务数
+	Completed int `json:"completed"` //范围内完成的任务数
+	// CompletionRate 范围内创建的任务中已完成的比例，没有创建任务时为0
+	CompletionRate float64 `json:"completion_rate"`
+	// AvgTimeToCompleteSeconds 范围内完成的任务从创建到完成的平均秒数，没有完成任务时为空
+	AvgTimeToCompleteSeconds *float64 `json:"avg_time_to_complete_seconds"`
+
+	CurrentStreak int `json:"current_streak"` //截至今天（或昨天）连续有任务完成的天数
+	LongestStreak int `json:"longest_streak"`
+
+	Overdue       int `json:"overdue"`        //当前已过截止时间仍未完成的任务数
+	CompletedLate int `json:"completed_late"` //范围内完成、但完成时已过截止时间的任务数
+}
@@ -2,14 +2,37 @@ package models
 
 import (
 	"time"
+
+	"github.com/lib/pq"
+)
+
+// 任务优先级
+const (
+	PriorityNone   = 0
+	PriorityLow    = 1
+	PriorityMedium = 2
+	PriorityHigh   = 3
+	PriorityUrgent = 4
 )
 
 type Task struct {
-	ID        int       `json:"id" db:"id"`
-	Title     string    `json:"title" db:"title"`
-	Content   string    `json:"content" db:"content"`
-	Done      bool      `json:"done" db:"done"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
-	UserID    int       `json:"user_id" db:"user_id"` 
-}
\ No newline at end of file
+	ID          int            `json:"id" db:"id"`
+	Title       string         `json:"title" db:"title"`
+	Content     string         `json:"content" db:"content"`
+	Done        bool           `json:"done" db:"done"`
+	ProjectID   *int           `json:"project_id" db:"project_id"`
+	Status      string         `json:"status" db:"status"` //工作流中的状态，为空时按 done 取默认状态
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
+	UserID      int            `json:"user_id" db:"user_id"`
+	Version     int            `json:"version" db:"version"`
+	DueAt       *time.Time     `json:"due_at" db:"due_at"`
+	Priority    int            `json:"priority" db:"priority"`
+	Tags        pq.StringArray `json:"tags" db:"tags"`
+	CompletedAt *time.Time     `json:"completed_at" db:"completed_at"`
+	Position    *string        `json:"position" db:"position"`
+	IsBlocked   bool           `json:"is_blocked" db:"is_blocked"` //存在未完成的前置任务
+	Unblocked   []int          `json:"unblocked,omitempty" db:"-"` //本次完成后不再被阻塞的任务
+	Rebalanced  []int          `json:"-" db:"-"`                   //移动时被重新分配排序键的任务
+	DeletedAt   *time.Time     `json:"deleted_at,omitempty" db:"deleted_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// TimeEntry 一段花在任务上的时间，StoppedAt 为空表示计时器仍在运行
+type TimeEntry struct {
+	ID              int        `json:"id" db:"id"`
+	TaskID          int        `json:"task_id" db:"task_id"`
+	UserID          int        `json:"user_id" db:"user_id"`
+	StartedAt       time.Time  `json:"started_at" db:"started_at"`
+	StoppedAt       *time.Time `json:"stopped_at" db:"stopped_at"`
+	DurationSeconds int64      `json:"duration_seconds" db:"duration_seconds"` //运行中的计时器计算到当前时间
+	Note            string     `json:"note" db:"note"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+}
+
+// 时间报表的分组方式
+const (
+	ReportByDay     = "day"
+	ReportByProject = "project"
+	ReportByTag     = "tag"
+)
+
+// TimeReportRow 时间报表中的一行
+type TimeReportRow struct {
+	Key     string `json:"key" db:"key"`
+	Label   string `json:"label" db:"label"`
+	Seconds int64  `json:"seconds" db:"seconds"`
+	Entries int    `json:"entries" db:"entries"`
+}
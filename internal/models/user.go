@@ -1,10 +1,46 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 type User struct {
 	ID           int       `json:"id" db:"id"`
 	Username     string    `json:"username" db:"username"`
-	PasswordHash string    `json:"-" db:"password_hash"` 
+	PasswordHash string    `json:"-" db:"password_hash"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-}
\ No newline at end of file
+
+	DisplayName string    `json:"display_name" db:"display_name"`
+	Timezone    string    `json:"timezone" db:"timezone"` //IANA 时区，例如 Asia/Shanghai
+	Locale      string    `json:"locale" db:"locale"`
+	WeekStart   int       `json:"week_start" db:"week_start"` //每周的第一天，0为周日
+	DefaultSort string    `json:"default_sort" db:"default_sort"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// LoadTimezone 解析 IANA 时区名，时区名会传给 Postgres 的 AT TIME ZONE
+// time.LoadLocation 把 "" 当作 UTC、把 "Local" 当作服务器的时区，Postgres 都不接受，这里当作无效的时区
+func LoadTimezone(name string) (*time.Location, error) {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" || name == "Local" || loc.String() != name {
+		return nil, fmt.Errorf("models: %q is not an IANA time zone name", name)
+	}
+	return loc, nil
+}
+
+// Location 返回用户的时区，无法识别时使用 UTC
+func (u *User) Location() *time.Location {
+	if loc, err := LoadTimezone(u.Timezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// Now 返回用户时区中的当前时间，“今天”“本周”等相对时间都以它为基准
+func (u *User) Now() time.Time {
+	return time.Now().In(u.Location())
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLoadTimezone 只接受 Postgres 也认识的 IANA 时区名
+func TestLoadTimezone(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"UTC", true},
+		{"Asia/Shanghai", true},
+		{"America/New_York", true},
+		{"", false},
+		{"Local", false},
+		{"Mars/Olympus", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := LoadTimezone(tt.name)
+			if !tt.ok {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.name, loc.String())
+		})
+	}
+
+	user := &User{Timezone: "Local"}
+	assert.Equal(t, "UTC", user.Location().String(), "已经保存的无效时区按 UTC 计算")
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// SavedView 保存的搜索（智能列表）
+// 内置视图没有 ID，用 Key 标识，例如 today、overdue
+type SavedView struct {
+	ID        int       `json:"id,omitempty" db:"id"`
+	Key       string    `json:"key,omitempty" db:"-"`
+	UserID    int       `json:"user_id,omitempty" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	Filter    string    `json:"filter" db:"filter"`
+	Sort      string    `json:"sort" db:"sort"`
+	Pinned    bool      `json:"pinned" db:"pinned"`
+	Builtin   bool      `json:"builtin" db:"-"`
+	CreatedAt time.Time `json:"created_at,omitempty" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
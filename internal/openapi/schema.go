@@ -0,0 +1,219 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// wrapper 由自定义 JSON 编码的包装类型实现（例如 models.Null），schema 使用 ElemType 返回的类型
+type wrapper interface {
+	ElemType() reflect.Type
+}
+
+// Generator 通过反射从 Go 类型生成 schema
+// 具名的结构体放到 Schemas 中并以 $ref 引用，字段名取自 json tag，校验规则取自 binding tag
+type Generator struct {
+	Schemas map[string]*Schema
+	types   map[string]reflect.Type
+}
+
+func NewGenerator() *Generator {
+	return &Generator{Schemas: map[string]*Schema{}, types: map[string]reflect.Type{}}
+}
+
+// Schema 返回 v 的类型对应的 schema
+func (g *Generator) Schema(v any) *Schema {
+	return g.fieldSchema(reflect.TypeOf(v), nil)
+}
+
+// fieldSchema 生成类型的 schema，指针类型可以为 null；rules 为 binding tag 中的规则，dive 之后的规则作用于元素
+func (g *Generator) fieldSchema(t reflect.Type, rules []string) *Schema {
+	if w, ok := reflect.Zero(t).Interface().(wrapper); ok {
+		t = w.ElemType()
+	}
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+	s := g.typeSchema(t)
+	if s.Ref == "" {
+		own, items, dive := rules, []string(nil), false
+		for i, rule := range rules {
+			if rule == "dive" {
+				own, items, dive = rules[:i], rules[i+1:], true
+				break
+			}
+		}
+		applyRules(s, own)
+		if dive && s.Items != nil && s.Items.Ref == "" {
+			applyRules(s.Items, items)
+		}
+	}
+	if !nullable {
+		return s
+	}
+	if s.Ref != "" {
+		return &Schema{OneOf: []*Schema{s, {Type: "null"}}}
+	}
+	if typ, ok := s.Type.(string); ok {
+		s.Type = []string{typ, "null"}
+	}
+	return s
+}
+
+// typeSchema 每次都返回新的 schema，调用方可以直接修改
+func (g *Generator) typeSchema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return Primitive("string", "date-time")
+	case rawMessageType:
+		return &Schema{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return Primitive("boolean", "")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return Primitive("integer", "int32")
+	case reflect.Int64, reflect.Uint64:
+		return Primitive("integer", "int64")
+	case reflect.Float32, reflect.Float64:
+		return Primitive("number", "double")
+	case reflect.String:
+		return Primitive("string", "")
+	case reflect.Slice, reflect.Array:
+		return ArrayOf(g.fieldSchema(t.Elem(), nil))
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.fieldSchema(t.Elem(), nil)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return Ref(g.register(t))
+	}
+	//interface{} 等可以是任意值
+	return &Schema{}
+}
+
+// register 把具名结构体放到 Schemas 中，不同包中的同名类型加上包名区分
+func (g *Generator) register(t reflect.Type) string {
+	name := t.Name()
+	if other, ok := g.types[name]; ok && other != t {
+		pkg := path.Base(t.PkgPath())
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	if _, ok := g.types[name]; ok {
+		return name
+	}
+	g.types[name] = t
+	g.Schemas[name] = &Schema{} //先占位，允许类型引用自身
+	*g.Schemas[name] = *g.structSchema(t)
+	return name
+}
+
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.addFields(s, t, hasBinding(t))
+	return s
+}
+
+// addFields 添加结构体的字段，嵌入的结构体展开到同一层，与 encoding/json 一致
+// 带 binding tag 的结构体是请求，只有 required 规则的字段是必需的；否则是响应，没有 omitempty 的字段都会输出
+func (g *Generator) addFields(s *Schema, t reflect.Type, request bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			g.addFields(s, f.Type, request)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		var rules []string
+		if binding := f.Tag.Get("binding"); binding != "" {
+			rules = strings.Split(binding, ",")
+		}
+		s.Properties[name] = g.fieldSchema(f.Type, rules)
+		omitempty := strings.Contains(opts, "omitempty")
+		if (request && len(rules) > 0 && rules[0] == "required") || (!request && !omitempty) {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func hasBinding(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Tag.Get("binding") != "" {
+			return true
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && hasBinding(f.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyRules 把 validator 的规则转换为 JSON Schema 的约束，不认识的规则忽略
+func applyRules(s *Schema, rules []string) {
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "min", "max":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			setBound(s, name == "min", n)
+		case "notblank":
+			if s.Type == "string" && s.MinLength == nil {
+				setBound(s, true, 1)
+			}
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				s.Enum = append(s.Enum, v)
+			}
+		}
+	}
+}
+
+func setBound(s *Schema, min bool, n int) {
+	switch s.Type {
+	case "string":
+		if min {
+			s.MinLength = &n
+		} else {
+			s.MaxLength = &n
+		}
+	case "array":
+		if min {
+			s.MinItems = &n
+		} else {
+			s.MaxItems = &n
+		}
+	case "integer", "number":
+		f := float64(n)
+		if min {
+			s.Minimum = &f
+		} else {
+			s.Maximum = &f
+		}
+	}
+}
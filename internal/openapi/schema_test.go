@@ -0,0 +1,97 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testBase struct {
+	ID int `json:"id"`
+}
+
+type testResponse struct {
+	testBase
+	Title   string     `json:"title"`
+	DueAt   *time.Time `json:"due_at"`
+	Note    string     `json:"note,omitempty"`
+	Child   *testChild `json:"child"`
+	Secret  string     `json:"-"`
+	Counter map[string]int
+}
+
+type testChild struct {
+	Name string `json:"name"`
+}
+
+// testNullTime 与 models.Null[time.Time] 一样由 ElemType 决定 schema
+type testNullTime struct{ value *time.Time }
+
+func (testNullTime) ElemType() reflect.Type { return reflect.TypeOf((*time.Time)(nil)) }
+
+type testRequest struct {
+	Title string       `json:"title" binding:"required,notblank,max=200"`
+	Tags  []string     `json:"tags" binding:"max=20,dive,max=50"`
+	Level *int         `json:"level" binding:"omitempty,min=0,max=4"`
+	Op    string       `json:"op" binding:"required,oneof=create delete"`
+	Due   testNullTime `json:"due"`
+}
+
+func TestGenerator_Response(t *testing.T) {
+	g := NewGenerator()
+	assert.Equal(t, "#/components/schemas/testResponse", g.Schema(testResponse{}).Ref)
+
+	s := g.Schemas["testResponse"]
+	require.NotNil(t, s)
+	//嵌入的结构体展开，json:"-" 的字段跳过
+	assert.Contains(t, s.Properties, "id")
+	assert.NotContains(t, s.Properties, "Secret")
+	assert.Contains(t, s.Properties, "Counter")
+	assert.Equal(t, []string{"id", "title", "due_at", "child", "Counter"}, s.Required)
+
+	assert.Equal(t, []string{"string", "null"}, s.Properties["due_at"].Type)
+	assert.Equal(t, "date-time", s.Properties["due_at"].Format)
+	assert.Len(t, s.Properties["child"].OneOf, 2)
+	assert.Contains(t, g.Schemas, "testChild")
+	assert.Equal(t, "integer", s.Properties["Counter"].AdditionalProperties.Type)
+}
+
+func TestGenerator_Request(t *testing.T) {
+	g := NewGenerator()
+	g.Schema(&testRequest{})
+	s := g.Schemas["testRequest"]
+	require.NotNil(t, s)
+	assert.Equal(t, []string{"title", "op"}, s.Required)
+
+	title := s.Properties["title"]
+	assert.Equal(t, 1, *title.MinLength)
+	assert.Equal(t, 200, *title.MaxLength)
+	tags := s.Properties["tags"]
+	assert.Equal(t, 20, *tags.MaxItems)
+	assert.Equal(t, 50, *tags.Items.MaxLength)
+	level := s.Properties["level"]
+	assert.Equal(t, []string{"integer", "null"}, level.Type)
+	assert.Equal(t, 4.0, *level.Maximum)
+	assert.Equal(t, []any{"create", "delete"}, s.Properties["op"].Enum)
+	assert.Equal(t, []string{"string", "null"}, s.Properties["due"].Type)
+	assert.Equal(t, "date-time", s.Properties["due"].Format)
+}
+
+func TestUIHandler(t *testing.T) {
+	h := UIHandler("/docs", "/openapi.json")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/docs/", w.Header().Get("Location"))
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `url: "/openapi.json"`)
+}
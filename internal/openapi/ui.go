@@ -0,0 +1,46 @@
+package openapi
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// ui 中是 swagger-ui-dist 的 swagger-ui-bundle.js 和 swagger-ui.css（版本和许可证见 ui/NOTICE），
+// 构建时不访问网络
+//
+//go:embed ui
+var uiFiles embed.FS
+
+var indexTemplate = template.Must(template.ParseFS(uiFiles, "ui/index.html"))
+
+// UIHandler 返回 Swagger UI，prefix 为挂载的路径（例如 /docs），specURL 为 OpenAPI 文档的地址
+func UIHandler(prefix, specURL string) http.Handler {
+	static, _ := fs.Sub(uiFiles, "ui")
+	files := http.StripPrefix(prefix, http.FileServer(http.FS(static)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, prefix)
+		if name == "" {
+			//页面中的资源使用相对路径
+			http.Redirect(w, r, prefix+"/", http.StatusMovedPermanently)
+			return
+		}
+		if name == "/" || name == "/index.html" {
+			//先渲染到缓冲区，出错时不会返回半个页面
+			var buf bytes.Buffer
+			if err := indexTemplate.Execute(&buf, struct{ SpecURL string }{specURL}); err != nil {
+				log.Printf("openapi: render index.html: %v", err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			buf.WriteTo(w)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
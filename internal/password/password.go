@@ -0,0 +1,127 @@
+// Package password 检查注册时的密码强度
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/config"
+)
+
+const (
+	defaultMinLength = 8
+	// bcrypt 只使用前72个字节，更长的密码会被拒绝
+	maxBytes = 72
+)
+
+//go:embed breached.txt
+var defaultBreached string
+
+// Policy 密码强度策略
+type Policy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	breached      map[string]struct{}
+}
+
+// NewPolicy 按配置创建策略，除内置的泄露密码列表外还会加载 cfg.BreachedListFile
+func NewPolicy(cfg config.PasswordConfig) (*Policy, error) {
+	p := &Policy{
+		MinLength:     cfg.MinLength,
+		RequireUpper:  cfg.RequireUpper,
+		RequireLower:  cfg.RequireLower,
+		RequireDigit:  cfg.RequireDigit,
+		RequireSymbol: cfg.RequireSymbol,
+		breached:      map[string]struct{}{},
+	}
+	if p.MinLength <= 0 {
+		p.MinLength = defaultMinLength
+	}
+	if err := p.loadBreached(strings.NewReader(defaultBreached)); err != nil {
+		return nil, err
+	}
+	if cfg.BreachedListFile != "" {
+		f, err := os.Open(cfg.BreachedListFile)
+		if err != nil {
+			return nil, fmt.Errorf("password: failed to open breached list: %w", err)
+		}
+		defer f.Close()
+		if err := p.loadBreached(f); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// loadBreached 读取每行一个密码的列表，忽略空行和 # 开头的注释
+func (p *Policy) loadBreached(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.breached[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("password: failed to read breached list: %w", err)
+	}
+	return nil
+}
+
+// Check 返回密码不满足的所有规则，全部满足时返回 nil
+func (p *Policy) Check(password string, username string) apperrors.FieldViolations {
+	var violations apperrors.FieldViolations
+	add := func(rule string, param string) {
+		violations = append(violations, apperrors.FieldViolation{Field: "password", Rule: rule, Param: param})
+	}
+	if len([]rune(password)) < p.MinLength {
+		add("min_length", strconv.Itoa(p.MinLength))
+	}
+	if len(password) > maxBytes {
+		add("max_bytes", strconv.Itoa(maxBytes))
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		add("uppercase", "")
+	}
+	if p.RequireLower && !lower {
+		add("lowercase", "")
+	}
+	if p.RequireDigit && !digit {
+		add("digit", "")
+	}
+	if p.RequireSymbol && !symbol {
+		add("symbol", "")
+	}
+	lowered := strings.ToLower(password)
+	if username != "" && strings.Contains(lowered, strings.ToLower(username)) {
+		add("contains_username", "")
+	}
+	if _, ok := p.breached[lowered]; ok {
+		add("breached", "")
+	}
+	return violations
+}
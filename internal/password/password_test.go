@@ -0,0 +1,36 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func rules(v apperrors.FieldViolations) []string {
+	result := []string{}
+	for _, violation := range v {
+		result = append(result, violation.Rule)
+	}
+	return result
+}
+
+func TestCheck(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "breached.txt")
+	assert.NoError(t, os.WriteFile(file, []byte("# extra\nCorrectHorse9\n"), 0o600))
+	policy, err := NewPolicy(config.PasswordConfig{MinLength: 10, RequireDigit: true, BreachedListFile: file})
+	assert.NoError(t, err)
+
+	assert.Empty(t, policy.Check("violet-harbor-42", "alice"))
+	assert.Equal(t, []string{"min_length", "digit"}, rules(policy.Check("short", "alice")))
+	// 内置列表和配置的列表都不区分大小写
+	assert.Equal(t, []string{"breached"}, rules(policy.Check("Password123", "alice")))
+	assert.Equal(t, []string{"breached"}, rules(policy.Check("correcthorse9", "alice")))
+	assert.Equal(t, []string{"contains_username"}, rules(policy.Check("xx-Alice-2024", "alice")))
+
+	policy.RequireUpper, policy.RequireSymbol = true, true
+	assert.Equal(t, []string{"uppercase", "symbol"}, rules(policy.Check("violetharbor42", "alice")))
+}
@@ -0,0 +1,122 @@
+// Package rank 实现用于手动排序的分数索引（fractional indexing）
+//
+// 排序键是 base62 字符串，按字节序比较，可以看作 (0, 1) 区间内的小数。
+// 在两个键之间插入只需要生成一个新键，不需要修改其它行。
+// 键不能以最小的数字 '0' 结尾，否则就无法在它前面插入。
+package rank
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// Digits 排序键使用的数字，按 ASCII 顺序排列
+const Digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var ErrInvalidKey = errors.New("rank: invalid key")
+
+// Between 返回一个严格位于 a 和 b 之间的键
+// a 为空表示没有下界（插入到最前面），b 为空表示没有上界（插入到最后面）
+func Between(a, b string) (string, error) {
+	if !valid(a) || !valid(b) {
+		return "", ErrInvalidKey
+	}
+	if a != "" && b != "" && a >= b {
+		return "", ErrInvalidKey
+	}
+	return midpoint(a, b, b != ""), nil
+}
+
+// midpoint 计算 a 和 b 之间的键，hasB 为 false 时 b 视为无穷大
+func midpoint(a, b string, hasB bool) string {
+	if hasB {
+		// 跳过公共前缀（a 较短时用 '0' 补齐）
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + midpoint(tail(a, n), b[n:], true)
+		}
+	}
+	digitA := 0
+	if a != "" {
+		digitA = strings.IndexByte(Digits, a[0])
+	}
+	digitB := len(Digits)
+	if hasB {
+		digitB = strings.IndexByte(Digits, b[0])
+	}
+	if digitB-digitA > 1 {
+		return string(Digits[(digitA+digitB+1)/2])
+	}
+	// 首位数字相邻：b 更长时取 b 的首位即可，否则保留 a 的首位继续向后找
+	if hasB && len(b) > 1 {
+		return b[:1]
+	}
+	return string(Digits[digitA]) + midpoint(tail(a, 1), "", false)
+}
+
+// Spread 生成 n 个均匀分布、长度尽可能短的递增键，用于重新平衡
+func Spread(n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	base := big.NewInt(int64(len(Digits)))
+	// 选择足够的位数，使相邻键之间至少间隔一个空位
+	length := 1
+	space := new(big.Int).Set(base)
+	for space.Cmp(big.NewInt(int64(2*(n+1)))) < 0 {
+		space.Mul(space, base)
+		length++
+	}
+	keys := make([]string, n)
+	step := new(big.Int).Div(space, big.NewInt(int64(n+1)))
+	for i := range keys {
+		v := new(big.Int).Mul(step, big.NewInt(int64(i+1)))
+		keys[i] = strings.TrimRight(encode(v, length, base), "0")
+	}
+	return keys
+}
+
+// encode 把 v 编码为固定长度的 base62 字符串
+func encode(v *big.Int, length int, base *big.Int) string {
+	buf := make([]byte, length)
+	v = new(big.Int).Set(v)
+	mod := new(big.Int)
+	for i := length - 1; i >= 0; i-- {
+		v.DivMod(v, base, mod)
+		buf[i] = Digits[mod.Int64()]
+	}
+	return string(buf)
+}
+
+func valid(key string) bool {
+	if key == "" {
+		return true
+	}
+	if key[len(key)-1] == Digits[0] {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(Digits, key[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func digitAt(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+	return Digits[0]
+}
+
+func tail(s string, n int) string {
+	if n >= len(s) {
+		return ""
+	}
+	return s[n:]
+}
@@ -0,0 +1,76 @@
+package rank
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBetween 测试生成的键严格位于两端之间
+func TestBetween(t *testing.T) {
+	cases := []struct{ a, b string }{
+		{"", ""},
+		{"", "V"},
+		{"V", ""},
+		{"a", "b"},
+		{"a", "a1"},
+		{"az", "b"},
+		{"z", ""},
+		{"", "01"},
+		{"0001", "0002"},
+	}
+	for _, tc := range cases {
+		key, err := Between(tc.a, tc.b)
+		assert.NoError(t, err)
+		if tc.a != "" {
+			assert.Less(t, tc.a, key, "%q %q", tc.a, tc.b)
+		}
+		if tc.b != "" {
+			assert.Less(t, key, tc.b, "%q %q", tc.a, tc.b)
+		}
+		assert.NotEqual(t, byte('0'), key[len(key)-1])
+	}
+
+	_, err := Between("b", "a")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = Between("a0", "")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+// TestBetween_RandomInserts 随机插入后顺序保持一致
+func TestBetween_RandomInserts(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	keys := []string{}
+	for i := 0; i < 1000; i++ {
+		pos := r.Intn(len(keys) + 1)
+		a, b := "", ""
+		if pos > 0 {
+			a = keys[pos-1]
+		}
+		if pos < len(keys) {
+			b = keys[pos]
+		}
+		key, err := Between(a, b)
+		assert.NoError(t, err)
+		keys = append(keys[:pos], append([]string{key}, keys[pos:]...)...)
+	}
+	assert.True(t, sort.StringsAreSorted(keys))
+}
+
+// TestSpread 测试重新平衡生成的键递增且较短
+func TestSpread(t *testing.T) {
+	for _, n := range []int{1, 2, 30, 61, 5000} {
+		keys := Spread(n)
+		assert.Len(t, keys, n)
+		assert.True(t, sort.StringsAreSorted(keys))
+		for i, k := range keys {
+			assert.True(t, valid(k), k)
+			if i > 0 {
+				assert.NotEqual(t, keys[i-1], k)
+			}
+		}
+		assert.LessOrEqual(t, len(keys[n-1]), 4)
+	}
+}
@@ -0,0 +1,339 @@
+package router
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/HywlEch/Todo_list/internal/handlers"
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/openapi"
+)
+
+// operation OpenAPI 文档中一个路由的说明
+// request 和 response 是 Go 类型的零值、*openapi.Schema、paged 或 fields，由 resolve 转换为 schema
+type operation struct {
+	id       string
+	tag      string
+	summary  string
+	public   bool                 //不需要认证
+	params   []*openapi.Parameter //查询参数和请求头，路径参数从路径中生成
+	request  any                  //application/json 的请求体
+	bodies   map[string]any       //其他媒体类型的请求体
+	status   int                  //成功时的状态码，默认为200
+	response any                  //为空表示没有响应体
+	headers  map[string]*openapi.Header
+	errors   []int //可能返回的错误，其余错误归入 default
+}
+
+// paged 分页响应，items 中元素的类型为 item
+type paged struct{ item any }
+
+// fields 由字段组成的对象，对应 handler 中用 gin.H 返回的响应
+type fields map[string]any
+
+var (
+	integer  = openapi.Primitive("integer", "int32")
+	str      = openapi.Primitive("string", "")
+	dateTime = openapi.Primitive("string", "date-time")
+	etag     = map[string]*openapi.Header{"ETag": {Description: "任务的版本号", Schema: str}}
+)
+
+func query(name, description string, schema *openapi.Schema) *openapi.Parameter {
+	return &openapi.Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func header(name, description string) *openapi.Parameter {
+	return &openapi.Parameter{Name: name, In: "header", Description: description, Schema: str}
+}
+
+func enum(values ...string) *openapi.Schema {
+	s := openapi.Primitive("string", "")
+	for _, v := range values {
+		s.Enum = append(s.Enum, v)
+	}
+	return s
+}
+
+var (
+	pageParams = []*openapi.Parameter{
+		query("page", "页码，从1开始", integer),
+		query("page_size", "每页条数", integer),
+	}
+	rangeParams = []*openapi.Parameter{
+		query("from", "开始时间（包含），RFC 3339 或 YYYY-MM-DD", str),
+		query("to", "结束时间（不包含），RFC 3339 或 YYYY-MM-DD", str),
+		query("tz", "IANA 时区，默认使用用户资料中的时区", str),
+	}
+	ifMatch     = header("If-Match", "期望的版本号（ETag），可以用逗号分隔多个，都不匹配时返回412；为空或 * 表示不检查")
+	ifNoneMatch = header("If-None-Match", "与当前 ETag 相同时返回304")
+)
+
+// idempotentPrefixes 使用 IdempotencyMiddleware 的分组，与 registerV1 保持一致
+var idempotentPrefixes = []string{"/tasks", "/trash", "/views", "/timer", "/projects"}
+
+// v1Operations v1 中每个路由的说明，key 为 "METHOD 路径"，路径与注册到 gin 的相同
+// 新增路由时需要在这里补充说明，否则 TestOpenAPICoversRoutes 会失败
+var v1Operations = map[string]operation{
+	//认证
+	"POST /auth/regist": {id: "register", tag: "auth", summary: "注册", public: true,
+		request: handlers.RegisterRequest{}, status: http.StatusCreated,
+		response: fields{"message": "", "userid": 0}, errors: []int{400, 409, 413}},
+	"POST /auth/login": {id: "login", tag: "auth", summary: "登录，返回 JWT", public: true,
+		request: handlers.LoginRequest{}, response: handlers.LoginResponse{}, errors: []int{400, 401, 413}},
+
+	//用户资料
+	"GET /me":   {id: "getMe", tag: "me", summary: "当前用户的资料", response: handlers.UserResponse{}},
+	"PATCH /me": {id: "updateMe", tag: "me", summary: "修改时区、语言、每周第一天等设置", request: handlers.ProfileRequest{}, response: handlers.UserResponse{}, errors: []int{400, 413}},
+
+	//任务
+	"POST /tasks": {id: "createTask", tag: "tasks", summary: "创建任务", request: handlers.TaskRequest{},
+		status: http.StatusCreated, response: handlers.TaskResponse{}, errors: []int{400, 409, 413, 422}},
+	"GET /tasks": {id: "listTasks", tag: "tasks", summary: "任务列表",
+		params: []*openapi.Parameter{
+			query("filter", "过滤表达式，例如 done:false AND due<today+7d", str),
+			query("sort", "排序方式，默认使用用户资料中的 default_sort", str),
+		},
+		response: []handlers.TaskResponse{}, errors: []int{400}},
+	"POST /tasks/bulk": {id: "bulkTasks", tag: "tasks", summary: "批量创建、修改、删除、完成或移动任务",
+		request: handlers.BulkRequest{}, response: fields{"atomic": false, "results": []handlers.BulkResultResponse{}}, errors: []int{400, 409, 412, 413}},
+	"GET /tasks/search": {id: "searchTasks", tag: "tasks", summary: "全文搜索标题、内容和评论",
+		params:   append([]*openapi.Parameter{query("q", "搜索词", str)}, pageParams...),
+		response: paged{handlers.SearchResultResponse{}}, errors: []int{400}},
+	"GET /tasks/:id": {id: "getTask", tag: "tasks", summary: "获取任务", params: []*openapi.Parameter{ifNoneMatch},
+		response: handlers.TaskResponse{}, headers: etag, errors: []int{304, 404}},
+	"PUT /tasks/:id": {id: "updateTask", tag: "tasks", summary: "修改任务", params: []*openapi.Parameter{ifMatch},
+		request: handlers.TaskRequest{}, response: handlers.TaskResponse{}, headers: etag, errors: []int{400, 404, 409, 412, 413, 422}},
+	"PATCH /tasks/:id": {id: "patchTask", tag: "tasks", summary: "用 JSON Merge Patch 或 JSON Patch 修改任务", params: []*openapi.Parameter{ifMatch},
+		bodies: map[string]any{
+			"application/merge-patch+json": &openapi.Schema{Type: "object", Description: "RFC 7396，字段与 TaskRequest 相同"},
+			"application/json-patch+json":  openapi.ArrayOf(&openapi.Schema{Type: "object", Description: "RFC 6902 操作"}),
+		},
+		response: handlers.TaskResponse{}, headers: etag, errors: []int{400, 404, 409, 412, 413, 415, 422}},
+	"DELETE /tasks/:id": {id: "deleteTask", tag: "tasks", summary: "把任务移到回收站", params: []*openapi.Parameter{ifMatch},
+		status: http.StatusNoContent, errors: []int{404, 412}},
+	"POST /tasks/:id/move": {id: "moveTask", tag: "tasks", summary: "手动排序，移动到 before_id 之前或 after_id 之后", params: []*openapi.Parameter{ifMatch},
+		request: handlers.MoveRequest{}, response: handlers.TaskResponse{}, headers: etag, errors: []int{400, 404, 412, 413}},
+
+	//依赖
+	"GET /tasks/:id/dependencies": {id: "getDependencies", tag: "dependencies", summary: "任务的依赖图",
+		response: handlers.DependencyGraphResponse{}, errors: []int{404}},
+	"POST /tasks/:id/dependencies": {id: "addDependency", tag: "dependencies", summary: "添加依赖，blocked_by 和 blocks 二选一",
+		request: handlers.DependencyRequest{}, status: http.StatusCreated, response: handlers.DependencyResponse{}, errors: []int{400, 404, 409, 413}},
+	"DELETE /tasks/:id/dependencies/:other_id": {id: "removeDependency", tag: "dependencies", summary: "删除两个任务之间的依赖",
+		status: http.StatusNoContent, errors: []int{404}},
+
+	//评论与动态
+	"POST /tasks/:id/comments": {id: "createComment", tag: "comments", summary: "发表评论，@用户名 会被识别为提及",
+		request: handlers.CommentRequest{}, status: http.StatusCreated, response: handlers.CommentResponse{}, errors: []int{400, 404, 413}},
+	"GET /tasks/:id/comments": {id: "listComments", tag: "comments", summary: "评论列表", params: pageParams,
+		response: paged{handlers.CommentResponse{}}, errors: []int{404}},
+	"PUT /tasks/:id/comments/:comment_id": {id: "updateComment", tag: "comments", summary: "修改自己的评论",
+		request: handlers.CommentRequest{}, response: handlers.CommentResponse{}, errors: []int{400, 404, 413}},
+	"DELETE /tasks/:id/comments/:comment_id": {id: "deleteComment", tag: "comments", summary: "删除自己的评论",
+		status: http.StatusNoContent, errors: []int{404}},
+	"GET /tasks/:id/activity": {id: "getActivity", tag: "comments", summary: "评论和变更记录合并后的动态流", params: pageParams,
+		response: paged{models.FeedItem{}}, errors: []int{404}},
+
+	//历史版本
+	"GET /tasks/:id/revisions": {id: "listRevisions", tag: "revisions", summary: "历史版本列表", params: pageParams,
+		response: paged{handlers.RevisionResponse{}}, errors: []int{404}},
+	"GET /tasks/:id/revisions/diff": {id: "diffRevisions", tag: "revisions", summary: "比较两个版本",
+		params:   []*openapi.Parameter{query("from", "版本号", integer), query("to", "版本号", integer)},
+		response: fields{"from": 0, "to": 0, "changes": []models.FieldChange{}}, errors: []int{400, 404}},
+	"GET /tasks/:id/revisions/:rev": {id: "getRevision", tag: "revisions", summary: "获取一个历史版本",
+		response: handlers.RevisionResponse{}, errors: []int{404}},
+	"POST /tasks/:id/revisions/:rev/restore": {id: "restoreRevision", tag: "revisions", summary: "恢复到历史版本", params: []*openapi.Parameter{ifMatch},
+		response: handlers.TaskResponse{}, headers: etag, errors: []int{400, 404, 409, 412}},
+
+	//回收站
+	"GET /trash":              {id: "listTrash", tag: "trash", summary: "回收站中的任务", response: []handlers.TaskResponse{}},
+	"POST /trash/:id/restore": {id: "restoreTask", tag: "trash", summary: "从回收站恢复任务", response: handlers.TaskResponse{}, errors: []int{404}},
+	"DELETE /trash/:id":       {id: "purgeTask", tag: "trash", summary: "永久删除回收站中的任务", status: http.StatusNoContent, errors: []int{404}},
+
+	//视图
+	"GET /views": {id: "listViews", tag: "views", summary: "内置视图和保存的视图",
+		response: fields{"builtin": []handlers.ViewResponse{}, "saved": []handlers.ViewResponse{}}},
+	"POST /views": {id: "createView", tag: "views", summary: "保存视图", request: handlers.ViewRequest{},
+		status: http.StatusCreated, response: handlers.ViewResponse{}, errors: []int{400, 413}},
+	"GET /views/:id":    {id: "getView", tag: "views", summary: "获取视图", response: handlers.ViewResponse{}, errors: []int{404}},
+	"PUT /views/:id":    {id: "updateView", tag: "views", summary: "修改视图", request: handlers.ViewRequest{}, response: handlers.ViewResponse{}, errors: []int{400, 404, 413}},
+	"DELETE /views/:id": {id: "deleteView", tag: "views", summary: "删除视图", status: http.StatusNoContent, errors: []int{404}},
+	"GET /views/:id/tasks": {id: "getViewTasks", tag: "views", summary: "视图中的任务",
+		response: fields{"view": handlers.ViewResponse{}, "tasks": []handlers.TaskResponse{}}, errors: []int{400, 404}},
+
+	//计时
+	"GET /timer": {id: "getRunningTimer", tag: "timer", summary: "正在运行的计时器，没有时 running 为 null",
+		response: fields{"running": (*handlers.TimeEntryResponse)(nil)}},
+	"POST /timer/start": {id: "startTimer", tag: "timer", summary: "为任务开始计时，已有正在运行的计时器时返回409",
+		request: handlers.StartTimerRequest{}, status: http.StatusCreated, response: handlers.TimeEntryResponse{}, errors: []int{400, 404, 409, 413}},
+	"POST /timer/stop": {id: "stopTimer", tag: "timer", summary: "停止计时",
+		request: handlers.StopTimerRequest{}, response: handlers.TimeEntryResponse{}, errors: []int{400, 404, 409, 413}},
+	"GET /time-entries": {id: "listTimeEntries", tag: "timer", summary: "时间记录",
+		params:   append(append([]*openapi.Parameter{query("task_id", "只返回该任务的记录", integer)}, rangeParams...), pageParams...),
+		response: paged{handlers.TimeEntryResponse{}}, errors: []int{400}},
+	"GET /time-entries/report": {id: "getTimeReport", tag: "timer", summary: "按天、项目或标签汇总时间，format=csv 时返回 text/csv",
+		params: append([]*openapi.Parameter{
+			query("group_by", "", enum(models.ReportByDay, models.ReportByProject, models.ReportByTag)),
+			query("format", "", enum("json", "csv")),
+		}, rangeParams...),
+		response: fields{"from": dateTime, "to": dateTime, "group_by": "", "rows": []models.TimeReportRow{}, "total_seconds": int64(0)},
+		errors:   []int{400}},
+
+	//统计
+	"GET /stats": {id: "getStats", tag: "stats", summary: "完成情况、连续完成天数和逾期数",
+		params:   append([]*openapi.Parameter{query("interval", "", enum(models.StatsByDay, models.StatsByWeek))}, rangeParams...),
+		response: handlers.StatsResponse{}, errors: []int{400}},
+
+	//项目
+	"GET /projects": {id: "listProjects", tag: "projects", summary: "项目列表", response: []handlers.ProjectResponse{}},
+	"POST /projects": {id: "createProject", tag: "projects", summary: "创建项目，不提供工作流时使用默认工作流",
+		request: handlers.ProjectRequest{}, status: http.StatusCreated, response: handlers.ProjectResponse{}, errors: []int{400, 413}},
+	"GET /projects/:id": {id: "getProject", tag: "projects", summary: "获取项目", response: handlers.ProjectResponse{}, errors: []int{404}},
+	"PUT /projects/:id": {id: "updateProject", tag: "projects", summary: "修改项目，仍有任务处于被移除的状态时返回409",
+		request: handlers.ProjectRequest{}, response: handlers.ProjectResponse{}, errors: []int{400, 404, 409, 413}},
+	"DELETE /projects/:id": {id: "deleteProject", tag: "projects", summary: "删除项目，任务保留并回到默认工作流",
+		status: http.StatusNoContent, errors: []int{404}},
+	"GET /projects/:id/board": {id: "getBoard", tag: "projects", summary: "按工作流的状态分组的看板",
+		response: fields{"project": handlers.ProjectResponse{}, "columns": []handlers.BoardColumnResponse{}}, errors: []int{404}},
+}
+
+var pathParam = regexp.MustCompile(`:([a-z_]+)`)
+
+// specBuilder 生成 OpenAPI 文档时的状态
+type specBuilder struct {
+	gen *openapi.Generator
+}
+
+// newSpec 生成 v1 的 OpenAPI 文档，路径相对于 /api/v1
+func newSpec() *openapi.Document {
+	b := &specBuilder{gen: openapi.NewGenerator()}
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       "Todo API",
+			Version:     "v1",
+			Description: "错误响应均为 application/problem+json（RFC 9457），code 为稳定的错误码。",
+		},
+		Servers: []openapi.Server{{URL: "/api/v1"}},
+		Paths:   map[string]openapi.PathItem{},
+		Components: openapi.Components{
+			Responses: map[string]*openapi.Response{
+				"Problem": {Description: "错误", Content: map[string]openapi.MediaType{
+					middleware.ProblemContentType: {Schema: b.gen.Schema(middleware.Problem{})},
+				}},
+			},
+			Parameters: map[string]*openapi.Parameter{
+				"IdempotencyKey": header("Idempotency-Key", "24小时内使用相同 key 的重试直接返回第一次的响应"),
+			},
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "POST /auth/login 返回的 token"},
+			},
+		},
+	}
+	tags := map[string]bool{}
+	for key, op := range v1Operations {
+		method, path, _ := strings.Cut(key, " ")
+		oasPath := pathParam.ReplaceAllString(path, "{$1}")
+		if doc.Paths[oasPath] == nil {
+			doc.Paths[oasPath] = openapi.PathItem{}
+		}
+		doc.Paths[oasPath][strings.ToLower(method)] = b.operation(method, path, op)
+		if !tags[op.tag] {
+			tags[op.tag] = true
+			doc.Tags = append(doc.Tags, openapi.Tag{Name: op.tag})
+		}
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+	doc.Components.Schemas = b.gen.Schemas
+	return doc
+}
+
+func (b *specBuilder) operation(method, path string, op operation) *openapi.Operation {
+	o := &openapi.Operation{
+		Tags:        []string{op.tag},
+		Summary:     op.summary,
+		OperationID: op.id,
+		Responses:   map[string]*openapi.Response{},
+		Security:    []openapi.SecurityRequirement{},
+	}
+	if !op.public {
+		o.Security = append(o.Security, openapi.SecurityRequirement{"bearerAuth": {}})
+	}
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		o.Parameters = append(o.Parameters, &openapi.Parameter{Name: m[1], In: "path", Required: true, Schema: integer})
+	}
+	o.Parameters = append(o.Parameters, op.params...)
+	if method != http.MethodGet && isIdempotent(path) {
+		o.Parameters = append(o.Parameters, &openapi.Parameter{Ref: "#/components/parameters/IdempotencyKey"})
+	}
+
+	if op.request != nil || op.bodies != nil {
+		o.RequestBody = &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{}}
+		if op.request != nil {
+			o.RequestBody.Content["application/json"] = openapi.MediaType{Schema: b.resolve(op.request)}
+		}
+		for contentType, body := range op.bodies {
+			o.RequestBody.Content[contentType] = openapi.MediaType{Schema: b.resolve(body)}
+		}
+	}
+
+	status := op.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	ok := &openapi.Response{Description: http.StatusText(status), Headers: op.headers}
+	if op.response != nil {
+		ok.Content = map[string]openapi.MediaType{"application/json": {Schema: b.resolve(op.response)}}
+	}
+	o.Responses[strconv.Itoa(status)] = ok
+
+	problem := &openapi.Response{Ref: "#/components/responses/Problem"}
+	errors := op.errors
+	if !op.public {
+		errors = append([]int{http.StatusUnauthorized}, errors...)
+	}
+	for _, code := range errors {
+		if code == http.StatusNotModified {
+			o.Responses["304"] = &openapi.Response{Description: http.StatusText(code)}
+			continue
+		}
+		o.Responses[strconv.Itoa(code)] = problem
+	}
+	o.Responses["default"] = problem
+	return o
+}
+
+// resolve 把 operation 中描述的请求体或响应转换为 schema
+func (b *specBuilder) resolve(v any) *openapi.Schema {
+	switch v := v.(type) {
+	case *openapi.Schema:
+		return v
+	case paged:
+		return openapi.Object(map[string]*openapi.Schema{
+			"items":     openapi.ArrayOf(b.resolve(v.item)),
+			"total":     integer,
+			"page":      integer,
+			"page_size": integer,
+		})
+	case fields:
+		properties := make(map[string]*openapi.Schema, len(v))
+		for name, field := range v {
+			properties[name] = b.resolve(field)
+		}
+		return openapi.Object(properties)
+	}
+	return b.gen.Schema(v)
+}
+
+func isIdempotent(path string) bool {
+	for _, prefix := range idempotentPrefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/HywlEch/Todo_list/internal/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOpenAPICoversRoutes 注册到 gin 的每个 v1 路由都必须出现在 OpenAPI 文档中，反之亦然
+func TestOpenAPICoversRoutes(t *testing.T) {
+	engine, err := New(Deps{})
+	require.NoError(t, err)
+	spec := newSpec()
+
+	prefix := "/api/v1"
+	registered := map[string]bool{}
+	for _, route := range engine.Routes() {
+		if !strings.HasPrefix(route.Path, prefix+"/") {
+			continue
+		}
+		path := strings.TrimPrefix(route.Path, prefix)
+		key := route.Method + " " + path
+		registered[key] = true
+
+		item, ok := spec.Paths[pathParam.ReplaceAllString(path, "{$1}")]
+		if assert.True(t, ok, "路由 %s 不在 OpenAPI 文档中，请在 v1Operations 中补充", key) {
+			assert.Contains(t, item, strings.ToLower(route.Method), "路由 %s 不在 OpenAPI 文档中，请在 v1Operations 中补充", key)
+		}
+	}
+	for key := range v1Operations {
+		assert.True(t, registered[key], "OpenAPI 文档中的 %s 没有注册到路由", key)
+	}
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	spec := newSpec()
+	data, err := json.Marshal(spec)
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, "3.1.0", doc["openapi"])
+
+	//所有 $ref 都指向存在的组件
+	for _, ref := range regexpRefs(string(data)) {
+		name := ref[strings.LastIndex(ref, "/")+1:]
+		switch {
+		case strings.HasPrefix(ref, "#/components/schemas/"):
+			assert.Contains(t, spec.Components.Schemas, name, ref)
+		case strings.HasPrefix(ref, "#/components/responses/"):
+			assert.Contains(t, spec.Components.Responses, name, ref)
+		case strings.HasPrefix(ref, "#/components/parameters/"):
+			assert.Contains(t, spec.Components.Parameters, name, ref)
+		default:
+			t.Errorf("unexpected $ref %s", ref)
+		}
+	}
+
+	//公开的接口不需要认证，其余使用 bearerAuth
+	login := spec.Paths["/auth/login"]["post"]
+	assert.NotNil(t, login.Security)
+	assert.Empty(t, login.Security)
+	assert.Equal(t, []openapi.SecurityRequirement{{"bearerAuth": {}}}, spec.Paths["/tasks"]["post"].Security)
+	assert.Contains(t, spec.Paths["/tasks"]["post"].Responses, "401")
+
+	task := spec.Components.Schemas["TaskResponse"]
+	require.NotNil(t, task)
+	assert.NotContains(t, task.Properties, "user_id")
+	request := spec.Components.Schemas["TaskRequest"]
+	require.NotNil(t, request)
+	assert.Equal(t, []string{"title"}, request.Required)
+}
+
+// TestDocs /docs 使用嵌入的 Swagger UI，页面引用的资源都能加载
+func TestDocs(t *testing.T) {
+	engine, err := New(Deps{})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"/openapi.json"`)
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs/swagger-ui-bundle.js", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "javascript")
+	assert.Contains(t, w.Body.String(), "SwaggerUIBundle")
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs/swagger-ui.css", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/css")
+}
+
+func regexpRefs(data string) []string {
+	var refs []string
+	for _, part := range strings.Split(data, `"$ref":"`)[1:] {
+		refs = append(refs, part[:strings.Index(part, `"`)])
+	}
+	return refs
+}
@@ -0,0 +1,137 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/config"
+	"github.com/HywlEch/Todo_list/internal/handlers"
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/HywlEch/Todo_list/internal/openapi"
+	"github.com/HywlEch/Todo_list/internal/password"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/HywlEch/Todo_list/internal/web"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redsync/redsync/v4"
+)
+
+// Deps 创建路由需要的依赖
+type Deps struct {
+	Store          store.Store
+	Redis          *redis.Client    //为空时不限流，也不处理 Idempotency-Key
+	TaskLock       *redsync.Redsync //为空时更新任务只依赖版本号
+	Locker         *redsync.Redsync //计时器使用的分布式锁
+	JWT            config.JWTConfig
+	PasswordPolicy *password.Policy
+	API            config.APIConfig
+}
+
+// apiVersion 一个 API 版本，挂载在 /api/<Name> 下
+type apiVersion struct {
+	Name     string
+	Register func(r *gin.RouterGroup, h *handlerSet, d Deps)
+}
+
+// versions 当前提供的 API 版本
+// 新增版本时在这里追加一项并实现它的 Register，旧版本在配置的 api.versions 中标记弃用时间
+var versions = []apiVersion{
+	{Name: "v1", Register: registerV1},
+}
+
+// legacyVersion 不带版本前缀的旧路径对应的版本
+const legacyVersion = "v1"
+
+// handlerSet 所有版本共用的 handler
+type handlerSet struct {
+	task    *handlers.TaskHandler
+	user    *handlers.UserHandler
+	comment *handlers.CommentHandler
+	view    *handlers.ViewHandler
+	project *handlers.ProjectHandler
+	timer   *handlers.TimerHandler
+	stats   *handlers.StatsHandler
+}
+
+func newHandlerSet(d Deps) *handlerSet {
+	return &handlerSet{
+		task:    handlers.NewTaskHandler(d.Store, d.TaskLock),
+		user:    handlers.NewUserHandler(d.Store, d.JWT, d.PasswordPolicy),
+		comment: handlers.NewCommentHandler(d.Store),
+		view:    handlers.NewViewHandler(d.Store),
+		project: handlers.NewProjectHandler(d.Store),
+		timer:   handlers.NewTimerHandler(d.Store, d.Locker),
+		stats:   handlers.NewStatsHandler(d.Store),
+	}
+}
+
+// New 创建 gin 引擎并注册所有版本的路由
+func New(d Deps) (*gin.Engine, error) {
+	router := gin.New() //使用gin.New()创建一个干净的引擎
+	//全局应用中间件
+	router.Use(middleware.RequestIDMiddleware())     //为每个请求分配ID，错误响应的 instance 也使用它
+	router.Use(middleware.Logger())                  //应用日志中间件
+	router.Use(gin.Recovery())                       //使用gin默认的Recovery中间件,防止panic
+	router.Use(middleware.LocaleMiddleware(d.Store)) //错误消息的语言
+	router.Use(middleware.ErrorMiddleware())
+	router.Use(middleware.RateLimitMiddleware(d.Redis))
+	router.Use(middleware.TimeoutMiddleware(10 * time.Second))
+
+	h := newHandlerSet(d)
+	for _, v := range versions {
+		dep, err := parseDeprecation(d.API.Versions[v.Name])
+		if err != nil {
+			return nil, fmt.Errorf("api.versions.%s: %w", v.Name, err)
+		}
+		prefix := "/api/" + v.Name
+		group := router.Group(prefix, middleware.APIVersionMiddleware(prefix, v.Name, dep))
+		v.Register(group, h, d)
+	}
+
+	//旧路径保留给已有的客户端，默认指向 /api/v1
+	dep, err := parseDeprecation(d.API.Legacy)
+	if err != nil {
+		return nil, fmt.Errorf("api.legacy: %w", err)
+	}
+	if dep.Successor == "" {
+		dep.Successor = "/api/" + legacyVersion
+	}
+	legacy := router.Group("", middleware.APIVersionMiddleware("", legacyVersion, dep))
+	registerV1(legacy, h, d)
+
+	//OpenAPI 文档和 Swagger UI
+	spec := newSpec()
+	router.GET("/openapi.json", func(c *gin.Context) { c.JSON(http.StatusOK, spec) })
+	docs := gin.WrapH(openapi.UIHandler("/docs", "/openapi.json"))
+	router.GET("/docs", docs)
+	router.GET("/docs/*file", docs)
+
+	//服务端渲染的网页界面，使用 cookie 会话
+	ui, err := web.NewHandler(d.Store, d.JWT)
+	if err != nil {
+		return nil, err
+	}
+	ui.Register(router.Group("/app"))
+	return router, nil
+}
+
+// parseDeprecation 解析配置中的弃用时间
+func parseDeprecation(cfg config.DeprecationConfig) (middleware.Deprecation, error) {
+	dep := middleware.Deprecation{Successor: cfg.Successor}
+	var err error
+	if cfg.Deprecated != "" {
+		if dep.Deprecated, err = time.Parse(time.RFC3339, cfg.Deprecated); err != nil {
+			return dep, fmt.Errorf("invalid deprecated time: %w", err)
+		}
+	}
+	if cfg.Sunset != "" {
+		if dep.Deprecated.IsZero() {
+			return dep, fmt.Errorf("sunset requires deprecated")
+		}
+		if dep.Sunset, err = time.Parse(time.RFC3339, cfg.Sunset); err != nil {
+			return dep, fmt.Errorf("invalid sunset time: %w", err)
+		}
+	}
+	return dep, nil
+}
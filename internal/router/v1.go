@@ -0,0 +1,98 @@
+package router
+
+import (
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// registerV1 注册 v1 的路由
+func registerV1(r *gin.RouterGroup, h *handlerSet, d Deps) {
+	auth := middleware.AuthMiddleware(d.JWT.Secret)
+	idempotency := middleware.IdempotencyMiddleware(d.Redis)
+
+	authRouter := r.Group("/auth")
+	{
+		authRouter.POST("/regist", h.user.Regiester)
+		authRouter.POST("/login", h.user.Login)
+	}
+
+	meRouter := r.Group("/me", auth)
+	{
+		meRouter.GET("", h.user.GetMe)
+		meRouter.PATCH("", h.user.PatchMe)
+	}
+
+	taskRouter := r.Group("/tasks", auth, idempotency)
+	{
+		taskRouter.POST("", h.task.CreateTask)
+		taskRouter.GET("", h.task.GetTasks)
+		taskRouter.POST("/bulk", h.task.BulkTasks)
+		taskRouter.GET("/search", h.task.SearchTasks)
+		taskRouter.GET("/:id", h.task.GetTaskByID)
+		taskRouter.PUT("/:id", h.task.UpdateTask)
+		taskRouter.PATCH("/:id", h.task.PatchTask)
+		taskRouter.DELETE("/:id", h.task.DeleteTask)
+		taskRouter.POST("/:id/move", h.task.MoveTask)
+		taskRouter.GET("/:id/dependencies", h.task.GetDependencies)
+		taskRouter.POST("/:id/dependencies", h.task.AddDependency)
+		taskRouter.DELETE("/:id/dependencies/:other_id", h.task.RemoveDependency)
+
+		//评论与动态
+		taskRouter.POST("/:id/comments", h.comment.CreateComment)
+		taskRouter.GET("/:id/comments", h.comment.GetComments)
+		taskRouter.PUT("/:id/comments/:comment_id", h.comment.UpdateComment)
+		taskRouter.DELETE("/:id/comments/:comment_id", h.comment.DeleteComment)
+		taskRouter.GET("/:id/activity", h.comment.GetActivity)
+
+		//历史版本
+		taskRouter.GET("/:id/revisions", h.task.GetTaskRevisions)
+		taskRouter.GET("/:id/revisions/diff", h.task.DiffTaskRevisions)
+		taskRouter.GET("/:id/revisions/:rev", h.task.GetTaskRevision)
+		taskRouter.POST("/:id/revisions/:rev/restore", h.task.RestoreTaskRevision)
+	}
+
+	trashRouter := r.Group("/trash", auth, idempotency)
+	{
+		trashRouter.GET("", h.task.GetTrash)
+		trashRouter.POST("/:id/restore", h.task.RestoreTask)
+		trashRouter.DELETE("/:id", h.task.PurgeTask)
+	}
+
+	viewRouter := r.Group("/views", auth, idempotency)
+	{
+		viewRouter.GET("", h.view.GetViews)
+		viewRouter.POST("", h.view.CreateView)
+		viewRouter.GET("/:id", h.view.GetView)
+		viewRouter.PUT("/:id", h.view.UpdateView)
+		viewRouter.DELETE("/:id", h.view.DeleteView)
+		viewRouter.GET("/:id/tasks", h.view.GetViewTasks)
+	}
+
+	timerRouter := r.Group("/timer", auth, idempotency)
+	{
+		timerRouter.GET("", h.timer.GetRunningTimer)
+		timerRouter.POST("/start", h.timer.StartTimer)
+		timerRouter.POST("/stop", h.timer.StopTimer)
+	}
+
+	timeEntryRouter := r.Group("/time-entries", auth)
+	{
+		timeEntryRouter.GET("", h.timer.GetTimeEntries)
+		timeEntryRouter.GET("/report", h.timer.GetTimeReport)
+	}
+
+	statsRouter := r.Group("/stats", auth)
+	{
+		statsRouter.GET("", h.stats.GetStats)
+	}
+
+	projectRouter := r.Group("/projects", auth, idempotency)
+	{
+		projectRouter.GET("", h.project.GetProjects)
+		projectRouter.POST("", h.project.CreateProject)
+		projectRouter.GET("/:id", h.project.GetProject)
+		projectRouter.PUT("/:id", h.project.UpdateProject)
+		projectRouter.DELETE("/:id", h.project.DeleteProject)
+		projectRouter.GET("/:id/board", h.project.GetBoard)
+	}
+}
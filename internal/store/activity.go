@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// taskChangeEvents 比较更新前后的任务，生成对应的动态记录
+func taskChangeEvents(old, updated *models.Task) []models.ActivityEvent {
+	var events []models.ActivityEvent
+	newEvent := func(action string, oldValue, newValue *string) {
+		events = append(events, models.ActivityEvent{
+			TaskID:   updated.ID,
+			UserID:   updated.UserID,
+			Action:   action,
+			OldValue: oldValue,
+			NewValue: newValue,
+		})
+	}
+	if old.Title != updated.Title {
+		oldTitle, newTitle := old.Title, updated.Title
+		newEvent(models.ActivityTitleChanged, &oldTitle, &newTitle)
+	}
+	if old.Content != updated.Content {
+		// 内容可能很长，不在动态里保存具体值
+		newEvent(models.ActivityContentChanged, nil, nil)
+	}
+	if !old.Done && updated.Done {
+		newEvent(models.ActivityTaskCompleted, nil, nil)
+	}
+	if old.Done && !updated.Done {
+		newEvent(models.ActivityTaskReopened, nil, nil)
+	}
+	if old.Status != updated.Status {
+		oldStatus, newStatus := old.Status, updated.Status
+		newEvent(models.ActivityStatusChanged, &oldStatus, &newStatus)
+	}
+	return events
+}
+
+// insertActivities 在事务中写入动态记录
+func insertActivities(ctx context.Context, tx *sqlx.Tx, events []models.ActivityEvent) error {
+	query := `INSERT INTO task_activities (task_id, user_id, action, old_value, new_value) VALUES ($1, $2, $3, $4, $5);`
+	for _, e := range events {
+		if _, err := tx.ExecContext(ctx, query, e.TaskID, e.UserID, e.Action, e.OldValue, e.NewValue); err != nil {
+			return fmt.Errorf("store: failed to record activity %s: %w", e.Action, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTaskChangeEvents 测试更新任务时生成的动态记录
+func TestTaskChangeEvents(t *testing.T) {
+	old := &models.Task{ID: 1, UserID: 7, Title: "old", Content: "a", Done: false}
+	updated := &models.Task{ID: 1, UserID: 7, Title: "new", Content: "a", Done: true}
+
+	events := taskChangeEvents(old, updated)
+
+	assert.Len(t, events, 2)
+	assert.Equal(t, models.ActivityTitleChanged, events[0].Action)
+	assert.Equal(t, "old", *events[0].OldValue)
+	assert.Equal(t, "new", *events[0].NewValue)
+	assert.Equal(t, models.ActivityTaskCompleted, events[1].Action)
+	assert.Equal(t, 7, events[1].UserID)
+
+	// 没有变化时不生成记录
+	assert.Empty(t, taskChangeEvents(updated, updated))
+}
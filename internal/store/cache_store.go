@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"slices"
 	"time"
 	"context"
 
@@ -34,6 +35,17 @@ func userTaskKey(userID int) string {
 	return fmt.Sprintf("user:%d", userID)
 }
 
+func userProfileKey(userID int) string {
+	return fmt.Sprintf("profile:%d", userID)
+}
+
+func statsKey(userID int, q StatsQuery) string {
+	return fmt.Sprintf("stats:%d:%d:%d:%s:%s:%d", userID, q.From.Unix(), q.To.Unix(), q.Interval, q.Location, q.WeekStart)
+}
+
+// 统计数据只短暂缓存，任务变化时不主动失效
+const statsTTL = 1 * time.Minute
+
 // 缓存核心逻辑
 func (s *CacheStore) GetTaskByID(ctx context.Context, id int, userID int) (*models.Task, error) {
 	key := taskKey(id)
@@ -97,6 +109,11 @@ func (s *CacheStore) GetTasks(ctx context.Context, userID int) ([]models.Task, e
 	return tasks, nil
 }
 
+// 带过滤条件的查询组合太多，不做缓存
+func (s *CacheStore) QueryTasks(ctx context.Context, userID int, q TaskQuery) ([]models.Task, error) {
+	return s.next.QueryTasks(ctx, userID, q)
+}
+
 // 缓存失效逻辑
 func (s *CacheStore) CreateTask(ctx context.Context, task *models.Task) error {
 	err := s.next.CreateTask(ctx, task)
@@ -118,24 +135,61 @@ func (s *CacheStore) UpdateTask(ctx context.Context, task *models.Task) error {
 	if err != nil {
 		return err
 	}
-	key := taskKey(task.ID)
-	log.Printf("[CacheStore]INVILIDATA: %s(due to UpdateTask)", key)
-	if err := s.redisClient.Del(ctx, key).Err(); err != nil {
-		log.Printf("[CacheStore]Error: Failed to delete key: %s:%v", key, err)
-	}
+	s.invalidateTask(ctx, task.ID, task.UserID, "UpdateTask")
+	s.invalidateDependents(ctx, task.ID, task.UserID, "UpdateTask")
+	return nil
+}
 
-	//更新了任务必须让该用户的“任务列表”缓存失效
-	taskKey := userTaskKey(task.UserID)
-	log.Printf("[CacheStore]INVILIDATA: %s(due to UpdateTask)", key)
-	if err := s.redisClient.Del(ctx, taskKey).Err(); err != nil {
-		log.Printf("[CacheStore]Error: Failed to delete key: %s:%v", key, err)
+// 部分更新
+func (s *CacheStore) PatchTask(ctx context.Context, task *models.Task, fields []string) error {
+	if err := s.next.PatchTask(ctx, task, fields); err != nil {
+		return err
+	}
+	s.invalidateTask(ctx, task.ID, task.UserID, "PatchTask")
+	if changesDone(fields) {
+		s.invalidateDependents(ctx, task.ID, task.UserID, "PatchTask")
 	}
 	return nil
 }
 
+// changesDone 修改这些字段时 done 可能随工作流变化
+func changesDone(fields []string) bool {
+	return slices.Contains(fields, "done") || slices.Contains(fields, "status") || slices.Contains(fields, "project_id")
+}
+
+// invalidateTask 让单个任务和该用户的“任务列表”缓存失效
+func (s *CacheStore) invalidateTask(ctx context.Context, id int, userID int, reason string) {
+	for _, key := range []string{taskKey(id), userTaskKey(userID)} {
+		log.Printf("[CacheStore]INVILIDATA: %s(due to %s)", key, reason)
+		if err := s.redisClient.Del(ctx, key).Err(); err != nil {
+			log.Printf("[CacheStore]Error: Failed to delete key: %s:%v", key, err)
+		}
+	}
+}
+
+// invalidateDependents blocker 完成、重新打开、删除或恢复后，被它阻塞的任务的 is_blocked 随之变化
+func (s *CacheStore) invalidateDependents(ctx context.Context, blockerID int, userID int, reason string) {
+	ids, err := s.next.GetDependents(ctx, blockerID, userID)
+	if err != nil {
+		log.Printf("[CacheStore]Error: Failed to get dependents of task %d: %v", blockerID, err)
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, taskKey(id))
+	}
+	log.Printf("[CacheStore]INVILIDATA: %d keys(due to %s)", len(keys), reason)
+	if err := s.redisClient.Del(ctx, keys...).Err(); err != nil {
+		log.Printf("[CacheStore]Error: Failed to delete keys: %v:%v", keys, err)
+	}
+}
+
 // 删除
-func (s *CacheStore) DeleteTask(ctx context.Context, id int, userID int) error {
-	err := s.next.DeleteTask(ctx, id, userID)
+func (s *CacheStore) DeleteTask(ctx context.Context, id int, userID int, version int) error {
+	err := s.next.DeleteTask(ctx, id, userID, version)
 	if err != nil {
 		return err
 	}
@@ -149,9 +203,86 @@ func (s *CacheStore) DeleteTask(ctx context.Context, id int, userID int) error {
 	if err := s.redisClient.Del(ctx, taskKey).Err(); err != nil {
 		log.Printf("[CacheStore]Error: Failed to delete key: %s:%v", key, err)
 	}
+	s.invalidateDependents(ctx, id, userID, "DeleteTask")
 	return nil
 }
 
+// 批量操作：所有单个任务的缓存一次删除，任务列表缓存每个用户只失效一次
+func (s *CacheStore) BulkApply(ctx context.Context, userID int, ops []models.BulkOperation, atomic bool) ([]models.BulkResult, error) {
+	results, err := s.next.BulkApply(ctx, userID, ops, atomic)
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{userTaskKey(userID)}
+	for _, r := range results {
+		if r.Err == nil && r.ID != 0 {
+			keys = append(keys, taskKey(r.ID))
+		}
+		if r.Task != nil {
+			for _, id := range r.Task.Rebalanced {
+				keys = append(keys, taskKey(id))
+			}
+		}
+	}
+	log.Printf("[CacheStore]INVILIDATA: %d keys(due to BulkApply)", len(keys))
+	if err := s.redisClient.Del(ctx, keys...).Err(); err != nil {
+		log.Printf("[CacheStore]Error: Failed to delete keys: %v:%v", keys, err)
+	}
+	for _, r := range results {
+		if r.Err != nil || r.ID == 0 {
+			continue
+		}
+		op := ops[r.Index]
+		if op.Op == models.BulkOpDelete || op.Op == models.BulkOpComplete ||
+			(op.Op == models.BulkOpUpdate && (op.Done != nil || op.Status != nil)) {
+			s.invalidateDependents(ctx, r.ID, userID, "BulkApply")
+		}
+	}
+	return results, nil
+}
+
+// 移动任务
+func (s *CacheStore) MoveTask(ctx context.Context, id int, userID int, version int, beforeID int, afterID int) (*models.Task, error) {
+	task, err := s.next.MoveTask(ctx, id, userID, version, beforeID, afterID)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateTask(ctx, id, userID, "MoveTask")
+	//移动前重新平衡过的列表中，其他任务的排序键也变了
+	if len(task.Rebalanced) > 0 {
+		keys := make([]string, 0, len(task.Rebalanced))
+		for _, rid := range task.Rebalanced {
+			keys = append(keys, taskKey(rid))
+		}
+		log.Printf("[CacheStore]INVILIDATA: %d keys(due to MoveTask)", len(keys))
+		if err := s.redisClient.Del(ctx, keys...).Err(); err != nil {
+			log.Printf("[CacheStore]Error: Failed to delete keys: %v:%v", keys, err)
+		}
+	}
+	return task, nil
+}
+
+// 重新平衡排序键：被重新平衡的任务及其所属用户的列表缓存全部失效
+func (s *CacheStore) RebalancePositions(ctx context.Context, maxKeyLength int) (map[int][]int, error) {
+	rebalanced, err := s.next.RebalancePositions(ctx, maxKeyLength)
+	for userID, ids := range rebalanced {
+		keys := []string{userTaskKey(userID)}
+		for _, id := range ids {
+			keys = append(keys, taskKey(id))
+		}
+		log.Printf("[CacheStore]INVILIDATA: %d keys(due to RebalancePositions)", len(keys))
+		if err := s.redisClient.Del(ctx, keys...).Err(); err != nil {
+			log.Printf("[CacheStore]Error: Failed to delete keys: %v:%v", keys, err)
+		}
+	}
+	return rebalanced, err
+}
+
+// 搜索结果不缓存
+func (s *CacheStore) SearchTasks(ctx context.Context, userID int, q string, opts ListOptions) ([]models.SearchResult, int, error) {
+	return s.next.SearchTasks(ctx, userID, q, opts)
+}
+
 func (s *CacheStore) CreateUser(ctx context.Context, user *models.User) error {
 	return s.next.CreateUser(ctx, user)
 }
@@ -159,3 +290,267 @@ func (s *CacheStore) CreateUser(ctx context.Context, user *models.User) error {
 func (s *CacheStore) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
 	return s.next.GetUserByUsername(ctx, username)
 }
+
+// 用户资料在每个涉及日期的请求中都会读取，缓存起来
+func (s *CacheStore) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	key := userProfileKey(id)
+	val, err := s.redisClient.Get(ctx, key).Result()
+	if err == nil {
+		var user models.User
+		if err := json.Unmarshal([]byte(val), &user); err == nil {
+			return &user, nil
+		}
+	}
+	log.Printf("[CacheStore]MISS: GetUserByID(key: %s)", key)
+	user, err := s.next.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	//PasswordHash 不参与 JSON 序列化，不会写入缓存
+	jsonData, err := json.Marshal(user)
+	if err != nil {
+		log.Printf("[CacheStore]Error: Failed to marshal user: %v", err)
+		return user, nil
+	}
+	if err := s.redisClient.Set(ctx, key, jsonData, s.ttl).Err(); err != nil {
+		log.Printf("[CacheStore]Error: Failed to set user in redis: %v", err)
+	}
+	return user, nil
+}
+
+func (s *CacheStore) UpdateUserProfile(ctx context.Context, user *models.User) error {
+	if err := s.next.UpdateUserProfile(ctx, user); err != nil {
+		return err
+	}
+	key := userProfileKey(user.ID)
+	log.Printf("[CacheStore]INVILIDATA: %s(due to UpdateUserProfile)", key)
+	if err := s.redisClient.Del(ctx, key).Err(); err != nil {
+		log.Printf("[CacheStore]Error: Failed to delete key: %s:%v", key, err)
+	}
+	return nil
+}
+
+// 评论和动态不做缓存，直接透传
+func (s *CacheStore) CreateComment(ctx context.Context, comment *models.Comment) error {
+	return s.next.CreateComment(ctx, comment)
+}
+
+func (s *CacheStore) GetComments(ctx context.Context, taskID int, opts ListOptions) ([]models.Comment, int, error) {
+	return s.next.GetComments(ctx, taskID, opts)
+}
+
+func (s *CacheStore) UpdateComment(ctx context.Context, comment *models.Comment) error {
+	return s.next.UpdateComment(ctx, comment)
+}
+
+func (s *CacheStore) DeleteComment(ctx context.Context, id int, taskID int, userID int) error {
+	return s.next.DeleteComment(ctx, id, taskID, userID)
+}
+
+func (s *CacheStore) GetActivityFeed(ctx context.Context, taskID int, opts ListOptions) ([]models.FeedItem, int, error) {
+	return s.next.GetActivityFeed(ctx, taskID, opts)
+}
+
+// 历史版本只追加不修改，直接透传
+func (s *CacheStore) GetTaskRevisions(ctx context.Context, taskID int, opts ListOptions) ([]models.TaskRevision, int, error) {
+	return s.next.GetTaskRevisions(ctx, taskID, opts)
+}
+
+func (s *CacheStore) GetTaskRevision(ctx context.Context, taskID int, revision int) (*models.TaskRevision, error) {
+	return s.next.GetTaskRevision(ctx, taskID, revision)
+}
+
+func (s *CacheStore) RestoreTaskRevision(ctx context.Context, task *models.Task, revision int) error {
+	if err := s.next.RestoreTaskRevision(ctx, task, revision); err != nil {
+		return err
+	}
+	s.invalidateTask(ctx, task.ID, task.UserID, "RestoreTaskRevision")
+	s.invalidateDependents(ctx, task.ID, task.UserID, "RestoreTaskRevision")
+	return nil
+}
+
+// 回收站
+func (s *CacheStore) GetTrash(ctx context.Context, userID int) ([]models.Task, error) {
+	return s.next.GetTrash(ctx, userID)
+}
+
+func (s *CacheStore) RestoreTask(ctx context.Context, id int, userID int) error {
+	if err := s.next.RestoreTask(ctx, id, userID); err != nil {
+		return err
+	}
+	//恢复后任务重新出现在列表中，版本号也变了
+	if err := s.redisClient.Del(ctx, taskKey(id)).Err(); err != nil {
+		log.Printf("[CacheStore]Error: Failed to delete key: %s:%v", taskKey(id), err)
+	}
+	key := userTaskKey(userID)
+	log.Printf("[CacheStore]INVILIDATA: %s(due to RestoreTask)", key)
+	if err := s.redisClient.Del(ctx, key).Err(); err != nil {
+		log.Printf("[CacheStore]Error: Failed to delete key: %s:%v", key, err)
+	}
+	s.invalidateDependents(ctx, id, userID, "RestoreTask")
+	return nil
+}
+
+// 彻底删除时依赖关系随任务一起删除，先记下被它阻塞的任务，删除后让它们的缓存失效
+func (s *CacheStore) PurgeTask(ctx context.Context, id int, userID int) error {
+	dependents, err := s.next.GetDependents(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.next.PurgeTask(ctx, id, userID); err != nil {
+		return err
+	}
+	if len(dependents) == 0 {
+		return nil
+	}
+	keys := []string{userTaskKey(userID)}
+	for _, dep := range dependents {
+		keys = append(keys, taskKey(dep))
+	}
+	log.Printf("[CacheStore]INVILIDATA: %d keys(due to PurgeTask)", len(keys))
+	if err := s.redisClient.Del(ctx, keys...).Err(); err != nil {
+		log.Printf("[CacheStore]Error: Failed to delete keys: %v:%v", keys, err)
+	}
+	return nil
+}
+
+// 回收站中的任务已经不再阻塞其他任务（见 task_is_blocked），按时间清空时不再逐个失效
+func (s *CacheStore) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return s.next.PurgeTrash(ctx, deletedBefore)
+}
+
+// 依赖关系改变被阻塞任务的 is_blocked
+func (s *CacheStore) AddDependency(ctx context.Context, userID int, blockerID int, blockedID int) (*models.Dependency, error) {
+	dep, err := s.next.AddDependency(ctx, userID, blockerID, blockedID)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateTask(ctx, blockedID, userID, "AddDependency")
+	return dep, nil
+}
+
+func (s *CacheStore) RemoveDependency(ctx context.Context, userID int, taskID int, otherID int) error {
+	if err := s.next.RemoveDependency(ctx, userID, taskID, otherID); err != nil {
+		return err
+	}
+	s.invalidateTask(ctx, taskID, userID, "RemoveDependency")
+	s.invalidateTask(ctx, otherID, userID, "RemoveDependency")
+	return nil
+}
+
+func (s *CacheStore) GetDependencyGraph(ctx context.Context, taskID int, userID int) (*models.DependencyGraph, error) {
+	return s.next.GetDependencyGraph(ctx, taskID, userID)
+}
+
+func (s *CacheStore) GetDependents(ctx context.Context, taskID int, userID int) ([]int, error) {
+	return s.next.GetDependents(ctx, taskID, userID)
+}
+
+// 时间记录不缓存
+func (s *CacheStore) StartTimer(ctx context.Context, entry *models.TimeEntry) error {
+	return s.next.StartTimer(ctx, entry)
+}
+
+func (s *CacheStore) StopTimer(ctx context.Context, userID int, note *string) (*models.TimeEntry, error) {
+	return s.next.StopTimer(ctx, userID, note)
+}
+
+func (s *CacheStore) GetRunningTimer(ctx context.Context, userID int) (*models.TimeEntry, error) {
+	return s.next.GetRunningTimer(ctx, userID)
+}
+
+func (s *CacheStore) GetTimeEntries(ctx context.Context, userID int, q TimeEntryQuery, opts ListOptions) ([]models.TimeEntry, int, error) {
+	return s.next.GetTimeEntries(ctx, userID, q, opts)
+}
+
+func (s *CacheStore) GetTimeReport(ctx context.Context, userID int, from, to time.Time, groupBy string, tz string) ([]models.TimeReportRow, error) {
+	return s.next.GetTimeReport(ctx, userID, from, to, groupBy, tz)
+}
+
+// 统计
+func (s *CacheStore) GetStats(ctx context.Context, userID int, q StatsQuery) (*models.Stats, error) {
+	key := statsKey(userID, q)
+	val, err := s.redisClient.Get(ctx, key).Result()
+	if err == nil {
+		var stats models.Stats
+		if err := json.Unmarshal([]byte(val), &stats); err == nil {
+			return &stats, nil
+		}
+	}
+	log.Printf("[CacheStore]MISS: GetStats(key: %s)", key)
+	stats, err := s.next.GetStats(ctx, userID, q)
+	if err != nil {
+		return nil, err
+	}
+	jsonData, err := json.Marshal(stats)
+	if err != nil {
+		log.Printf("[CacheStore]Error: Failed to marshal stats: %v", err)
+		return stats, nil
+	}
+	if err := s.redisClient.Set(ctx, key, jsonData, statsTTL).Err(); err != nil {
+		log.Printf("[CacheStore]Error: Failed to set stats in redis: %v", err)
+	}
+	return stats, nil
+}
+
+// 项目
+func (s *CacheStore) CreateProject(ctx context.Context, project *models.Project) error {
+	return s.next.CreateProject(ctx, project)
+}
+
+func (s *CacheStore) GetProjects(ctx context.Context, userID int) ([]models.Project, error) {
+	return s.next.GetProjects(ctx, userID)
+}
+
+func (s *CacheStore) GetProject(ctx context.Context, id int, userID int) (*models.Project, error) {
+	return s.next.GetProject(ctx, id, userID)
+}
+
+func (s *CacheStore) UpdateProject(ctx context.Context, project *models.Project) error {
+	return s.next.UpdateProject(ctx, project)
+}
+
+// 删除项目会修改项目中所有任务的状态，先记下这些任务再让它们的缓存失效
+func (s *CacheStore) DeleteProject(ctx context.Context, id int, userID int) error {
+	tasks, err := s.next.GetProjectTasks(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.next.DeleteProject(ctx, id, userID); err != nil {
+		return err
+	}
+	keys := []string{userTaskKey(userID)}
+	for _, task := range tasks {
+		keys = append(keys, taskKey(task.ID))
+	}
+	log.Printf("[CacheStore]INVILIDATA: %d keys(due to DeleteProject)", len(keys))
+	if err := s.redisClient.Del(ctx, keys...).Err(); err != nil {
+		log.Printf("[CacheStore]Error: Failed to delete keys: %v:%v", keys, err)
+	}
+	return nil
+}
+
+func (s *CacheStore) GetProjectTasks(ctx context.Context, projectID int, userID int) ([]models.Task, error) {
+	return s.next.GetProjectTasks(ctx, projectID, userID)
+}
+
+// 保存的视图
+func (s *CacheStore) CreateView(ctx context.Context, view *models.SavedView) error {
+	return s.next.CreateView(ctx, view)
+}
+
+func (s *CacheStore) GetViews(ctx context.Context, userID int) ([]models.SavedView, error) {
+	return s.next.GetViews(ctx, userID)
+}
+
+func (s *CacheStore) GetView(ctx context.Context, id int, userID int) (*models.SavedView, error) {
+	return s.next.GetView(ctx, id, userID)
+}
+
+func (s *CacheStore) UpdateView(ctx context.Context, view *models.SavedView) error {
+	return s.next.UpdateView(ctx, view)
+}
+
+func (s *CacheStore) DeleteView(ctx context.Context, id int, userID int) error {
+	return s.next.DeleteView(ctx, id, userID)
+}
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCacheStore 返回使用 miniredis 的 CacheStore，缓存中预先放入 keys
+func newTestCacheStore(t *testing.T, keys ...string) (*CacheStore, *MockStore, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	for _, key := range keys {
+		require.NoError(t, mr.Set(key, "{}"))
+	}
+	next := new(MockStore)
+	return NewCacheStore(next, client), next, mr
+}
+
+func TestCacheStore_MoveTaskRebalanced(t *testing.T) {
+	s, next, mr := newTestCacheStore(t, "task:1", "task:2", "task:3", "task:4", "user:1")
+	next.On("MoveTask", mock.Anything, 1, 1, 0, 0, 3).
+		Return(&models.Task{ID: 1, UserID: 1, Rebalanced: []int{1, 2, 3}}, nil)
+
+	_, err := s.MoveTask(context.Background(), 1, 1, 0, 0, 3)
+
+	require.NoError(t, err)
+	for _, key := range []string{"task:1", "task:2", "task:3", "user:1"} {
+		assert.False(t, mr.Exists(key), key)
+	}
+	assert.True(t, mr.Exists("task:4"), "没有被重新平衡的任务保留缓存")
+}
+
+func TestCacheStore_BulkMoveRebalanced(t *testing.T) {
+	s, next, mr := newTestCacheStore(t, "task:1", "task:2", "task:3", "user:1")
+	ops := []models.BulkOperation{{Op: models.BulkOpMove, ID: 1, AfterID: 3}}
+	next.On("BulkApply", mock.Anything, 1, ops, true).Return([]models.BulkResult{
+		{Index: 0, Op: models.BulkOpMove, ID: 1, Task: &models.Task{ID: 1, UserID: 1, Rebalanced: []int{1, 2}}},
+	}, nil)
+
+	_, err := s.BulkApply(context.Background(), 1, ops, true)
+
+	require.NoError(t, err)
+	for _, key := range []string{"task:1", "task:2", "user:1"} {
+		assert.False(t, mr.Exists(key), key)
+	}
+	assert.True(t, mr.Exists("task:3"))
+}
+
+// TestCacheStore_InvalidateDependents blocker 的完成或删除状态变化后，被它阻塞的任务的缓存失效
+func TestCacheStore_InvalidateDependents(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		call func(s *CacheStore, next *MockStore) error
+	}{
+		{"重新打开", func(s *CacheStore, next *MockStore) error {
+			task := &models.Task{ID: 1, UserID: 1, Done: false}
+			next.On("PatchTask", mock.Anything, task, []string{"done"}).Return(nil)
+			return s.PatchTask(ctx, task, []string{"done"})
+		}},
+		{"修改状态", func(s *CacheStore, next *MockStore) error {
+			task := &models.Task{ID: 1, UserID: 1, Status: "todo"}
+			next.On("PatchTask", mock.Anything, task, []string{"status"}).Return(nil)
+			return s.PatchTask(ctx, task, []string{"status"})
+		}},
+		{"移入回收站", func(s *CacheStore, next *MockStore) error {
+			next.On("DeleteTask", mock.Anything, 1, 1, 0).Return(nil)
+			return s.DeleteTask(ctx, 1, 1, 0)
+		}},
+		{"恢复", func(s *CacheStore, next *MockStore) error {
+			next.On("RestoreTask", mock.Anything, 1, 1).Return(nil)
+			return s.RestoreTask(ctx, 1, 1)
+		}},
+		{"彻底删除", func(s *CacheStore, next *MockStore) error {
+			next.On("PurgeTask", mock.Anything, 1, 1).Return(nil)
+			return s.PurgeTask(ctx, 1, 1)
+		}},
+		{"批量删除", func(s *CacheStore, next *MockStore) error {
+			ops := []models.BulkOperation{{Op: models.BulkOpDelete, ID: 1}}
+			next.On("BulkApply", mock.Anything, 1, ops, false).Return([]models.BulkResult{{Index: 0, Op: models.BulkOpDelete, ID: 1}}, nil)
+			_, err := s.BulkApply(ctx, 1, ops, false)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, next, mr := newTestCacheStore(t, "task:1", "task:2", "task:3", "task:4", "user:1")
+			next.On("GetDependents", mock.Anything, 1, 1).Return([]int{2, 3}, nil).Once()
+
+			require.NoError(t, tt.call(s, next))
+
+			for _, key := range []string{"task:2", "task:3", "user:1"} {
+				assert.False(t, mr.Exists(key), key)
+			}
+			assert.True(t, mr.Exists("task:4"), "无关的任务保留缓存")
+			next.AssertExpectations(t)
+		})
+	}
+}
+
+// TestCacheStore_PatchWithoutDone 不影响 done 的修改不查询被阻塞的任务
+func TestCacheStore_PatchWithoutDone(t *testing.T) {
+	s, next, mr := newTestCacheStore(t, "task:1", "task:2")
+	task := &models.Task{ID: 1, UserID: 1, Title: "renamed"}
+	next.On("PatchTask", mock.Anything, task, []string{"title"}).Return(nil)
+
+	require.NoError(t, s.PatchTask(context.Background(), task, []string{"title"}))
+
+	assert.False(t, mr.Exists("task:1"))
+	assert.True(t, mr.Exists("task:2"))
+	next.AssertNotCalled(t, "GetDependents", mock.Anything, mock.Anything, mock.Anything)
+}
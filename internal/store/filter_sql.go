@@ -0,0 +1,120 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/HywlEch/Todo_list/internal/filter"
+)
+
+// filterColumns 过滤字段到数据库列的映射，只有这里列出的列会出现在 SQL 中
+var filterColumns = map[string]string{
+	"done":      "done",
+	"blocked":   "task_is_blocked(id)",
+	"title":     "title",
+	"content":   "content",
+	"tag":       "tags",
+	"priority":  "priority",
+	"due":       "due_at",
+	"created":   "created_at",
+	"updated":   "updated_at",
+	"completed": "completed_at",
+}
+
+// likeEscaper 转义 LIKE 中的通配符
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// sqlBuilder 把过滤表达式转换为参数化的 WHERE 条件，值全部通过占位符传入
+type sqlBuilder struct {
+	args []interface{}
+	sql  strings.Builder
+}
+
+func (b *sqlBuilder) arg(v interface{}) string {
+	b.args = append(b.args, v)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+// build 返回 n 对应的条件，所有节点写入同一个 strings.Builder，耗时与节点数成正比
+func (b *sqlBuilder) build(n filter.Node) (string, error) {
+	b.sql.Reset()
+	if err := b.write(n); err != nil {
+		return "", err
+	}
+	return b.sql.String(), nil
+}
+
+func (b *sqlBuilder) write(n filter.Node) error {
+	switch n := n.(type) {
+	case *filter.And:
+		return b.binary(n.Left, n.Right, "AND")
+	case *filter.Or:
+		return b.binary(n.Left, n.Right, "OR")
+	case *filter.Not:
+		b.sql.WriteString("NOT (")
+		if err := b.write(n.X); err != nil {
+			return err
+		}
+		b.sql.WriteString(")")
+		return nil
+	case *filter.Cond:
+		cond, err := b.cond(n)
+		if err != nil {
+			return err
+		}
+		b.sql.WriteString(cond)
+		return nil
+	}
+	return fmt.Errorf("store: unknown filter node %T", n)
+}
+
+func (b *sqlBuilder) binary(left, right filter.Node, op string) error {
+	b.sql.WriteString("(")
+	if err := b.write(left); err != nil {
+		return err
+	}
+	b.sql.WriteString(" " + op + " ")
+	if err := b.write(right); err != nil {
+		return err
+	}
+	b.sql.WriteString(")")
+	return nil
+}
+
+func (b *sqlBuilder) cond(c *filter.Cond) (string, error) {
+	col, ok := filterColumns[c.Field]
+	if !ok {
+		return "", fmt.Errorf("store: unknown filter field %q", c.Field)
+	}
+	switch c.Type {
+	case filter.TextField:
+		if c.Op == filter.OpMatch {
+			return col + " ILIKE " + b.arg("%"+likeEscaper.Replace(c.Value.(string))+"%"), nil
+		}
+	case filter.TagField:
+		has := b.arg(c.Value) + " = ANY(" + col + ")"
+		if c.Op == filter.OpNe {
+			return "NOT (" + has + ")", nil
+		}
+		return has, nil
+	case filter.TimeField:
+		if c.Value == nil {
+			if c.Op == filter.OpNe {
+				return col + " IS NOT NULL", nil
+			}
+			return col + " IS NULL", nil
+		}
+		if c.Day {
+			start, end := c.DayRange()
+			return "(" + col + " >= " + b.arg(start) + " AND " + col + " < " + b.arg(end) + ")", nil
+		}
+	}
+	op := c.Op
+	switch op {
+	case filter.OpMatch:
+		op = "="
+	case filter.OpNe:
+		op = "<>"
+	}
+	return col + " " + op + " " + b.arg(c.Value), nil
+}
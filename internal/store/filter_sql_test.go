@@ -0,0 +1,22 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/filter"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSQLBuilder 测试过滤表达式转换为参数化 SQL，值不会拼接进 SQL
+func TestSQLBuilder(t *testing.T) {
+	now := time.Date(2025, 3, 10, 15, 30, 0, 0, time.UTC)
+	node, err := filter.Parse(`done:false AND (tag:backend OR priority>=high) AND due<now+7d AND title:"50%_off" AND NOT due:none`, now)
+	assert.NoError(t, err)
+
+	b := &sqlBuilder{args: []interface{}{1}}
+	where, err := b.build(node)
+	assert.NoError(t, err)
+	assert.Equal(t, "((((done = $2 AND ($3 = ANY(tags) OR priority >= $4)) AND due_at < $5) AND title ILIKE $6) AND NOT (due_at IS NULL))", where)
+	assert.Equal(t, []interface{}{1, false, "backend", 3, now.AddDate(0, 0, 7), `%50\%\_off%`}, b.args)
+}
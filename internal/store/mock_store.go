@@ -2,6 +2,8 @@ package store
 
 import (
 	"context"
+	"time"
+
 	"github.com/HywlEch/Todo_list/internal/models"
 	"github.com/stretchr/testify/mock"
 )
@@ -28,6 +30,21 @@ func (m *MockStore) GetUserByUsername(ctx context.Context, username string) (*mo
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+// GetUserByID 的模拟实现
+func (m *MockStore) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+// UpdateUserProfile 的模拟实现
+func (m *MockStore) UpdateUserProfile(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
 //模拟CreateTask实现
 func (m *MockStore) CreateTask(ctx context.Context, task *models.Task) error {
 	args := m.Called(ctx, task)
@@ -40,6 +57,15 @@ func (m *MockStore) GetTasks(ctx context.Context, userID int) ([]models.Task, er
 	return args.Get(0).([]models.Task), args.Error(1)
 }
 
+// QueryTasks 的模拟实现
+func (m *MockStore) QueryTasks(ctx context.Context, userID int, q TaskQuery) ([]models.Task, error) {
+	args := m.Called(ctx, userID, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Task), args.Error(1)
+}
+
 // GetTaskByID 的模拟实现
 func (m *MockStore) GetTaskByID(ctx context.Context,id int, userid int) (*models.Task, error) {
 	args := m.Called(ctx, id, userid)
@@ -55,8 +81,302 @@ func (m *MockStore) UpdateTask(ctx context.Context, task *models.Task) error {
 	return args.Error(0)
 }
 
+// PatchTask 的模拟实现
+func (m *MockStore) PatchTask(ctx context.Context, task *models.Task, fields []string) error {
+	args := m.Called(ctx, task, fields)
+	return args.Error(0)
+}
+
 // DeleteTask 是模拟实现
-func (m *MockStore) DeleteTask(ctx context.Context, id int, userID int) error {
+func (m *MockStore) DeleteTask(ctx context.Context, id int, userID int, version int) error {
+	args := m.Called(ctx, id, userID, version)
+	return args.Error(0)
+}
+
+// CreateComment 的模拟实现
+func (m *MockStore) CreateComment(ctx context.Context, comment *models.Comment) error {
+	args := m.Called(ctx, comment)
+	return args.Error(0)
+}
+
+// GetComments 的模拟实现
+func (m *MockStore) GetComments(ctx context.Context, taskID int, opts ListOptions) ([]models.Comment, int, error) {
+	args := m.Called(ctx, taskID, opts)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]models.Comment), args.Int(1), args.Error(2)
+}
+
+// UpdateComment 的模拟实现
+func (m *MockStore) UpdateComment(ctx context.Context, comment *models.Comment) error {
+	args := m.Called(ctx, comment)
+	return args.Error(0)
+}
+
+// DeleteComment 的模拟实现
+func (m *MockStore) DeleteComment(ctx context.Context, id int, taskID int, userID int) error {
+	args := m.Called(ctx, id, taskID, userID)
+	return args.Error(0)
+}
+
+// GetActivityFeed 的模拟实现
+func (m *MockStore) GetActivityFeed(ctx context.Context, taskID int, opts ListOptions) ([]models.FeedItem, int, error) {
+	args := m.Called(ctx, taskID, opts)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]models.FeedItem), args.Int(1), args.Error(2)
+}
+
+// GetTaskRevisions 的模拟实现
+func (m *MockStore) GetTaskRevisions(ctx context.Context, taskID int, opts ListOptions) ([]models.TaskRevision, int, error) {
+	args := m.Called(ctx, taskID, opts)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]models.TaskRevision), args.Int(1), args.Error(2)
+}
+
+// GetTaskRevision 的模拟实现
+func (m *MockStore) GetTaskRevision(ctx context.Context, taskID int, revision int) (*models.TaskRevision, error) {
+	args := m.Called(ctx, taskID, revision)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TaskRevision), args.Error(1)
+}
+
+// RestoreTaskRevision 的模拟实现
+func (m *MockStore) RestoreTaskRevision(ctx context.Context, task *models.Task, revision int) error {
+	args := m.Called(ctx, task, revision)
+	return args.Error(0)
+}
+
+// BulkApply 的模拟实现
+func (m *MockStore) BulkApply(ctx context.Context, userID int, ops []models.BulkOperation, atomic bool) ([]models.BulkResult, error) {
+	args := m.Called(ctx, userID, ops, atomic)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.BulkResult), args.Error(1)
+}
+
+// SearchTasks 的模拟实现
+func (m *MockStore) SearchTasks(ctx context.Context, userID int, q string, opts ListOptions) ([]models.SearchResult, int, error) {
+	args := m.Called(ctx, userID, q, opts)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]models.SearchResult), args.Int(1), args.Error(2)
+}
+
+// MoveTask 的模拟实现
+func (m *MockStore) MoveTask(ctx context.Context, id int, userID int, version int, beforeID int, afterID int) (*models.Task, error) {
+	args := m.Called(ctx, id, userID, version, beforeID, afterID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+// RebalancePositions 的模拟实现
+func (m *MockStore) RebalancePositions(ctx context.Context, maxKeyLength int) (map[int][]int, error) {
+	args := m.Called(ctx, maxKeyLength)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int][]int), args.Error(1)
+}
+
+// GetTrash 的模拟实现
+func (m *MockStore) GetTrash(ctx context.Context, userID int) ([]models.Task, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Task), args.Error(1)
+}
+
+// RestoreTask 的模拟实现
+func (m *MockStore) RestoreTask(ctx context.Context, id int, userID int) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+// PurgeTask 的模拟实现
+func (m *MockStore) PurgeTask(ctx context.Context, id int, userID int) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+// PurgeTrash 的模拟实现
+func (m *MockStore) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// AddDependency 的模拟实现
+func (m *MockStore) AddDependency(ctx context.Context, userID int, blockerID int, blockedID int) (*models.Dependency, error) {
+	args := m.Called(ctx, userID, blockerID, blockedID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Dependency), args.Error(1)
+}
+
+// RemoveDependency 的模拟实现
+func (m *MockStore) RemoveDependency(ctx context.Context, userID int, taskID int, otherID int) error {
+	args := m.Called(ctx, userID, taskID, otherID)
+	return args.Error(0)
+}
+
+// GetDependencyGraph 的模拟实现
+func (m *MockStore) GetDependencyGraph(ctx context.Context, taskID int, userID int) (*models.DependencyGraph, error) {
+	args := m.Called(ctx, taskID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DependencyGraph), args.Error(1)
+}
+
+// GetDependents 的模拟实现
+func (m *MockStore) GetDependents(ctx context.Context, taskID int, userID int) ([]int, error) {
+	args := m.Called(ctx, taskID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int), args.Error(1)
+}
+
+// StartTimer 的模拟实现
+func (m *MockStore) StartTimer(ctx context.Context, entry *models.TimeEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+// StopTimer 的模拟实现
+func (m *MockStore) StopTimer(ctx context.Context, userID int, note *string) (*models.TimeEntry, error) {
+	args := m.Called(ctx, userID, note)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TimeEntry), args.Error(1)
+}
+
+// GetRunningTimer 的模拟实现
+func (m *MockStore) GetRunningTimer(ctx context.Context, userID int) (*models.TimeEntry, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TimeEntry), args.Error(1)
+}
+
+// GetTimeEntries 的模拟实现
+func (m *MockStore) GetTimeEntries(ctx context.Context, userID int, q TimeEntryQuery, opts ListOptions) ([]models.TimeEntry, int, error) {
+	args := m.Called(ctx, userID, q, opts)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]models.TimeEntry), args.Int(1), args.Error(2)
+}
+
+// GetTimeReport 的模拟实现
+func (m *MockStore) GetTimeReport(ctx context.Context, userID int, from, to time.Time, groupBy string, tz string) ([]models.TimeReportRow, error) {
+	args := m.Called(ctx, userID, from, to, groupBy, tz)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TimeReportRow), args.Error(1)
+}
+
+// GetStats 的模拟实现
+func (m *MockStore) GetStats(ctx context.Context, userID int, q StatsQuery) (*models.Stats, error) {
+	args := m.Called(ctx, userID, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Stats), args.Error(1)
+}
+
+// CreateProject 的模拟实现
+func (m *MockStore) CreateProject(ctx context.Context, project *models.Project) error {
+	args := m.Called(ctx, project)
+	return args.Error(0)
+}
+
+// GetProjects 的模拟实现
+func (m *MockStore) GetProjects(ctx context.Context, userID int) ([]models.Project, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Project), args.Error(1)
+}
+
+// GetProject 的模拟实现
+func (m *MockStore) GetProject(ctx context.Context, id int, userID int) (*models.Project, error) {
 	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Project), args.Error(1)
+}
+
+// UpdateProject 的模拟实现
+func (m *MockStore) UpdateProject(ctx context.Context, project *models.Project) error {
+	args := m.Called(ctx, project)
 	return args.Error(0)
-}
\ No newline at end of file
+}
+
+// DeleteProject 的模拟实现
+func (m *MockStore) DeleteProject(ctx context.Context, id int, userID int) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+// GetProjectTasks 的模拟实现
+func (m *MockStore) GetProjectTasks(ctx context.Context, projectID int, userID int) ([]models.Task, error) {
+	args := m.Called(ctx, projectID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Task), args.Error(1)
+}
+
+// CreateView 的模拟实现
+func (m *MockStore) CreateView(ctx context.Context, view *models.SavedView) error {
+	args := m.Called(ctx, view)
+	return args.Error(0)
+}
+
+// GetViews 的模拟实现
+func (m *MockStore) GetViews(ctx context.Context, userID int) ([]models.SavedView, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SavedView), args.Error(1)
+}
+
+// GetView 的模拟实现
+func (m *MockStore) GetView(ctx context.Context, id int, userID int) (*models.SavedView, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SavedView), args.Error(1)
+}
+
+// UpdateView 的模拟实现
+func (m *MockStore) UpdateView(ctx context.Context, view *models.SavedView) error {
+	args := m.Called(ctx, view)
+	return args.Error(0)
+}
+
+// DeleteView 的模拟实现
+func (m *MockStore) DeleteView(ctx context.Context, id int, userID int) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// errBulkAborted 全有或全无模式下某个操作失败，整个事务回滚
+var errBulkAborted = errors.New("store: bulk operation aborted")
+
+// BulkApply 在同一个事务中执行一批任务操作
+// atomic 为 true 时任一操作失败都会回滚全部操作；否则每个操作使用独立的 savepoint，
+// 失败的操作只回滚自己，结果中的 Err 记录失败原因
+func (s *PostgresStore) BulkApply(ctx context.Context, userID int, ops []models.BulkOperation, atomic bool) ([]models.BulkResult, error) {
+	results := make([]models.BulkResult, len(ops))
+	err := s.withTx(ctx, func(tx *sqlx.Tx) error {
+		for i, op := range ops {
+			results[i] = models.BulkResult{Index: i, Op: op.Op, ID: op.ID}
+			if !atomic {
+				if _, err := tx.ExecContext(ctx, `SAVEPOINT bulk_item;`); err != nil {
+					return fmt.Errorf("store: failed to create savepoint: %w", err)
+				}
+			}
+
+			task, err := applyBulkOperation(ctx, tx, userID, op)
+			if err != nil {
+				results[i].Err = err
+				if atomic {
+					return errBulkAborted
+				}
+				if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT bulk_item;`); err != nil {
+					return fmt.Errorf("store: failed to rollback savepoint: %w", err)
+				}
+				continue
+			}
+			results[i].Task = task
+			if task != nil {
+				results[i].ID = task.ID
+			}
+			if !atomic {
+				if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT bulk_item;`); err != nil {
+					return fmt.Errorf("store: failed to release savepoint: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+	if err == errBulkAborted {
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// applyBulkOperation 执行单个操作，删除操作返回的任务为 nil
+func applyBulkOperation(ctx context.Context, tx *sqlx.Tx, userID int, op models.BulkOperation) (*models.Task, error) {
+	task := &models.Task{ID: op.ID, UserID: userID, Version: op.Version}
+	switch op.Op {
+	case models.BulkOpCreate:
+		setBulkFields(task, op)
+		return task, createTask(ctx, tx, task)
+	case models.BulkOpUpdate:
+		return task, patchTask(ctx, tx, task, setBulkFields(task, op))
+	case models.BulkOpComplete:
+		task.Done = true
+		return task, patchTask(ctx, tx, task, []string{"done"})
+	case models.BulkOpDelete:
+		return nil, deleteTask(ctx, tx, op.ID, userID, op.Version)
+	case models.BulkOpMove:
+		return moveTask(ctx, tx, op.ID, userID, op.Version, op.BeforeID, op.AfterID)
+	}
+	return nil, fmt.Errorf("store: unknown bulk operation %q", op.Op)
+}
+
+// setBulkFields 把操作中提供的字段写入 task，返回被设置的字段名
+func setBulkFields(task *models.Task, op models.BulkOperation) []string {
+	var fields []string
+	if op.Title != nil {
+		task.Title = *op.Title
+		fields = append(fields, "title")
+	}
+	if op.Content != nil {
+		task.Content = *op.Content
+		fields = append(fields, "content")
+	}
+	if op.Done != nil {
+		task.Done = *op.Done
+		fields = append(fields, "done")
+	}
+	if op.DueAt.Set {
+		task.DueAt = op.DueAt.Value
+		fields = append(fields, "due_at")
+	}
+	if op.Priority != nil {
+		task.Priority = *op.Priority
+		fields = append(fields, "priority")
+	}
+	if op.Tags != nil {
+		task.Tags = *op.Tags
+		fields = append(fields, "tags")
+	}
+	if op.ProjectID.Set {
+		task.ProjectID = op.ProjectID.Value
+		fields = append(fields, "project_id")
+	}
+	if op.Status != nil {
+		task.Status = *op.Status
+		fields = append(fields, "status")
+	}
+	return fields
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockPostgresStore(t *testing.T) (*PostgresStore, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return &PostgresStore{DB: sqlx.NewDb(db, "postgres")}, mock
+}
+
+// expectDelete 第一个任务删除成功，第二个任务不存在
+func expectDelete(mock sqlmock.Sqlmock, id int, exists bool) {
+	q := mock.ExpectQuery(`SELECT version FROM tasks WHERE id = \$1 AND user_id = \$2 AND deleted_at IS NULL FOR UPDATE`).WithArgs(id, 1)
+	if !exists {
+		q.WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		return
+	}
+	q.WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+	mock.ExpectExec(`UPDATE tasks SET deleted_at = NOW\(\)`).WithArgs(id, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO task_activities`).WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// TestBulkApply_AtomicRollsBack 全有或全无模式下中间的操作失败时回滚整个事务
+func TestBulkApply_AtomicRollsBack(t *testing.T) {
+	s, mock := newMockPostgresStore(t)
+	mock.ExpectBegin()
+	expectDelete(mock, 1, true)
+	expectDelete(mock, 2, false)
+	mock.ExpectRollback()
+
+	ops := []models.BulkOperation{{Op: models.BulkOpDelete, ID: 1}, {Op: models.BulkOpDelete, ID: 2}, {Op: models.BulkOpDelete, ID: 3}}
+	results, err := s.BulkApply(context.Background(), 1, ops, true)
+	require.NoError(t, err)
+	assert.NoError(t, results[0].Err)
+	assert.ErrorIs(t, results[1].Err, ErrNotFound)
+	assert.Zero(t, results[2].Op, "失败之后的操作不再执行")
+	assert.NoError(t, mock.ExpectationsWereMet(), "没有提交事务")
+}
+
+// TestBulkApply_PerItem 逐条模式下失败的操作只回滚到自己的 savepoint，其余操作照常提交
+func TestBulkApply_PerItem(t *testing.T) {
+	s, mock := newMockPostgresStore(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT bulk_item`).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectDelete(mock, 1, true)
+	mock.ExpectExec(`RELEASE SAVEPOINT bulk_item`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SAVEPOINT bulk_item`).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectDelete(mock, 2, false)
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT bulk_item`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SAVEPOINT bulk_item`).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectDelete(mock, 3, true)
+	mock.ExpectExec(`RELEASE SAVEPOINT bulk_item`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	ops := []models.BulkOperation{{Op: models.BulkOpDelete, ID: 1}, {Op: models.BulkOpDelete, ID: 2}, {Op: models.BulkOpDelete, ID: 3}}
+	results, err := s.BulkApply(context.Background(), 1, ops, false)
+	require.NoError(t, err)
+	assert.NoError(t, results[0].Err)
+	assert.ErrorIs(t, results[1].Err, ErrNotFound)
+	assert.NoError(t, results[2].Err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestSetBulkFields_Null 测试 update 操作中没有 due_at 时不修改，为 null 时清空，project_id 同理
+func TestSetBulkFields_Null(t *testing.T) {
+	due := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	projectID := 7
+	tests := []struct {
+		name      string
+		body      string
+		fields    []string
+		dueAt     *time.Time
+		projectID *int
+	}{
+		{"没有这两个字段", `{"op":"update","id":3,"title":"x"}`, []string{"title"}, &due, &projectID},
+		{"值为 null", `{"op":"update","id":3,"due_at":null,"project_id":null}`, []string{"due_at", "project_id"}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var op models.BulkOperation
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &op))
+			task := &models.Task{ID: 3, DueAt: &due, ProjectID: &projectID}
+			assert.Equal(t, tt.fields, setBulkFields(task, op))
+			assert.Equal(t, tt.dueAt, task.DueAt)
+			assert.Equal(t, tt.projectID, task.ProjectID)
+		})
+	}
+}
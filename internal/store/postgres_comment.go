@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+func (s *PostgresStore) CreateComment(ctx context.Context, comment *models.Comment) error {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("创建评论失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO task_comments (task_id, user_id, content) VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at, (SELECT username FROM users WHERE id = $2);`
+	err = tx.QueryRowxContext(ctx, query, comment.TaskID, comment.UserID, comment.Content).
+		Scan(&comment.ID, &comment.CreatedAt, &comment.UpdatedAt, &comment.Username)
+	if err != nil {
+		return fmt.Errorf("创建评论失败: %w", err)
+	}
+	if err := saveMentions(ctx, tx, comment); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresStore) GetComments(ctx context.Context, taskID int, opts ListOptions) ([]models.Comment, int, error) {
+	var total int
+	if err := s.DB.GetContext(ctx, &total, `SELECT COUNT(*) FROM task_comments WHERE task_id = $1;`, taskID); err != nil {
+		return nil, 0, fmt.Errorf("store: failed to count comments: %w", err)
+	}
+
+	query := `SELECT c.id, c.task_id, c.user_id, u.username, c.content, c.created_at, c.updated_at
+		FROM task_comments c JOIN users u ON u.id = c.user_id
+		WHERE c.task_id = $1 ORDER BY c.created_at DESC, c.id DESC LIMIT $2 OFFSET $3;`
+	comments := []models.Comment{}
+	if err := s.DB.SelectContext(ctx, &comments, query, taskID, opts.Limit, opts.Offset); err != nil {
+		return nil, 0, fmt.Errorf("store: failed to get comments: %w", err)
+	}
+	if len(comments) == 0 {
+		return comments, total, nil
+	}
+
+	// 一次性加载本页所有评论的@用户
+	ids := make([]int64, len(comments))
+	index := make(map[int]int, len(comments))
+	for i, c := range comments {
+		ids[i] = int64(c.ID)
+		index[c.ID] = i
+		comments[i].Mentions = []models.Mention{}
+	}
+	var rows []struct {
+		CommentID int `db:"comment_id"`
+		models.Mention
+	}
+	mentionQuery := `SELECT m.comment_id, u.id AS user_id, u.username
+		FROM comment_mentions m JOIN users u ON u.id = m.user_id
+		WHERE m.comment_id = ANY($1) ORDER BY u.username;`
+	if err := s.DB.SelectContext(ctx, &rows, mentionQuery, pq.Array(ids)); err != nil {
+		return nil, 0, fmt.Errorf("store: failed to get mentions: %w", err)
+	}
+	for _, r := range rows {
+		i := index[r.CommentID]
+		comments[i].Mentions = append(comments[i].Mentions, r.Mention)
+	}
+	return comments, total, nil
+}
+
+func (s *PostgresStore) UpdateComment(ctx context.Context, comment *models.Comment) error {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("更新评论失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 只有评论作者本人可以修改
+	query := `UPDATE task_comments SET content = $1, updated_at = NOW()
+		WHERE id = $2 AND task_id = $3 AND user_id = $4
+		RETURNING created_at, updated_at, (SELECT username FROM users WHERE id = $4);`
+	err = tx.QueryRowxContext(ctx, query, comment.Content, comment.ID, comment.TaskID, comment.UserID).
+		Scan(&comment.CreatedAt, &comment.UpdatedAt, &comment.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		return fmt.Errorf("更新评论失败 %d: %w", comment.ID, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM comment_mentions WHERE comment_id = $1;`, comment.ID); err != nil {
+		return fmt.Errorf("更新评论失败 %d: %w", comment.ID, err)
+	}
+	if err := saveMentions(ctx, tx, comment); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresStore) DeleteComment(ctx context.Context, id int, taskID int, userID int) error {
+	query := `DELETE FROM task_comments WHERE id = $1 AND task_id = $2 AND user_id = $3;`
+	res, err := s.DB.ExecContext(ctx, query, id, taskID, userID)
+	if err != nil {
+		return fmt.Errorf("删除评论失败 %d: %w", id, err)
+	}
+	rowsAffected, _ := res.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetActivityFeed(ctx context.Context, taskID int, opts ListOptions) ([]models.FeedItem, int, error) {
+	var total int
+	countQuery := `SELECT (SELECT COUNT(*) FROM task_comments WHERE task_id = $1) + (SELECT COUNT(*) FROM task_activities WHERE task_id = $1);`
+	if err := s.DB.GetContext(ctx, &total, countQuery, taskID); err != nil {
+		return nil, 0, fmt.Errorf("store: failed to count activity: %w", err)
+	}
+
+	query := `SELECT * FROM (
+			SELECT 'comment' AS kind, c.id, c.user_id, u.username, c.content,
+				NULL::text AS action, NULL::text AS old_value, NULL::text AS new_value, c.created_at
+			FROM task_comments c JOIN users u ON u.id = c.user_id WHERE c.task_id = $1
+			UNION ALL
+			SELECT 'event' AS kind, a.id, a.user_id, u.username, NULL::text AS content,
+				a.action, a.old_value, a.new_value, a.created_at
+			FROM task_activities a JOIN users u ON u.id = a.user_id WHERE a.task_id = $1
+		) feed ORDER BY created_at DESC, kind, id DESC LIMIT $2 OFFSET $3;`
+	items := []models.FeedItem{}
+	if err := s.DB.SelectContext(ctx, &items, query, taskID, opts.Limit, opts.Offset); err != nil {
+		return nil, 0, fmt.Errorf("store: failed to get activity: %w", err)
+	}
+	return items, total, nil
+}
+
+// saveMentions 把评论中@的用户名解析为用户并保存，不存在的用户名会被忽略
+func saveMentions(ctx context.Context, tx *sqlx.Tx, comment *models.Comment) error {
+	usernames := make([]string, 0, len(comment.Mentions))
+	for _, m := range comment.Mentions {
+		usernames = append(usernames, m.Username)
+	}
+	comment.Mentions = []models.Mention{}
+	if len(usernames) == 0 {
+		return nil
+	}
+
+	query := `SELECT id AS user_id, username FROM users WHERE username = ANY($1) ORDER BY username;`
+	if err := tx.SelectContext(ctx, &comment.Mentions, query, pq.Array(usernames)); err != nil {
+		return fmt.Errorf("store: failed to resolve mentions: %w", err)
+	}
+	for _, m := range comment.Mentions {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO comment_mentions (comment_id, user_id) VALUES ($1, $2);`, comment.ID, m.UserID); err != nil {
+			return fmt.Errorf("store: failed to save mention: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateComment_Mentions 测试@到的用户名解析为用户并保存，不存在的用户名被忽略
+func TestCreateComment_Mentions(t *testing.T) {
+	s, mock := newMockPostgresStore(t)
+	now := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO task_comments`).WithArgs(5, 1, "hi @bob @ghost").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at", "username"}).AddRow(11, now, now, "alice"))
+	mock.ExpectQuery(`SELECT id AS user_id, username FROM users WHERE username = ANY\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "username"}).AddRow(2, "bob"))
+	mock.ExpectExec(`INSERT INTO comment_mentions`).WithArgs(11, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	comment := &models.Comment{TaskID: 5, UserID: 1, Content: "hi @bob @ghost",
+		Mentions: []models.Mention{{Username: "bob"}, {Username: "ghost"}}}
+	require.NoError(t, s.CreateComment(context.Background(), comment))
+	assert.Equal(t, 11, comment.ID)
+	assert.Equal(t, "alice", comment.Username)
+	assert.Equal(t, []models.Mention{{UserID: 2, Username: "bob"}}, comment.Mentions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestUpdateComment_NotAuthor 测试只有作者能修改评论，其他人修改时返回 ErrNotFound 并回滚
+func TestUpdateComment_NotAuthor(t *testing.T) {
+	s, mock := newMockPostgresStore(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE task_comments SET content = \$1, updated_at = NOW\(\)\s+WHERE id = \$2 AND task_id = \$3 AND user_id = \$4`).
+		WithArgs("edited", 11, 5, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at", "username"}))
+	mock.ExpectRollback()
+
+	err := s.UpdateComment(context.Background(), &models.Comment{ID: 11, TaskID: 5, UserID: 2, Content: "edited"})
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
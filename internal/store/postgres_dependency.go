@@ -0,0 +1,150 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// AddDependency 让 blockedID 依赖 blockerID，会形成循环时返回 ErrDependencyCycle
+func (s *PostgresStore) AddDependency(ctx context.Context, userID int, blockerID int, blockedID int) (*models.Dependency, error) {
+	if blockerID == blockedID {
+		return nil, ErrDependencyCycle
+	}
+	dep := &models.Dependency{BlockerID: blockerID, BlockedID: blockedID}
+	err := s.withTx(ctx, func(tx *sqlx.Tx) error {
+		//同一用户的依赖修改串行执行，避免两个并发的请求各自通过检查后形成循环
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('task_dependencies'), $1);`, userID); err != nil {
+			return fmt.Errorf("store: failed to lock dependencies: %w", err)
+		}
+		var count int
+		query := `SELECT COUNT(*) FROM tasks WHERE id IN ($1, $2) AND user_id = $3 AND deleted_at IS NULL;`
+		if err := tx.GetContext(ctx, &count, query, blockerID, blockedID, userID); err != nil {
+			return fmt.Errorf("store: failed to check tasks: %w", err)
+		}
+		if count != 2 {
+			return ErrNotFound
+		}
+
+		//blocker 已经（直接或间接）依赖 blocked 时，新的依赖会形成循环
+		var cycle bool
+		query = `WITH RECURSIVE downstream(id) AS (
+				SELECT $1::int
+				UNION
+				SELECT d.blocked_id FROM task_dependencies d JOIN downstream ds ON d.blocker_id = ds.id
+			)
+			SELECT EXISTS (SELECT 1 FROM downstream WHERE id = $2);`
+		if err := tx.GetContext(ctx, &cycle, query, blockedID, blockerID); err != nil {
+			return fmt.Errorf("store: failed to check dependency cycle: %w", err)
+		}
+		if cycle {
+			return ErrDependencyCycle
+		}
+
+		query = `INSERT INTO task_dependencies (blocker_id, blocked_id) VALUES ($1, $2)
+			ON CONFLICT (blocker_id, blocked_id) DO UPDATE SET blocker_id = EXCLUDED.blocker_id
+			RETURNING created_at;`
+		if err := tx.GetContext(ctx, &dep.CreatedAt, query, blockerID, blockedID); err != nil {
+			return fmt.Errorf("store: failed to add dependency: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dep, nil
+}
+
+// RemoveDependency 删除两个任务之间的依赖，不区分方向
+func (s *PostgresStore) RemoveDependency(ctx context.Context, userID int, taskID int, otherID int) error {
+	query := `DELETE FROM task_dependencies d USING tasks t
+		WHERE t.id = d.blocked_id AND t.user_id = $3
+		AND ((d.blocker_id = $1 AND d.blocked_id = $2) OR (d.blocker_id = $2 AND d.blocked_id = $1));`
+	res, err := s.DB.ExecContext(ctx, query, taskID, otherID, userID)
+	if err != nil {
+		return fmt.Errorf("store: failed to remove dependency: %w", err)
+	}
+	rowsAffected, _ := res.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetDependencyGraph 返回任务的传递依赖图，回收站中的任务不出现在图中
+func (s *PostgresStore) GetDependencyGraph(ctx context.Context, taskID int, userID int) (*models.DependencyGraph, error) {
+	if _, err := s.GetTaskByID(ctx, taskID, userID); err != nil {
+		return nil, err
+	}
+	var ids pq.Int64Array
+	query := `WITH RECURSIVE upstream(id) AS (
+			SELECT $1::int
+			UNION
+			SELECT d.blocker_id FROM task_dependencies d
+			JOIN upstream u ON d.blocked_id = u.id
+			JOIN tasks t ON t.id = d.blocker_id AND t.deleted_at IS NULL
+		), downstream(id) AS (
+			SELECT $1::int
+			UNION
+			SELECT d.blocked_id FROM task_dependencies d
+			JOIN downstream ds ON d.blocker_id = ds.id
+			JOIN tasks t ON t.id = d.blocked_id AND t.deleted_at IS NULL
+		)
+		SELECT ARRAY(SELECT id FROM upstream UNION SELECT id FROM downstream);`
+	if err := s.DB.GetContext(ctx, &ids, query, taskID); err != nil {
+		return nil, fmt.Errorf("store: failed to get dependency graph of task %d: %w", taskID, err)
+	}
+
+	graph := &models.DependencyGraph{TaskID: taskID, Nodes: []models.Task{}, Edges: []models.Dependency{}}
+	query = `SELECT ` + taskColumns + ` FROM tasks WHERE id = ANY($1) AND user_id = $2 ORDER BY id;`
+	if err := s.DB.SelectContext(ctx, &graph.Nodes, query, ids, userID); err != nil {
+		return nil, fmt.Errorf("store: failed to get dependency graph of task %d: %w", taskID, err)
+	}
+	query = `SELECT blocker_id, blocked_id, created_at FROM task_dependencies
+		WHERE blocker_id = ANY($1) AND blocked_id = ANY($1) ORDER BY blocker_id, blocked_id;`
+	if err := s.DB.SelectContext(ctx, &graph.Edges, query, ids); err != nil {
+		return nil, fmt.Errorf("store: failed to get dependency graph of task %d: %w", taskID, err)
+	}
+	return graph, nil
+}
+
+// GetDependents 返回直接被 taskID 阻塞的任务ID，taskID 本身在回收站中也会返回
+func (s *PostgresStore) GetDependents(ctx context.Context, taskID int, userID int) ([]int, error) {
+	ids := []int{}
+	query := `SELECT d.blocked_id FROM task_dependencies d JOIN tasks t ON t.id = d.blocked_id
+		WHERE d.blocker_id = $1 AND t.user_id = $2 ORDER BY d.blocked_id;`
+	if err := s.DB.SelectContext(ctx, &ids, query, taskID, userID); err != nil {
+		return nil, fmt.Errorf("store: failed to get dependents of task %d: %w", taskID, err)
+	}
+	return ids, nil
+}
+
+// unblockTasks 在 blocker 完成后找出不再被阻塞的任务，并在这些任务的动态中记录通知
+func unblockTasks(ctx context.Context, tx *sqlx.Tx, blocker *models.Task) ([]int, error) {
+	var ids []int
+	query := `SELECT d.blocked_id FROM task_dependencies d JOIN tasks t ON t.id = d.blocked_id
+		WHERE d.blocker_id = $1 AND t.deleted_at IS NULL AND NOT t.done AND NOT task_is_blocked(t.id)
+		ORDER BY d.blocked_id;`
+	if err := tx.SelectContext(ctx, &ids, query, blocker.ID); err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("store: failed to find unblocked tasks: %w", err)
+	}
+	blockerID := strconv.Itoa(blocker.ID)
+	events := make([]models.ActivityEvent, 0, len(ids))
+	for _, id := range ids {
+		events = append(events, models.ActivityEvent{
+			TaskID:   id,
+			UserID:   blocker.UserID,
+			Action:   models.ActivityTaskUnblocked,
+			NewValue: &blockerID,
+		})
+	}
+	if err := insertActivities(ctx, tx, events); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+)
+
+// QueryTasks 按过滤条件和排序方式查询用户的任务
+func (s *PostgresStore) QueryTasks(ctx context.Context, userID int, q TaskQuery) ([]models.Task, error) {
+	b := &sqlBuilder{args: []interface{}{userID}}
+	where := "user_id = $1 AND deleted_at IS NULL"
+	if q.Filter != nil {
+		cond, err := b.build(q.Filter)
+		if err != nil {
+			return nil, err
+		}
+		where += " AND " + cond
+	}
+	orderBy := SortOrders["manual"]
+	if q.Sort != "" {
+		var ok bool
+		if orderBy, ok = SortOrders[q.Sort]; !ok {
+			return nil, fmt.Errorf("store: unknown sort order %q", q.Sort)
+		}
+	}
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE ` + where + ` ORDER BY ` + orderBy + `;`
+	tasks := []models.Task{}
+	if err := s.DB.SelectContext(ctx, &tasks, query, b.args...); err != nil {
+		return nil, fmt.Errorf("store: failed to query tasks: %w", err)
+	}
+	return tasks, nil
+}
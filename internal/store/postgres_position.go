@@ -0,0 +1,215 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/rank"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// firstPosition 返回排在用户所有任务之前的排序键
+// 现有的键不合法时返回 nil，等待后台重新平衡
+func firstPosition(ctx context.Context, tx *sqlx.Tx, userID int) (*string, error) {
+	var first sql.NullString
+	query := `SELECT MIN(position) FROM tasks WHERE user_id = $1 AND deleted_at IS NULL;`
+	if err := tx.GetContext(ctx, &first, query, userID); err != nil {
+		return nil, fmt.Errorf("store: failed to get first position: %w", err)
+	}
+	key, err := rank.Between("", first.String)
+	if err != nil {
+		return nil, nil
+	}
+	return &key, nil
+}
+
+// MoveTask 把任务移动到 afterID 之后或 beforeID 之前，两者同时提供时放在它们之间
+// version 不为0时只有版本一致才会移动
+func (s *PostgresStore) MoveTask(ctx context.Context, id int, userID int, version int, beforeID int, afterID int) (*models.Task, error) {
+	var task *models.Task
+	err := s.withTx(ctx, func(tx *sqlx.Tx) error {
+		var err error
+		task, err = moveTask(ctx, tx, id, userID, version, beforeID, afterID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// moveTask 在事务中移动任务，只更新被移动任务的排序键
+// 需要先重新平衡时，涉及的任务ID记在返回的 Rebalanced 中
+func moveTask(ctx context.Context, tx *sqlx.Tx, id, userID, version, beforeID, afterID int) (*models.Task, error) {
+	//移动可能需要重新平衡整个列表，先按固定的顺序锁住列表，再读取被移动的任务
+	if err := lockList(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	var current int
+	err := tx.GetContext(ctx, &current, `SELECT version FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL;`, id, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("store: failed to lock task %d: %w", id, err)
+	}
+	if version != 0 && version != current {
+		return nil, ErrVersionConflict
+	}
+
+	var unranked int
+	if err := tx.GetContext(ctx, &unranked, `SELECT COUNT(*) FROM tasks WHERE user_id = $1 AND deleted_at IS NULL AND position IS NULL;`, userID); err != nil {
+		return nil, fmt.Errorf("store: failed to count unranked tasks: %w", err)
+	}
+	var rebalanced []int
+	if unranked > 0 {
+		if rebalanced, err = rebalanceList(ctx, tx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := movePosition(ctx, tx, id, userID, beforeID, afterID)
+	if err == errNeedRebalance {
+		//相邻的键重复或已经无法再细分，重新平衡后再试一次
+		if rebalanced, err = rebalanceList(ctx, tx, userID); err != nil {
+			return nil, err
+		}
+		key, err = movePosition(ctx, tx, id, userID, beforeID, afterID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	task := &models.Task{}
+	query := `UPDATE tasks SET position = $1, version = version + 1, updated_at = NOW()
+		WHERE id = $2 RETURNING ` + taskColumns + `;`
+	if err := tx.QueryRowxContext(ctx, query, key, id).StructScan(task); err != nil {
+		return nil, fmt.Errorf("store: failed to move task %d: %w", id, err)
+	}
+	task.Rebalanced = rebalanced
+	return task, nil
+}
+
+// errNeedRebalance 相邻的排序键之间已经没有空间
+var errNeedRebalance = errors.New("store: positions need rebalance")
+
+// movePosition 计算被移动任务的新排序键
+func movePosition(ctx context.Context, tx *sqlx.Tx, id, userID, beforeID, afterID int) (string, error) {
+	var prev, next string
+	var err error
+	if afterID != 0 {
+		if prev, err = neighbourPosition(ctx, tx, id, userID, afterID); err != nil {
+			return "", err
+		}
+	}
+	if beforeID != 0 {
+		if next, err = neighbourPosition(ctx, tx, id, userID, beforeID); err != nil {
+			return "", err
+		}
+	}
+	switch {
+	case afterID != 0 && beforeID == 0:
+		next, err = adjacentPosition(ctx, tx, id, userID, `position > $3 ORDER BY position ASC`, prev)
+	case beforeID != 0 && afterID == 0:
+		prev, err = adjacentPosition(ctx, tx, id, userID, `position < $3 ORDER BY position DESC`, next)
+	case afterID != 0 && beforeID != 0 && prev > next:
+		return "", ErrInvalidMove
+	}
+	if err != nil {
+		return "", err
+	}
+	key, err := rank.Between(prev, next)
+	if err != nil {
+		return "", errNeedRebalance
+	}
+	return key, nil
+}
+
+// neighbourPosition 返回作为参照的相邻任务的排序键
+func neighbourPosition(ctx context.Context, tx *sqlx.Tx, id, userID, neighbourID int) (string, error) {
+	if neighbourID == id {
+		return "", ErrInvalidMove
+	}
+	var position sql.NullString
+	err := tx.GetContext(ctx, &position, `SELECT position FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL;`, neighbourID, userID)
+	if err == sql.ErrNoRows {
+		return "", ErrInvalidMove
+	}
+	if err != nil {
+		return "", fmt.Errorf("store: failed to get position of task %d: %w", neighbourID, err)
+	}
+	return position.String, nil
+}
+
+// adjacentPosition 返回紧挨着 position 的另一个任务的排序键，不存在时返回空字符串
+func adjacentPosition(ctx context.Context, tx *sqlx.Tx, id, userID int, cond string, position string) (string, error) {
+	var adjacent sql.NullString
+	query := `SELECT position FROM tasks WHERE user_id = $1 AND deleted_at IS NULL AND id <> $2 AND ` + cond + ` LIMIT 1;`
+	err := tx.GetContext(ctx, &adjacent, query, userID, id, position)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("store: failed to get adjacent position: %w", err)
+	}
+	return adjacent.String, nil
+}
+
+// lockList 按任务ID的顺序锁住用户列表中的所有任务
+// 移动和重新平衡都先调用它，并发的事务以相同的顺序加锁，不会互相死锁
+func lockList(ctx context.Context, tx *sqlx.Tx, userID int) error {
+	query := `SELECT id FROM tasks WHERE user_id = $1 AND deleted_at IS NULL ORDER BY id FOR UPDATE;`
+	if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("store: failed to lock task list: %w", err)
+	}
+	return nil
+}
+
+// rebalanceList 按当前顺序给用户的所有任务重新分配均匀分布的短排序键，返回涉及的任务ID
+// 调用前必须先用 lockList 锁住列表；排序键变了，所以每个任务的版本号都会增加
+func rebalanceList(ctx context.Context, tx *sqlx.Tx, userID int) ([]int, error) {
+	var ids []int
+	query := `SELECT id FROM tasks WHERE user_id = $1 AND deleted_at IS NULL ORDER BY ` + SortOrders["manual"] + `;`
+	if err := tx.SelectContext(ctx, &ids, query, userID); err != nil {
+		return nil, fmt.Errorf("store: failed to list positions: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	update := `UPDATE tasks t SET position = v.position, version = t.version + 1
+		FROM unnest($1::int[], $2::text[]) AS v(id, position) WHERE t.id = v.id;`
+	if _, err := tx.ExecContext(ctx, update, pq.Array(ids), pq.Array(rank.Spread(len(ids)))); err != nil {
+		return nil, fmt.Errorf("store: failed to rebalance positions: %w", err)
+	}
+	return ids, nil
+}
+
+// RebalancePositions 重新平衡存在空排序键或排序键超过 maxKeyLength 的列表
+// 返回每个被重新平衡的用户及其任务ID
+func (s *PostgresStore) RebalancePositions(ctx context.Context, maxKeyLength int) (map[int][]int, error) {
+	var userIDs []int
+	query := `SELECT user_id FROM tasks WHERE deleted_at IS NULL GROUP BY user_id
+		HAVING bool_or(position IS NULL) OR MAX(length(position)) > $1;`
+	if err := s.DB.SelectContext(ctx, &userIDs, query, maxKeyLength); err != nil {
+		return nil, fmt.Errorf("store: failed to find lists to rebalance: %w", err)
+	}
+	rebalanced := make(map[int][]int, len(userIDs))
+	for _, userID := range userIDs {
+		err := s.withTx(ctx, func(tx *sqlx.Tx) error {
+			if err := lockList(ctx, tx, userID); err != nil {
+				return err
+			}
+			ids, err := rebalanceList(ctx, tx, userID)
+			if err != nil {
+				return err
+			}
+			rebalanced[userID] = ids
+			return nil
+		})
+		if err != nil {
+			return rebalanced, err
+		}
+	}
+	return rebalanced, nil
+}
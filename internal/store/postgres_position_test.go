@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expectLockList 移动前先按ID顺序锁住整个列表，再读取被移动任务的版本
+func expectLockList(mock sqlmock.Sqlmock, version int) {
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT id FROM tasks WHERE user_id = \$1 AND deleted_at IS NULL ORDER BY id FOR UPDATE`).
+		WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery(`SELECT version FROM tasks WHERE id = \$1 AND user_id = \$2 AND deleted_at IS NULL;`).
+		WithArgs(2, 1).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(version))
+}
+
+// TestMoveTask 测试把任务移动到另一个任务之后，新的排序键在两个相邻的键之间
+func TestMoveTask(t *testing.T) {
+	s, mock := newMockPostgresStore(t)
+	expectLockList(mock, 4)
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM tasks WHERE user_id = \$1 AND deleted_at IS NULL AND position IS NULL`).
+		WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT position FROM tasks WHERE id = \$1 AND user_id = \$2`).
+		WithArgs(3, 1).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("a"))
+	mock.ExpectQuery(`position > \$3 ORDER BY position ASC`).
+		WithArgs(1, 2, "a").WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("c"))
+	mock.ExpectQuery(`UPDATE tasks SET position = \$1, version = version \+ 1`).
+		WithArgs("b", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "version", "position"}).AddRow(2, 1, 5, "b"))
+	mock.ExpectCommit()
+
+	task, err := s.MoveTask(context.Background(), 2, 1, 4, 0, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 5, task.Version)
+	assert.Equal(t, "b", *task.Position)
+	assert.Empty(t, task.Rebalanced)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestMoveTask_Rebalance 测试列表中有空排序键时先重新平衡，被重新平衡的任务版本号增加
+func TestMoveTask_Rebalance(t *testing.T) {
+	s, mock := newMockPostgresStore(t)
+	expectLockList(mock, 4)
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM tasks WHERE user_id = \$1 AND deleted_at IS NULL AND position IS NULL`).
+		WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT id FROM tasks WHERE user_id = \$1 AND deleted_at IS NULL ORDER BY .*;$`).
+		WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(2).AddRow(7))
+	mock.ExpectExec(`UPDATE tasks t SET position = v.position, version = t.version \+ 1`).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery(`SELECT position FROM tasks WHERE id = \$1 AND user_id = \$2`).
+		WithArgs(7, 1).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("t"))
+	mock.ExpectQuery(`position < \$3 ORDER BY position DESC`).
+		WithArgs(1, 2, "t").WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("i"))
+	mock.ExpectQuery(`UPDATE tasks SET position = \$1, version = version \+ 1`).
+		WithArgs(sqlmock.AnyArg(), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "version", "position"}).AddRow(2, 1, 6, "o"))
+	mock.ExpectCommit()
+
+	task, err := s.MoveTask(context.Background(), 2, 1, 0, 7, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 2, 7}, task.Rebalanced)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestMoveTask_VersionConflict 测试版本不一致时不移动，事务回滚
+func TestMoveTask_VersionConflict(t *testing.T) {
+	s, mock := newMockPostgresStore(t)
+	expectLockList(mock, 5)
+	mock.ExpectRollback()
+
+	_, err := s.MoveTask(context.Background(), 2, 1, 4, 0, 3)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,231 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const projectColumns = `id, user_id, name, workflow, created_at, updated_at`
+
+func (s *PostgresStore) CreateProject(ctx context.Context, project *models.Project) error {
+	query := `INSERT INTO projects (user_id, name, workflow) VALUES ($1, $2, $3) RETURNING id, created_at, updated_at;`
+	err := s.DB.QueryRowxContext(ctx, query, project.UserID, project.Name, project.Workflow).
+		Scan(&project.ID, &project.CreatedAt, &project.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("创建项目失败: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetProjects(ctx context.Context, userID int) ([]models.Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE user_id = $1 ORDER BY name, id;`
+	projects := []models.Project{}
+	if err := s.DB.SelectContext(ctx, &projects, query, userID); err != nil {
+		return nil, fmt.Errorf("store: failed to get projects: %w", err)
+	}
+	return projects, nil
+}
+
+func (s *PostgresStore) GetProject(ctx context.Context, id int, userID int) (*models.Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE id = $1 AND user_id = $2;`
+	var project models.Project
+	if err := s.DB.GetContext(ctx, &project, query, id, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("store: failed to get project %d: %w", id, err)
+	}
+	return &project, nil
+}
+
+// UpdateProject 修改项目名称和工作流，仍有任务（包括回收站中的）处于被移除或修改了分类的状态时返回 ErrStatusInUse
+// 分类决定任务的 done，原地把状态改成完成类（或反过来）会让其中的任务与 done 不一致
+func (s *PostgresStore) UpdateProject(ctx context.Context, project *models.Project) error {
+	return s.withTx(ctx, func(tx *sqlx.Tx) error {
+		//锁住项目，检查期间其他事务不能把任务移到将被修改的状态
+		var current models.Workflow
+		query := `SELECT workflow FROM projects WHERE id = $1 AND user_id = $2 FOR UPDATE;`
+		if err := tx.GetContext(ctx, &current, query, project.ID, project.UserID); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return fmt.Errorf("store: failed to get workflow of project %d: %w", project.ID, err)
+		}
+		var used []string
+		query = `SELECT DISTINCT status FROM tasks WHERE project_id = $1 AND user_id = $2;`
+		if err := tx.SelectContext(ctx, &used, query, project.ID, project.UserID); err != nil {
+			return fmt.Errorf("store: failed to get statuses in use: %w", err)
+		}
+		for _, status := range used {
+			next, ok := project.Workflow.Status(status)
+			if !ok {
+				return ErrStatusInUse
+			}
+			if prev, ok := current.Status(status); ok && prev.Category != next.Category {
+				return ErrStatusInUse
+			}
+		}
+		query = `UPDATE projects SET name = $1, workflow = $2, updated_at = NOW()
+			WHERE id = $3 AND user_id = $4 RETURNING created_at, updated_at;`
+		err := tx.QueryRowxContext(ctx, query, project.Name, project.Workflow, project.ID, project.UserID).
+			Scan(&project.CreatedAt, &project.UpdatedAt)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return fmt.Errorf("更新项目失败 %d: %w", project.ID, err)
+		}
+		return nil
+	})
+}
+
+// DeleteProject 删除项目，项目中的任务移出项目并回到默认工作流
+func (s *PostgresStore) DeleteProject(ctx context.Context, id int, userID int) error {
+	return s.withTx(ctx, func(tx *sqlx.Tx) error {
+		//先把任务移出项目，外键的 ON DELETE SET NULL 不会修改状态
+		defaults := models.DefaultWorkflow()
+		query := `UPDATE tasks SET project_id = NULL, version = version + 1, updated_at = NOW(),
+			status = CASE WHEN done THEN $1 ELSE $2 END WHERE project_id = $3 AND user_id = $4;`
+		if _, err := tx.ExecContext(ctx, query, defaults.StatusFor(true), defaults.StatusFor(false), id, userID); err != nil {
+			return fmt.Errorf("删除项目失败 %d: %w", id, err)
+		}
+		res, err := tx.ExecContext(ctx, `DELETE FROM projects WHERE id = $1 AND user_id = $2;`, id, userID)
+		if err != nil {
+			return fmt.Errorf("删除项目失败 %d: %w", id, err)
+		}
+		rowsAffected, _ := res.RowsAffected()
+		if rowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+// GetProjectTasks 返回项目中的任务，按手动排序
+func (s *PostgresStore) GetProjectTasks(ctx context.Context, projectID int, userID int) ([]models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE project_id = $1 AND user_id = $2 AND deleted_at IS NULL
+		ORDER BY ` + SortOrders["manual"] + `;`
+	tasks := []models.Task{}
+	if err := s.DB.SelectContext(ctx, &tasks, query, projectID, userID); err != nil {
+		return nil, fmt.Errorf("store: failed to get tasks of project %d: %w", projectID, err)
+	}
+	return tasks, nil
+}
+
+// projectWorkflow 返回任务所在项目的工作流，不属于任何项目时返回默认工作流
+func projectWorkflow(ctx context.Context, tx *sqlx.Tx, projectID *int, userID int) (models.Workflow, error) {
+	if projectID == nil {
+		return models.DefaultWorkflow(), nil
+	}
+	var workflow models.Workflow
+	err := tx.GetContext(ctx, &workflow, `SELECT workflow FROM projects WHERE id = $1 AND user_id = $2 FOR SHARE;`, *projectID, userID)
+	if err == sql.ErrNoRows {
+		return workflow, ErrUnknownProject
+	}
+	if err != nil {
+		return workflow, fmt.Errorf("store: failed to get workflow of project %d: %w", *projectID, err)
+	}
+	return workflow, nil
+}
+
+// applyWorkflow 让任务的状态和 done 保持一致，返回需要写入的字段
+// old 为 nil 表示新建任务，此时所有字段都视为由调用方提供
+//   - 提供了状态时，状态必须在工作流中，且允许从原状态转换过来；done 由状态的类别决定
+//   - 只修改 done 时，原状态的类别不再匹配就换成工作流中对应的第一个状态，不检查转换规则
+//   - 进入新的一列时检查该列的 WIP 限制
+//
+// restore 为 true 时写入的是历史版本：不检查转换规则，项目已被删除时移出项目，
+// 状态已不在工作流中时与只修改 done 一样由 done 推导
+func applyWorkflow(ctx context.Context, tx *sqlx.Tx, old *models.Task, task *models.Task, fields []string, restore bool) ([]string, error) {
+	fields = slices.Clone(fields)
+	has := func(field string) bool { return old == nil || slices.Contains(fields, field) }
+	if !has("project_id") {
+		task.ProjectID = old.ProjectID
+	}
+	if !has("done") {
+		task.Done = old.Done
+	}
+	if !has("status") {
+		task.Status = old.Status
+	}
+	workflow, err := projectWorkflow(ctx, tx, task.ProjectID, task.UserID)
+	if restore && errors.Is(err, ErrUnknownProject) {
+		task.ProjectID = nil
+		workflow, err = models.DefaultWorkflow(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	projectChanged := old == nil || !sameProject(old.ProjectID, task.ProjectID)
+
+	status, known := workflow.Status(task.Status)
+	if has("status") && task.Status != "" && (old == nil || task.Status != old.Status || projectChanged) && (known || !restore) {
+		if !known {
+			return nil, ErrInvalidStatus
+		}
+		if !restore && !projectChanged && !workflow.CanTransition(old.Status, task.Status) {
+			return nil, ErrTransitionNotAllowed
+		}
+		task.Done = status.Category == models.StatusCategoryDone
+	} else {
+		//没有指定状态（或状态未变化）时由 done 推导
+		if projectChanged {
+			task.Status = ""
+		} else {
+			task.Status = old.Status
+		}
+		status, ok := workflow.Status(task.Status)
+		if !ok || (status.Category == models.StatusCategoryDone) != task.Done {
+			task.Status = workflow.StatusFor(task.Done)
+		}
+	}
+	if old != nil {
+		for _, field := range []string{"done", "status"} {
+			if !slices.Contains(fields, field) {
+				fields = append(fields, field)
+			}
+		}
+	}
+
+	if old == nil || projectChanged || task.Status != old.Status {
+		if err := checkWIPLimit(ctx, tx, workflow, task); err != nil {
+			return nil, err
+		}
+	}
+	return fields, nil
+}
+
+// checkWIPLimit 任务进入的列已满时返回 ErrWIPLimitExceeded
+func checkWIPLimit(ctx context.Context, tx *sqlx.Tx, workflow models.Workflow, task *models.Task) error {
+	status, _ := workflow.Status(task.Status)
+	if status.WIPLimit == nil || task.ProjectID == nil {
+		return nil
+	}
+	//同一项目的 WIP 检查串行执行，避免并发的移动同时通过检查
+	//projectWorkflow 已持有项目的 FOR SHARE 锁，再升级为行锁会使并发的移动互相等待而死锁，所以用 advisory lock
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('project_wip'), $1);`, *task.ProjectID); err != nil {
+		return fmt.Errorf("store: failed to lock project %d: %w", *task.ProjectID, err)
+	}
+	var count int
+	query := `SELECT COUNT(*) FROM tasks WHERE project_id = $1 AND user_id = $2 AND status = $3 AND id <> $4 AND deleted_at IS NULL;`
+	if err := tx.GetContext(ctx, &count, query, *task.ProjectID, task.UserID, task.Status, task.ID); err != nil {
+		return fmt.Errorf("store: failed to count tasks in column %s: %w", task.Status, err)
+	}
+	if count >= *status.WIPLimit {
+		return ErrWIPLimitExceeded
+	}
+	return nil
+}
+
+func sameProject(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
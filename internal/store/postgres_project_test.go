@@ -0,0 +1,186 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const wipWorkflow = `{"statuses":[{"key":"todo","name":"To do","category":"todo"},` +
+	`{"key":"doing","name":"Doing","category":"in_progress","wip_limit":1},` +
+	`{"key":"done","name":"Done","category":"done"}]}`
+
+// TestApplyWorkflow_WIPLock 检查 WIP 限制时不再升级项目行上的锁
+// 只要出现 FOR NO KEY UPDATE 之类未预期的查询，sqlmock 就会报错
+func TestApplyWorkflow_WIPLock(t *testing.T) {
+	s, mock := newMockPostgresStore(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT workflow FROM projects WHERE id = \$1 AND user_id = \$2 FOR SHARE`).WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"workflow"}).AddRow([]byte(wipWorkflow)))
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(hashtext\('project_wip'\), \$1\)`).WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM tasks WHERE project_id = \$1`).WithArgs(5, 1, "doing", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	projectID := 5
+	old := models.Task{ID: 2, UserID: 1, ProjectID: &projectID, Status: "todo"}
+	task := &models.Task{ID: 2, UserID: 1, Status: "doing"}
+	err := s.withTx(context.Background(), func(tx *sqlx.Tx) error {
+		_, err := applyWorkflow(context.Background(), tx, &old, task, []string{"status"}, false)
+		return err
+	})
+	assert.ErrorIs(t, err, ErrWIPLimitExceeded)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestApplyWorkflow_DoneStatusSync 测试状态和 done 保持一致，不属于项目的任务使用默认工作流，不访问数据库
+func TestApplyWorkflow_DoneStatusSync(t *testing.T) {
+	tests := []struct {
+		name       string
+		old        models.Task
+		task       models.Task
+		fields     []string
+		wantDone   bool
+		wantStatus string
+		wantErr    error
+	}{
+		{"完成时换成完成类的状态", models.Task{Status: "in_progress"}, models.Task{Done: true}, []string{"done"}, true, "done", nil},
+		{"重新打开时回到第一个待办状态", models.Task{Done: true, Status: "done"}, models.Task{Done: false}, []string{"done"}, false, "todo", nil},
+		{"移入完成列时标记为完成", models.Task{Status: "todo"}, models.Task{Status: "done"}, []string{"status"}, true, "done", nil},
+		{"离开完成列时取消完成", models.Task{Done: true, Status: "done"}, models.Task{Status: "in_progress"}, []string{"status"}, false, "in_progress", nil},
+		{"类别一致时保留原状态", models.Task{Status: "in_progress"}, models.Task{Title: "x"}, []string{"title"}, false, "in_progress", nil},
+		{"不存在的状态", models.Task{Status: "todo"}, models.Task{Status: "archived"}, []string{"status"}, false, "", ErrInvalidStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := tt.task
+			fields, err := applyWorkflow(context.Background(), nil, &tt.old, &task, tt.fields, false)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDone, task.Done)
+			assert.Equal(t, tt.wantStatus, task.Status)
+			assert.Subset(t, fields, append([]string{"done", "status"}, tt.fields...))
+		})
+	}
+}
+
+// TestPatchTask_ConcurrentWIPMoves 两个任务同时移入 WIP 限制为1的列，必须一个成功、一个返回 ErrWIPLimitExceeded，不能死锁
+// 需要一个已经执行过所有迁移的数据库，例如 TODO_TEST_DATABASE="dbname=todo_test sslmode=disable"
+func TestPatchTask_ConcurrentWIPMoves(t *testing.T) {
+	dsn := os.Getenv("TODO_TEST_DATABASE")
+	if dsn == "" {
+		t.Skip("TODO_TEST_DATABASE 未设置")
+	}
+	db, err := sqlx.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	s := &PostgresStore{DB: db}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	user := &models.User{Username: fmt.Sprintf("wip-%d", time.Now().UnixNano()), PasswordHash: "secret123"}
+	require.NoError(t, s.CreateUser(ctx, user))
+	t.Cleanup(func() { db.Exec(`DELETE FROM users WHERE id = $1;`, user.ID) })
+
+	var workflow models.Workflow
+	require.NoError(t, workflow.Scan([]byte(wipWorkflow)))
+	for round := 0; round < 10; round++ {
+		project := &models.Project{UserID: user.ID, Name: fmt.Sprintf("board %d", round), Workflow: workflow}
+		require.NoError(t, s.CreateProject(ctx, project))
+		var ids [2]int
+		for i := range ids {
+			task := &models.Task{UserID: user.ID, Title: fmt.Sprintf("task %d", i), ProjectID: &project.ID}
+			require.NoError(t, s.CreateTask(ctx, task))
+			ids[i] = task.ID
+		}
+
+		errs := make([]error, len(ids))
+		var wg sync.WaitGroup
+		for i, id := range ids {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = s.PatchTask(ctx, &models.Task{ID: id, UserID: user.ID, Status: "doing"}, []string{"status"})
+			}()
+		}
+		wg.Wait()
+
+		var pqErr *pq.Error
+		for _, err := range errs {
+			if errors.As(err, &pqErr) {
+				t.Fatalf("round %d: %v (%s)", round, err, pqErr.Code)
+			}
+		}
+		failed := 0
+		for _, err := range errs {
+			if err != nil {
+				require.ErrorIs(t, err, ErrWIPLimitExceeded)
+				failed++
+			}
+		}
+		assert.Equal(t, 1, failed, "round %d", round)
+	}
+}
+
+// TestUpdateProject_StatusInUse 有任务的状态不能删除，也不能修改分类（分类决定任务的 done）
+func TestUpdateProject_StatusInUse(t *testing.T) {
+	reviewAs := func(category string) models.Workflow {
+		return models.Workflow{Statuses: []models.WorkflowStatus{
+			{Key: "todo", Name: "To do", Category: models.StatusCategoryTodo},
+			{Key: "review", Name: "Review", Category: category},
+			{Key: "done", Name: "Done", Category: models.StatusCategoryDone},
+		}}
+	}
+	tests := []struct {
+		name     string
+		workflow models.Workflow
+		wantErr  error
+	}{
+		{"只改名称", reviewAs(models.StatusCategoryInProgress), nil},
+		{"删除有任务的状态", models.DefaultWorkflow(), ErrStatusInUse},
+		{"有任务的状态改为完成类", reviewAs(models.StatusCategoryDone), ErrStatusInUse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newMockPostgresStore(t)
+			current, err := reviewAs(models.StatusCategoryInProgress).Value()
+			require.NoError(t, err)
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT workflow FROM projects WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).WithArgs(5, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"workflow"}).AddRow(current))
+			mock.ExpectQuery(`SELECT DISTINCT status FROM tasks`).WithArgs(5, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("review"))
+			if tt.wantErr == nil {
+				now := time.Now()
+				mock.ExpectQuery(`UPDATE projects SET name`).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(now, now))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			err = s.UpdateProject(context.Background(), &models.Project{ID: 5, UserID: 1, Name: "Renamed", Workflow: tt.workflow})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// revisionColumns 查询历史版本时统一选择的列
+const revisionColumns = `id, task_id, revision, user_id, title, content, done, due_at, priority, tags, project_id, status, changed_fields, created_at`
+
+// revisionRow 对应 task_revisions 表的一行，tags 和 changed_fields 是 TEXT[]
+type revisionRow struct {
+	ID            int            `db:"id"`
+	TaskID        int            `db:"task_id"`
+	Revision      int            `db:"revision"`
+	UserID        int            `db:"user_id"`
+	Title         string         `db:"title"`
+	Content       string         `db:"content"`
+	Done          bool           `db:"done"`
+	DueAt         *time.Time     `db:"due_at"`
+	Priority      int            `db:"priority"`
+	Tags          pq.StringArray `db:"tags"`
+	ProjectID     *int           `db:"project_id"`
+	Status        string         `db:"status"`
+	ChangedFields pq.StringArray `db:"changed_fields"`
+	CreatedAt     time.Time      `db:"created_at"`
+}
+
+func (r revisionRow) toModel() models.TaskRevision {
+	return models.TaskRevision{
+		ID:            r.ID,
+		TaskID:        r.TaskID,
+		Revision:      r.Revision,
+		UserID:        r.UserID,
+		Title:         r.Title,
+		Content:       r.Content,
+		Done:          r.Done,
+		DueAt:         r.DueAt,
+		Priority:      r.Priority,
+		Tags:          []string(r.Tags),
+		ProjectID:     r.ProjectID,
+		Status:        r.Status,
+		ChangedFields: []string(r.ChangedFields),
+		CreatedAt:     r.CreatedAt,
+	}
+}
+
+// insertRevision 在事务中为任务保存一份新的版本快照，调用方需持有该任务的行锁
+func insertRevision(ctx context.Context, tx *sqlx.Tx, task *models.Task, fields []string) error {
+	tags := task.Tags
+	if tags == nil {
+		tags = pq.StringArray{}
+	}
+	query := `INSERT INTO task_revisions (task_id, revision, user_id, title, content, done, due_at, priority, tags, project_id, status, changed_fields)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11 FROM task_revisions WHERE task_id = $1;`
+	_, err := tx.ExecContext(ctx, query, task.ID, task.UserID, task.Title, task.Content, task.Done,
+		task.DueAt, task.Priority, tags, task.ProjectID, task.Status, pq.Array(fields))
+	if err != nil {
+		return fmt.Errorf("store: failed to save revision for task %d: %w", task.ID, err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetTaskRevisions(ctx context.Context, taskID int, opts ListOptions) ([]models.TaskRevision, int, error) {
+	var total int
+	if err := s.DB.GetContext(ctx, &total, `SELECT COUNT(*) FROM task_revisions WHERE task_id = $1;`, taskID); err != nil {
+		return nil, 0, fmt.Errorf("store: failed to count revisions: %w", err)
+	}
+	query := `SELECT ` + revisionColumns + `
+		FROM task_revisions WHERE task_id = $1 ORDER BY revision DESC LIMIT $2 OFFSET $3;`
+	var rows []revisionRow
+	if err := s.DB.SelectContext(ctx, &rows, query, taskID, opts.Limit, opts.Offset); err != nil {
+		return nil, 0, fmt.Errorf("store: failed to get revisions: %w", err)
+	}
+	revisions := make([]models.TaskRevision, 0, len(rows))
+	for _, r := range rows {
+		revisions = append(revisions, r.toModel())
+	}
+	return revisions, total, nil
+}
+
+func (s *PostgresStore) GetTaskRevision(ctx context.Context, taskID int, revision int) (*models.TaskRevision, error) {
+	query := `SELECT ` + revisionColumns + `
+		FROM task_revisions WHERE task_id = $1 AND revision = $2;`
+	var row revisionRow
+	if err := s.DB.GetContext(ctx, &row, query, taskID, revision); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("store: failed to get revision %d of task %d: %w", revision, taskID, err)
+	}
+	rev := row.toModel()
+	return &rev, nil
+}
+
+// RestoreTaskRevision 把任务恢复到历史版本 revision，恢复本身作为一次新的修改保存
+// task 中需要有 ID 和 UserID，Version 不为0时检查版本；成功后 task 为恢复后的完整任务
+// 历史版本的状态不受转换规则限制，项目已被删除时任务移出项目，见 applyWorkflow
+func (s *PostgresStore) RestoreTaskRevision(ctx context.Context, task *models.Task, revision int) error {
+	return s.withTx(ctx, func(tx *sqlx.Tx) error {
+		query := `SELECT ` + revisionColumns + `
+			FROM task_revisions WHERE task_id = $1 AND revision = $2;`
+		var row revisionRow
+		if err := tx.GetContext(ctx, &row, query, task.ID, revision); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return fmt.Errorf("store: failed to get revision %d of task %d: %w", revision, task.ID, err)
+		}
+		rev := row.toModel()
+		rev.ApplyTo(task)
+		return writeTask(ctx, tx, task, TaskFields, true)
+	})
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChangedFields 所有可修改的字段都会产生新版本，字段名与 TaskFields 一一对应
+func TestChangedFields(t *testing.T) {
+	due := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	projectID := 2
+	old := &models.Task{Title: "a", Priority: 1, Tags: []string{"home"}, Status: "todo"}
+
+	assert.Empty(t, models.ChangedFields(old, &models.Task{Title: "a", Priority: 1, Tags: []string{"home"}, Status: "todo"}))
+	updated := &models.Task{Title: "a", Priority: 3, Tags: []string{"home", "work"}, DueAt: &due, ProjectID: &projectID, Status: "doing"}
+	assert.Equal(t, []string{"due_at", "priority", "tags", "project_id", "status"}, models.ChangedFields(old, updated))
+	all := &models.Task{Title: "x", Content: "y", Done: true, DueAt: &due, Priority: 1, Tags: []string{"t"}, ProjectID: &projectID, Status: "s"}
+	assert.Equal(t, TaskFields, models.ChangedFields(&models.Task{}, all))
+}
+
+// onewayWorkflow 只能从 todo 到 doing 再到 done，不能重新打开
+const onewayWorkflow = `{"statuses":[{"key":"todo","name":"To do","category":"todo"},` +
+	`{"key":"doing","name":"Doing","category":"in_progress"},{"key":"done","name":"Done","category":"done"}],` +
+	`"transitions":[{"from":"todo","to":"doing"},{"from":"doing","to":"done"}]}`
+
+// TestApplyWorkflow_RestoreDeletedProject 历史版本的项目已被删除时，恢复的任务移出项目并使用默认工作流
+func TestApplyWorkflow_RestoreDeletedProject(t *testing.T) {
+	for _, restore := range []bool{false, true} {
+		s, mock := newMockPostgresStore(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT workflow FROM projects WHERE id = \$1 AND user_id = \$2 FOR SHARE`).WithArgs(7, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"workflow"}))
+		if restore {
+			mock.ExpectCommit()
+		} else {
+			mock.ExpectRollback()
+		}
+
+		projectID := 7
+		old := models.Task{ID: 2, UserID: 1, Status: "todo"}
+		task := &models.Task{ID: 2, UserID: 1, ProjectID: &projectID, Status: "review"}
+		err := s.withTx(context.Background(), func(tx *sqlx.Tx) error {
+			_, err := applyWorkflow(context.Background(), tx, &old, task, TaskFields, restore)
+			return err
+		})
+		if restore {
+			require.NoError(t, err)
+			assert.Nil(t, task.ProjectID)
+			assert.Equal(t, "todo", task.Status, "已不存在的状态按 done 选择")
+		} else {
+			assert.ErrorIs(t, err, ErrUnknownProject)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
+
+// TestApplyWorkflow_RestoreSkipsTransitions 恢复历史版本时不检查状态转换规则
+func TestApplyWorkflow_RestoreSkipsTransitions(t *testing.T) {
+	for _, restore := range []bool{false, true} {
+		s, mock := newMockPostgresStore(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT workflow FROM projects WHERE id = \$1 AND user_id = \$2 FOR SHARE`).WithArgs(5, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"workflow"}).AddRow([]byte(onewayWorkflow)))
+		if restore {
+			mock.ExpectCommit()
+		} else {
+			mock.ExpectRollback()
+		}
+
+		projectID := 5
+		old := models.Task{ID: 2, UserID: 1, ProjectID: &projectID, Done: true, Status: "done"}
+		task := &models.Task{ID: 2, UserID: 1, ProjectID: &projectID, Status: "todo"}
+		err := s.withTx(context.Background(), func(tx *sqlx.Tx) error {
+			_, err := applyWorkflow(context.Background(), tx, &old, task, TaskFields, restore)
+			return err
+		})
+		if restore {
+			require.NoError(t, err)
+			assert.Equal(t, "todo", task.Status)
+			assert.False(t, task.Done)
+		} else {
+			assert.ErrorIs(t, err, ErrTransitionNotAllowed)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
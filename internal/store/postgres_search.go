@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+)
+
+// 高亮片段的参数，匹配的词先用 headlineStart/headlineStop 标记，由 highlightSnippet 转义并替换
+const headlineOptions = `StartSel="` + headlineStart + `", StopSel="` + headlineStop + `", MaxWords=30, MinWords=10, MaxFragments=2`
+
+// searchRow 搜索结果的一行
+type searchRow struct {
+	models.Task
+	Rank           float64 `db:"rank"`
+	TitleSnippet   string  `db:"title_snippet"`
+	ContentSnippet string  `db:"content_snippet"`
+	CommentSnippet *string `db:"comment_snippet"`
+}
+
+// SearchTasks 在用户自己的任务的标题、内容和评论中全文搜索，按相关度排序
+func (s *PostgresStore) SearchTasks(ctx context.Context, userID int, q string, opts ListOptions) ([]models.SearchResult, int, error) {
+	results := []models.SearchResult{}
+	tsquery := buildTSQuery(q)
+	if tsquery == "" {
+		return results, 0, nil
+	}
+
+	// 与 GetTasks 相同的访问规则：只搜索当前用户未删除的任务
+	match := `FROM tasks t, to_tsquery('simple', $2) query
+		WHERE t.user_id = $1 AND t.deleted_at IS NULL
+		AND (t.search_vector @@ query OR EXISTS (
+			SELECT 1 FROM task_comments c WHERE c.task_id = t.id AND c.search_vector @@ query))`
+
+	var total int
+	if err := s.DB.GetContext(ctx, &total, `SELECT COUNT(*) `+match+`;`, userID, tsquery); err != nil {
+		return nil, 0, fmt.Errorf("store: failed to count search results: %w", err)
+	}
+
+	query := `SELECT ` + taskColumns + `,
+			ts_rank(t.search_vector, query) + 0.5 * COALESCE((
+				SELECT MAX(ts_rank(c.search_vector, query)) FROM task_comments c
+				WHERE c.task_id = t.id AND c.search_vector @@ query), 0) AS rank,
+			ts_headline('simple', t.title, query, '` + headlineOptions + `') AS title_snippet,
+			ts_headline('simple', t.content, query, '` + headlineOptions + `') AS content_snippet,
+			(SELECT ts_headline('simple', c.content, query, '` + headlineOptions + `') FROM task_comments c
+				WHERE c.task_id = t.id AND c.search_vector @@ query
+				ORDER BY ts_rank(c.search_vector, query) DESC LIMIT 1) AS comment_snippet
+		` + match + `
+		ORDER BY rank DESC, t.updated_at DESC LIMIT $3 OFFSET $4;`
+	var rows []searchRow
+	if err := s.DB.SelectContext(ctx, &rows, query, userID, tsquery, opts.Limit, opts.Offset); err != nil {
+		return nil, 0, fmt.Errorf("store: failed to search tasks: %w", err)
+	}
+	for _, r := range rows {
+		result := models.SearchResult{
+			Task:           r.Task,
+			Rank:           r.Rank,
+			TitleSnippet:   highlightSnippet(r.TitleSnippet),
+			ContentSnippet: highlightSnippet(r.ContentSnippet),
+		}
+		if r.CommentSnippet != nil {
+			comment := highlightSnippet(*r.CommentSnippet)
+			result.CommentSnippet = &comment
+		}
+		results = append(results, result)
+	}
+	return results, total, nil
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSearchTasks 测试搜索词转换为 tsquery、分页参数传给查询，评论片段被转义和高亮
+func TestSearchTasks(t *testing.T) {
+	s, mock := newMockPostgresStore(t)
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM tasks t, to_tsquery\('simple', \$2\) query`).
+		WithArgs(1, "quarterly:* & report:*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(`AS comment_snippet .* ORDER BY rank DESC, t.updated_at DESC LIMIT \$3 OFFSET \$4`).
+		WithArgs(1, "quarterly:* & report:*", 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "rank", "title_snippet", "content_snippet", "comment_snippet"}).
+			AddRow(3, 1, "Quarterly report", 0.8, headlineStart+"Quarterly"+headlineStop+" "+headlineStart+"report"+headlineStop, "", nil).
+			AddRow(4, 1, "Draft", 0.2, "Draft", "", "<b>see</b> the "+headlineStart+"report"+headlineStop))
+
+	results, total, err := s.SearchTasks(context.Background(), 1, "Quarterly report", ListOptions{Limit: 2, Offset: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, results, 2)
+	assert.Equal(t, "<mark>Quarterly</mark> <mark>report</mark>", results[0].TitleSnippet)
+	assert.Nil(t, results[0].CommentSnippet)
+	require.NotNil(t, results[1].CommentSnippet)
+	assert.Equal(t, "&lt;b&gt;see&lt;/b&gt; the <mark>report</mark>", *results[1].CommentSnippet)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestSearchTasks_NoWords 测试只有特殊字符的搜索词不查询数据库，直接返回空结果
+func TestSearchTasks_NoWords(t *testing.T) {
+	s, mock := newMockPostgresStore(t)
+	results, total, err := s.SearchTasks(context.Background(), 1, `&|!`, ListOptions{Limit: 20})
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+	assert.Equal(t, []models.SearchResult{}, results)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+)
+
+// GetStats 在 q.Location 时区中统计 [From, To) 内的任务完成情况
+// 连续完成天数不受时间范围限制，按全部历史计算
+func (s *PostgresStore) GetStats(ctx context.Context, userID int, q StatsQuery) (*models.Stats, error) {
+	tz := q.Location.String()
+	stats := &models.Stats{From: q.From, To: q.To, Timezone: tz, Interval: q.Interval}
+
+	//按天或按周分组的创建数和完成数
+	var buckets []struct {
+		Bucket    time.Time `db:"bucket"`
+		Created   int       `db:"created"`
+		Completed int       `db:"completed"`
+	}
+	//date_trunc 的周从周一开始，先平移 $6 天再截断，使每周从 q.WeekStart 开始
+	shift := 0
+	if q.Interval == models.StatsByWeek {
+		shift = (8 - int(q.WeekStart)) % 7
+	}
+	query := `SELECT bucket, SUM(created)::INT AS created, SUM(completed)::INT AS completed FROM (
+			SELECT date_trunc($4, (created_at AT TIME ZONE $5) + $6::INT * INTERVAL '1 day') - $6::INT * INTERVAL '1 day' AS bucket, 1 AS created, 0 AS completed
+			FROM tasks WHERE user_id = $1 AND deleted_at IS NULL AND created_at >= $2 AND created_at < $3
+			UNION ALL
+			SELECT date_trunc($4, (completed_at AT TIME ZONE $5) + $6::INT * INTERVAL '1 day') - $6::INT * INTERVAL '1 day', 0, 1
+			FROM tasks WHERE user_id = $1 AND deleted_at IS NULL AND completed_at >= $2 AND completed_at < $3
+		) events GROUP BY bucket ORDER BY bucket;`
+	if err := s.DB.SelectContext(ctx, &buckets, query, userID, q.From, q.To, q.Interval, tz, shift); err != nil {
+		return nil, fmt.Errorf("store: failed to get stats series: %w", err)
+	}
+	counts := make(map[string]models.StatsBucket, len(buckets))
+	for _, b := range buckets {
+		date := b.Bucket.Format("2006-01-02")
+		counts[date] = models.StatsBucket{Date: date, Created: b.Created, Completed: b.Completed}
+	}
+	stats.Series = fillStatsSeries(counts, q.From.In(q.Location), q.To.In(q.Location), q.Interval, q.WeekStart)
+
+	//总数、完成率、平均完成时间和逾期
+	var totals struct {
+		Created          int      `db:"created"`
+		CreatedCompleted int      `db:"created_completed"`
+		Completed        int      `db:"completed"`
+		AvgSeconds       *float64 `db:"avg_seconds"`
+		CompletedLate    int      `db:"completed_late"`
+		Overdue          int      `db:"overdue"`
+	}
+	query = `SELECT
+			COUNT(*) FILTER (WHERE created_at >= $2 AND created_at < $3) AS created,
+			COUNT(*) FILTER (WHERE created_at >= $2 AND created_at < $3 AND done) AS created_completed,
+			COUNT(*) FILTER (WHERE completed_at >= $2 AND completed_at < $3) AS completed,
+			AVG(EXTRACT(EPOCH FROM (completed_at - created_at))) FILTER (WHERE completed_at >= $2 AND completed_at < $3) AS avg_seconds,
+			COUNT(*) FILTER (WHERE completed_at >= $2 AND completed_at < $3 AND completed_at > due_at) AS completed_late,
+			COUNT(*) FILTER (WHERE NOT done AND due_at < NOW()) AS overdue
+		FROM tasks WHERE user_id = $1 AND deleted_at IS NULL;`
+	if err := s.DB.GetContext(ctx, &totals, query, userID, q.From, q.To); err != nil {
+		return nil, fmt.Errorf("store: failed to get stats totals: %w", err)
+	}
+	stats.Created, stats.Completed = totals.Created, totals.Completed
+	stats.AvgTimeToCompleteSeconds = totals.AvgSeconds
+	stats.CompletedLate, stats.Overdue = totals.CompletedLate, totals.Overdue
+	if totals.Created > 0 {
+		stats.CompletionRate = float64(totals.CreatedCompleted) / float64(totals.Created)
+	}
+
+	//连续完成天数：把有完成记录的日期按连续区间分组（gaps and islands）
+	var islands []struct {
+		End    time.Time `db:"end_day"`
+		Length int       `db:"length"`
+	}
+	query = `WITH days AS (
+			SELECT DISTINCT (completed_at AT TIME ZONE $2)::DATE AS day
+			FROM tasks WHERE user_id = $1 AND deleted_at IS NULL AND completed_at IS NOT NULL
+		)
+		SELECT MAX(day) AS end_day, COUNT(*)::INT AS length FROM (
+			SELECT day, day - (ROW_NUMBER() OVER (ORDER BY day))::INT AS grp FROM days
+		) islands GROUP BY grp ORDER BY end_day;`
+	if err := s.DB.SelectContext(ctx, &islands, query, userID, tz); err != nil {
+		return nil, fmt.Errorf("store: failed to get completion streaks: %w", err)
+	}
+	now := time.Now().In(q.Location)
+	yesterday := time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, time.UTC)
+	for _, island := range islands {
+		if island.Length > stats.LongestStreak {
+			stats.LongestStreak = island.Length
+		}
+		//今天还没有完成任务时，截至昨天的连续天数仍然算作当前连续
+		if !island.End.Before(yesterday) {
+			stats.CurrentStreak = island.Length
+		}
+	}
+	return stats, nil
+}
+
+// fillStatsSeries 生成从 from 到 to 的每一天（或从 weekStart 开始的每一周）的数据，没有记录的补0
+func fillStatsSeries(counts map[string]models.StatsBucket, from, to time.Time, interval string, weekStart time.Weekday) []models.StatsBucket {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	step := 1
+	if interval == models.StatsByWeek {
+		start = start.AddDate(0, 0, -(int(start.Weekday())-int(weekStart)+7)%7)
+		step = 7
+	}
+	series := []models.StatsBucket{}
+	for day := start; day.Before(to); day = day.AddDate(0, 0, step) {
+		date := day.Format("2006-01-02")
+		bucket, ok := counts[date]
+		if !ok {
+			bucket = models.StatsBucket{Date: date}
+		}
+		series = append(series, bucket)
+	}
+	return series
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	//"os/user"
 
 	"github.com/HywlEch/Todo_list/internal/config"
@@ -14,6 +15,12 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// taskColumns 查询任务时统一选择的列
+const taskColumns = `id, title, content, done, project_id, status, created_at, updated_at, user_id, version, due_at, priority, tags, completed_at, position, task_is_blocked(id) AS is_blocked`
+
+// userColumns 查询用户时统一选择的列
+const userColumns = `id, username, password_hash, created_at, display_name, timezone, locale, week_start, default_sort, updated_at`
+
 // PostgresStore 实现了 Store 接口
 type PostgresStore struct {
 	DB *sqlx.DB
@@ -34,6 +41,20 @@ func NewPostgresStore(cfg config.DBConfig) (*PostgresStore, error) {
 	return &PostgresStore{DB: db}, nil
 }
 
+// withTx 在一个事务中执行 fn，fn 返回错误时回滚
+func (s *PostgresStore) withTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("store: failed to begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 func (s *PostgresStore) CreateUser(ctx context.Context, user *models.User) error {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.PasswordHash), bcrypt.DefaultCost)
 	if err != nil {
@@ -52,7 +73,7 @@ func (s *PostgresStore) CreateUser(ctx context.Context, user *models.User) error
 }
 
 func (s *PostgresStore)GetUserByUsername(ctx context.Context, username string ) (*models.User, error){
-	query := `SELECT id, username, password_hash, created_at FROM users WHERE username = $1;`
+	query := `SELECT ` + userColumns + ` FROM users WHERE username = $1;`
 	var user models.User
 	err := s.DB.GetContext(ctx,&user, query, username)
 	if err != nil {
@@ -67,16 +88,40 @@ func (s *PostgresStore)GetUserByUsername(ctx context.Context, username string )
 
 
 func (s *PostgresStore) CreateTask(ctx context.Context, task *models.Task) error {
-	query := `INSERT INTO tasks (title, content, done,user_id) VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at;`
-	err := s.DB.QueryRowxContext(ctx, query, task.Title, task.Content, task.Done, task.UserID).Scan(&task.ID, &task.CreatedAt, &task.UpdatedAt)
+	return s.withTx(ctx, func(tx *sqlx.Tx) error {
+		return createTask(ctx, tx, task)
+	})
+}
+
+// createTask 在事务中创建任务，并记录动态和第一个版本
+func createTask(ctx context.Context, tx *sqlx.Tx, task *models.Task) error {
+	if task.Tags == nil {
+		task.Tags = pq.StringArray{}
+	}
+	if _, err := applyWorkflow(ctx, tx, nil, task, nil, false); err != nil {
+		return err
+	}
+	//新任务排在列表最前面
+	position, err := firstPosition(ctx, tx, task.UserID)
+	if err != nil {
+		return err
+	}
+	task.Position = position
+	query := `INSERT INTO tasks (title, content, done,user_id, due_at, priority, tags, completed_at, position, project_id, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, CASE WHEN $3 THEN NOW() END, $8, $9, $10) RETURNING id, created_at, updated_at, version, completed_at;`
+	err = tx.QueryRowxContext(ctx, query, task.Title, task.Content, task.Done, task.UserID, task.DueAt, task.Priority, task.Tags, task.Position, task.ProjectID, task.Status).Scan(&task.ID, &task.CreatedAt, &task.UpdatedAt, &task.Version, &task.CompletedAt)
 	if err != nil { 
 		return fmt.Errorf("创建任务失败: %w", err)
 	}
-	return nil
+	created := models.ActivityEvent{TaskID: task.ID, UserID: task.UserID, Action: models.ActivityTaskCreated, NewValue: &task.Title}
+	if err := insertActivities(ctx, tx, []models.ActivityEvent{created}); err != nil {
+		return err
+	}
+	return insertRevision(ctx, tx, task, TaskFields)
 }
 
 func (s *PostgresStore) GetTasks(ctx context.Context, userID int) ([]models.Task, error) {
-	query := `SELECT id, title, content, done, created_at, updated_at, user_id FROM tasks WHERE user_id = $1 ORDER BY created_at DESC;`
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE user_id = $1 AND deleted_at IS NULL ORDER BY ` + SortOrders["manual"] + `;`
 
 	var tasks []models.Task
 	err := s.DB.SelectContext(ctx, &tasks, query, userID)
@@ -87,7 +132,7 @@ func (s *PostgresStore) GetTasks(ctx context.Context, userID int) ([]models.Task
 }
 
 func (s *PostgresStore) GetTaskByID(ctx context.Context, id int, userID int) (*models.Task, error) {
-	query := `SELECT id, title, content, done, created_at, updated_at, user_id FROM tasks WHERE id = $1	AND user_id = $2;`
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE id = $1	AND user_id = $2 AND deleted_at IS NULL;`
 	var task models.Task
 	err := s.DB.GetContext(ctx,&task, query, id, userID)
 	if err != nil {
@@ -100,27 +145,124 @@ func (s *PostgresStore) GetTaskByID(ctx context.Context, id int, userID int) (*m
 }
 
 func (s *PostgresStore) UpdateTask(ctx context.Context, task *models.Task) error {
-	query := `UPDATE tasks SET title = $1, content = $2, done = $3, updated_at =NOW() WHERE id = $4 AND user_id = $5 RETURNING updated_at;`
-	// 我们需要扫描返回的 updated_at，更新到传入的 task 对象上
-	err := s.DB.QueryRowxContext(ctx, query, task.Title, task.Content, task.Done, task.ID,task.UserID).Scan(&task.UpdatedAt)
+	return s.PatchTask(ctx, task, TaskFields)
+}
+
+// TaskFields 可以被修改的任务字段，与数据库列名一致
+var TaskFields = []string{"title", "content", "done", "due_at", "priority", "tags", "project_id", "status"}
+
+// taskFieldValues 返回 task 中各个可修改字段的值
+func taskFieldValues(task *models.Task) map[string]interface{} {
+	tags := task.Tags
+	if tags == nil {
+		tags = pq.StringArray{}
+	}
+	return map[string]interface{}{
+		"title":      task.Title,
+		"content":    task.Content,
+		"done":       task.Done,
+		"due_at":     task.DueAt,
+		"priority":   task.Priority,
+		"tags":       tags,
+		"project_id": task.ProjectID,
+		"status":     task.Status,
+	}
+}
+
+// PatchTask 只更新 fields 中列出的字段，其余字段保持数据库中的值
+// 成功后 task 会被填充为更新后的完整任务
+func (s *PostgresStore) PatchTask(ctx context.Context, task *models.Task, fields []string) error {
+	return s.withTx(ctx, func(tx *sqlx.Tx) error {
+		return patchTask(ctx, tx, task, fields)
+	})
+}
+
+// patchTask 在事务中锁住任务并更新指定字段
+func patchTask(ctx context.Context, tx *sqlx.Tx, task *models.Task, fields []string) error {
+	return writeTask(ctx, tx, task, fields, false)
+}
+
+// writeTask 是 patchTask 的实现，restore 为 true 时写入的是历史版本，见 applyWorkflow
+func writeTask(ctx context.Context, tx *sqlx.Tx, task *models.Task, fields []string, restore bool) error {
+	// 先锁住旧数据，用于生成动态记录
+	var old models.Task
+	err := tx.GetContext(ctx, &old, `SELECT `+taskColumns+` FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE;`, task.ID, task.UserID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		return fmt.Errorf("store: failed to get task %d: %w", task.ID, err)
+	}
+	// task.Version 不为0时表示调用方期望的版本（乐观锁）
+	if task.Version != 0 && task.Version != old.Version {
+		return ErrVersionConflict
+	}
+	// 状态和 done 需要按工作流保持一致
+	if fields, err = applyWorkflow(ctx, tx, &old, task, fields, restore); err != nil {
+		return err
+	}
+
+	values := taskFieldValues(task)
+	sets := []string{"updated_at = NOW()", "version = version + 1"}
+	args := []interface{}{task.ID, task.UserID}
+	for _, field := range fields {
+		if _, ok := values[field]; !ok {
+			return fmt.Errorf("store: field %q cannot be updated", field)
+		}
+		args = append(args, values[field])
+		sets = append(sets, fmt.Sprintf("%s = $%d", field, len(args)))
+		if field == "done" {
+			//完成时记录完成时间，重新打开时清空
+			sets = append(sets, fmt.Sprintf("completed_at = CASE WHEN $%d THEN COALESCE(completed_at, NOW()) END", len(args)))
+		}
+	}
+	query := `UPDATE tasks SET ` + strings.Join(sets, ", ") + ` WHERE id = $1 AND user_id = $2 RETURNING ` + taskColumns + `;`
+	// 扫描返回的完整任务，更新到传入的 task 对象上
+	if err := tx.QueryRowxContext(ctx, query, args...).StructScan(task); err != nil {
 		if err == sql.ErrNoRows {
 			return ErrNotFound
 		}
 		return err
 	}
+	if err := insertActivities(ctx, tx, taskChangeEvents(&old, task)); err != nil {
+		return err
+	}
+	if !old.Done && task.Done {
+		if task.Unblocked, err = unblockTasks(ctx, tx, task); err != nil {
+			return err
+		}
+	}
+	//只有内容真正变化时才产生新版本
+	if fields := models.ChangedFields(&old, task); len(fields) > 0 {
+		return insertRevision(ctx, tx, task, fields)
+	}
 	return nil
 }
 
-func (s *PostgresStore) DeleteTask(ctx context.Context, id int,userID int) error {
-	query := `DELETE FROM tasks WHERE id = $1 AND user_id = $2;`
-	res, err := s.DB.ExecContext(ctx, query, id,userID)
+// DeleteTask 把任务移入回收站（软删除），version 不为0时只有版本一致才会删除
+func (s *PostgresStore) DeleteTask(ctx context.Context, id int,userID int, version int) error {
+	return s.withTx(ctx, func(tx *sqlx.Tx) error {
+		return deleteTask(ctx, tx, id, userID, version)
+	})
+}
+
+// deleteTask 在事务中把任务移入回收站
+func deleteTask(ctx context.Context, tx *sqlx.Tx, id int, userID int, version int) error {
+	var current int
+	err := tx.GetContext(ctx, &current, `SELECT version FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE;`, id, userID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
 		return fmt.Errorf("删除任务失败 %d: %w", id, err)
 	}
-	rowsAffected, _ := res.RowsAffected()
-	if rowsAffected == 0 {
-		return ErrNotFound
+	if version != 0 && version != current {
+		return ErrVersionConflict
 	}
-	return nil
-}
\ No newline at end of file
+	query := `UPDATE tasks SET deleted_at = NOW(), version = version + 1 WHERE id = $1 AND user_id = $2;`
+	if _, err := tx.ExecContext(ctx, query, id,userID); err != nil {
+		return fmt.Errorf("删除任务失败 %d: %w", id, err)
+	}
+	trashed := models.ActivityEvent{TaskID: id, UserID: userID, Action: models.ActivityTaskTrashed}
+	return insertActivities(ctx, tx, []models.ActivityEvent{trashed})
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/lib/pq"
+)
+
+const timeEntryColumns = `id, task_id, user_id, started_at, stopped_at, note, created_at,
+	EXTRACT(EPOCH FROM (COALESCE(stopped_at, NOW()) - started_at))::BIGINT AS duration_seconds`
+
+// StartTimer 为任务开始计时，用户已有正在运行的计时器时返回 ErrTimerRunning
+func (s *PostgresStore) StartTimer(ctx context.Context, entry *models.TimeEntry) error {
+	query := `INSERT INTO time_entries (task_id, user_id, note)
+		SELECT id, user_id, $3 FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+		RETURNING ` + timeEntryColumns + `;`
+	err := s.DB.QueryRowxContext(ctx, query, entry.TaskID, entry.UserID, entry.Note).StructScan(entry)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
+			return ErrTimerRunning
+		}
+		return fmt.Errorf("store: failed to start timer: %w", err)
+	}
+	return nil
+}
+
+// StopTimer 停止用户正在运行的计时器，note 不为 nil 时同时修改备注
+func (s *PostgresStore) StopTimer(ctx context.Context, userID int, note *string) (*models.TimeEntry, error) {
+	query := `UPDATE time_entries SET stopped_at = NOW(), note = COALESCE($2, note)
+		WHERE user_id = $1 AND stopped_at IS NULL RETURNING ` + timeEntryColumns + `;`
+	var entry models.TimeEntry
+	if err := s.DB.QueryRowxContext(ctx, query, userID, note).StructScan(&entry); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("store: failed to stop timer: %w", err)
+	}
+	return &entry, nil
+}
+
+// GetRunningTimer 返回用户正在运行的计时器，没有时返回 ErrNotFound
+func (s *PostgresStore) GetRunningTimer(ctx context.Context, userID int) (*models.TimeEntry, error) {
+	query := `SELECT ` + timeEntryColumns + ` FROM time_entries WHERE user_id = $1 AND stopped_at IS NULL;`
+	var entry models.TimeEntry
+	if err := s.DB.GetContext(ctx, &entry, query, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("store: failed to get running timer: %w", err)
+	}
+	return &entry, nil
+}
+
+// GetTimeEntries 返回在 [From, To) 内开始的时间记录，最近的在前
+func (s *PostgresStore) GetTimeEntries(ctx context.Context, userID int, q TimeEntryQuery, opts ListOptions) ([]models.TimeEntry, int, error) {
+	where := `user_id = $1 AND started_at >= $2 AND started_at < $3`
+	args := []interface{}{userID, q.From, q.To}
+	if q.TaskID != 0 {
+		args = append(args, q.TaskID)
+		where += ` AND task_id = $` + strconv.Itoa(len(args))
+	}
+	var total int
+	if err := s.DB.GetContext(ctx, &total, `SELECT COUNT(*) FROM time_entries WHERE `+where+`;`, args...); err != nil {
+		return nil, 0, fmt.Errorf("store: failed to count time entries: %w", err)
+	}
+	entries := []models.TimeEntry{}
+	query := fmt.Sprintf(`SELECT %s FROM time_entries WHERE %s ORDER BY started_at DESC, id DESC LIMIT $%d OFFSET $%d;`,
+		timeEntryColumns, where, len(args)+1, len(args)+2)
+	if err := s.DB.SelectContext(ctx, &entries, query, append(args, opts.Limit, opts.Offset)...); err != nil {
+		return nil, 0, fmt.Errorf("store: failed to get time entries: %w", err)
+	}
+	return entries, total, nil
+}
+
+// reportGroups 报表分组方式对应的分组键和显示名称，按天分组时 $4 为时区
+var reportGroups = map[string]struct{ key, label string }{
+	models.ReportByDay:     {`to_char(e.started_at AT TIME ZONE $4, 'YYYY-MM-DD')`, `to_char(e.started_at AT TIME ZONE $4, 'YYYY-MM-DD')`},
+	models.ReportByProject: {`COALESCE(p.id::TEXT, '')`, `COALESCE(p.name, '')`},
+	models.ReportByTag:     {`COALESCE(tag, '')`, `COALESCE(tag, '')`},
+}
+
+// GetTimeReport 按天、项目或标签汇总 [from, to) 内的时间
+// 只统计记录落在时间范围内的部分；按天分组时以开始时间在 tz 时区中的日期为准；
+// 带多个标签的任务的时间会计入每个标签
+func (s *PostgresStore) GetTimeReport(ctx context.Context, userID int, from, to time.Time, groupBy string, tz string) ([]models.TimeReportRow, error) {
+	group, ok := reportGroups[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("store: unknown report grouping %q", groupBy)
+	}
+	args := []interface{}{userID, from, to}
+	if groupBy == models.ReportByDay {
+		args = append(args, tz)
+	}
+	//只有按标签分组时才展开标签，避免同一条记录被重复计算
+	tagJoin := ""
+	if groupBy == models.ReportByTag {
+		tagJoin = `LEFT JOIN LATERAL unnest(t.tags) AS tag ON TRUE`
+	}
+	query := `SELECT ` + group.key + ` AS key, ` + group.label + ` AS label,
+			SUM(EXTRACT(EPOCH FROM (LEAST(COALESCE(e.stopped_at, NOW()), $3) - GREATEST(e.started_at, $2))))::BIGINT AS seconds,
+			COUNT(e.id) AS entries
+		FROM time_entries e
+		JOIN tasks t ON t.id = e.task_id
+		LEFT JOIN projects p ON p.id = t.project_id
+		` + tagJoin + `
+		WHERE e.user_id = $1 AND e.started_at < $3 AND COALESCE(e.stopped_at, NOW()) > $2
+		GROUP BY 1, 2 ORDER BY 1;`
+	rows := []models.TimeReportRow{}
+	if err := s.DB.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("store: failed to get time report: %w", err)
+	}
+	return rows, nil
+}
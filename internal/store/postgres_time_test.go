@@ -0,0 +1,33 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStopTimer 停止时写入 stopped_at，返回按 stopped_at 计算的时长
+func TestStopTimer(t *testing.T) {
+	s, mock := newMockPostgresStore(t)
+	started := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	stopped := started.Add(90 * time.Minute)
+	columns := []string{"id", "task_id", "user_id", "started_at", "stopped_at", "note", "created_at", "duration_seconds"}
+	mock.ExpectQuery(`UPDATE time_entries SET stopped_at = NOW\(\), note = COALESCE\(\$2, note\)\s+WHERE user_id = \$1 AND stopped_at IS NULL RETURNING .*COALESCE\(stopped_at, NOW\(\)\) - started_at`).
+		WithArgs(1, nil).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(8, 2, 1, started, stopped, "", started, 5400))
+	mock.ExpectQuery(`UPDATE time_entries SET stopped_at = NOW\(\)`).WithArgs(1, nil).WillReturnError(sql.ErrNoRows)
+
+	entry, err := s.StopTimer(context.Background(), 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, stopped, *entry.StoppedAt)
+	assert.Equal(t, int64(5400), entry.DurationSeconds)
+
+	_, err = s.StopTimer(context.Background(), 1, nil)
+	assert.ErrorIs(t, err, ErrNotFound, "没有正在运行的计时器")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
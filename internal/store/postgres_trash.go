@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// GetTrash 返回用户回收站中的任务，最近删除的在前
+func (s *PostgresStore) GetTrash(ctx context.Context, userID int) ([]models.Task, error) {
+	query := `SELECT ` + taskColumns + `, deleted_at FROM tasks
+		WHERE user_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC;`
+	tasks := []models.Task{}
+	if err := s.DB.SelectContext(ctx, &tasks, query, userID); err != nil {
+		return nil, fmt.Errorf("store: failed to get trash: %w", err)
+	}
+	return tasks, nil
+}
+
+// RestoreTask 把任务从回收站中恢复
+func (s *PostgresStore) RestoreTask(ctx context.Context, id int, userID int) error {
+	return s.withTx(ctx, func(tx *sqlx.Tx) error {
+		query := `UPDATE tasks SET deleted_at = NULL, version = version + 1 WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL;`
+		res, err := tx.ExecContext(ctx, query, id, userID)
+		if err != nil {
+			return fmt.Errorf("恢复任务失败 %d: %w", id, err)
+		}
+		rowsAffected, _ := res.RowsAffected()
+		if rowsAffected == 0 {
+			return ErrNotFound
+		}
+		restored := models.ActivityEvent{TaskID: id, UserID: userID, Action: models.ActivityTaskRestored}
+		return insertActivities(ctx, tx, []models.ActivityEvent{restored})
+	})
+}
+
+// PurgeTask 彻底删除回收站中的任务
+func (s *PostgresStore) PurgeTask(ctx context.Context, id int, userID int) error {
+	query := `DELETE FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL;`
+	res, err := s.DB.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("彻底删除任务失败 %d: %w", id, err)
+	}
+	rowsAffected, _ := res.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PurgeTrash 彻底删除所有在 deletedBefore 之前进入回收站的任务，返回删除的数量
+func (s *PostgresStore) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < $1;`, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("store: failed to purge trash: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,36 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+)
+
+func (s *PostgresStore) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1;`
+	var user models.User
+	if err := s.DB.GetContext(ctx, &user, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("store: failed to get user %d: %w", id, err)
+	}
+	return &user, nil
+}
+
+// UpdateUserProfile 修改用户资料，不修改用户名和密码
+func (s *PostgresStore) UpdateUserProfile(ctx context.Context, user *models.User) error {
+	query := `UPDATE users SET display_name = $1, timezone = $2, locale = $3, week_start = $4, default_sort = $5, updated_at = NOW()
+		WHERE id = $6 RETURNING ` + userColumns + `;`
+	err := s.DB.QueryRowxContext(ctx, query, user.DisplayName, user.Timezone, user.Locale, user.WeekStart, user.DefaultSort, user.ID).
+		StructScan(user)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		return fmt.Errorf("更新用户资料失败 %d: %w", user.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+)
+
+const viewColumns = `id, user_id, name, filter, sort, pinned, created_at, updated_at`
+
+func (s *PostgresStore) CreateView(ctx context.Context, view *models.SavedView) error {
+	query := `INSERT INTO saved_views (user_id, name, filter, sort, pinned) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at;`
+	err := s.DB.QueryRowxContext(ctx, query, view.UserID, view.Name, view.Filter, view.Sort, view.Pinned).
+		Scan(&view.ID, &view.CreatedAt, &view.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("创建视图失败: %w", err)
+	}
+	return nil
+}
+
+// GetViews 返回用户保存的视图，置顶的在前
+func (s *PostgresStore) GetViews(ctx context.Context, userID int) ([]models.SavedView, error) {
+	query := `SELECT ` + viewColumns + ` FROM saved_views WHERE user_id = $1 ORDER BY pinned DESC, name, id;`
+	views := []models.SavedView{}
+	if err := s.DB.SelectContext(ctx, &views, query, userID); err != nil {
+		return nil, fmt.Errorf("store: failed to get views: %w", err)
+	}
+	return views, nil
+}
+
+func (s *PostgresStore) GetView(ctx context.Context, id int, userID int) (*models.SavedView, error) {
+	query := `SELECT ` + viewColumns + ` FROM saved_views WHERE id = $1 AND user_id = $2;`
+	var view models.SavedView
+	if err := s.DB.GetContext(ctx, &view, query, id, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("store: failed to get view %d: %w", id, err)
+	}
+	return &view, nil
+}
+
+func (s *PostgresStore) UpdateView(ctx context.Context, view *models.SavedView) error {
+	query := `UPDATE saved_views SET name = $1, filter = $2, sort = $3, pinned = $4, updated_at = NOW()
+		WHERE id = $5 AND user_id = $6 RETURNING created_at, updated_at;`
+	err := s.DB.QueryRowxContext(ctx, query, view.Name, view.Filter, view.Sort, view.Pinned, view.ID, view.UserID).
+		Scan(&view.CreatedAt, &view.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		return fmt.Errorf("更新视图失败 %d: %w", view.ID, err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) DeleteView(ctx context.Context, id int, userID int) error {
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM saved_views WHERE id = $1 AND user_id = $2;`, id, userID)
+	if err != nil {
+		return fmt.Errorf("删除视图失败 %d: %w", id, err)
+	}
+	rowsAffected, _ := res.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// ts_headline 用私有区字符标记匹配的词，转义 HTML 之后再替换为 <mark></mark>
+const (
+	headlineStart = "\uE000"
+	headlineStop  = "\uE001"
+)
+
+// highlightSnippet 把 ts_headline 的结果转换为安全的 HTML 片段
+// 任务文本由用户输入，必须先转义，只有 <mark></mark> 是标记
+func highlightSnippet(s string) string {
+	s = html.EscapeString(s)
+	return strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>").Replace(s)
+}
+
+// buildTSQuery 把用户输入的搜索词转换为 to_tsquery 的参数
+// 普通词按前缀匹配（word:*），双引号中的内容按短语匹配（a <-> b），各部分之间为 AND
+// 所有 tsquery 的特殊字符都会被去掉，结果为空表示没有可搜索的词
+func buildTSQuery(q string) string {
+	var parts []string
+	for i, segment := range strings.Split(q, `"`) {
+		// 引号把输入切成交替的“普通/短语”片段
+		inPhrase := i%2 == 1
+		words := tsWords(segment)
+		if len(words) == 0 {
+			continue
+		}
+		if inPhrase {
+			parts = append(parts, "("+strings.Join(words, " <-> ")+")")
+			continue
+		}
+		for _, w := range words {
+			parts = append(parts, w+":*")
+		}
+	}
+	return strings.Join(parts, " & ")
+}
+
+// tsWords 把片段拆成只包含字母和数字的词
+func tsWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
@@ -0,0 +1,29 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBuildTSQuery 测试前缀匹配、短语和特殊字符的处理
+func TestBuildTSQuery(t *testing.T) {
+	cases := map[string]string{
+		"report":                   "report:*",
+		"Quarterly report":         "quarterly:* & report:*",
+		`"release notes" draft`:    "(release <-> notes) & draft:*",
+		`fix & (drop | table)':*!`: "fix:* & drop:* & table:*",
+		`"unterminated phrase`:     "(unterminated <-> phrase)",
+		"   ":                      "",
+	}
+	for input, want := range cases {
+		assert.Equal(t, want, buildTSQuery(input), input)
+	}
+}
+
+// TestHighlightSnippet 任务文本中的 HTML 被转义，只有匹配的词被 <mark> 包裹
+func TestHighlightSnippet(t *testing.T) {
+	raw := `<img src=x onerror=alert(1)> ` + headlineStart + `report` + headlineStop + ` & "notes"`
+	assert.Equal(t, `&lt;img src=x onerror=alert(1)&gt; <mark>report</mark> &amp; &#34;notes&#34;`, highlightSnippet(raw))
+	assert.Equal(t, "", highlightSnippet(""))
+}
@@ -0,0 +1,35 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFillStatsSeries 测试按天和按周补齐没有数据的时间段
+func TestFillStatsSeries(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	assert.NoError(t, err)
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, loc) // 周三
+	to := time.Date(2024, 5, 4, 0, 0, 0, 0, loc)
+	counts := map[string]models.StatsBucket{
+		"2024-05-02": {Date: "2024-05-02", Created: 3, Completed: 1},
+	}
+
+	series := fillStatsSeries(counts, from, to, models.StatsByDay, time.Monday)
+	assert.Equal(t, []models.StatsBucket{
+		{Date: "2024-05-01"},
+		{Date: "2024-05-02", Created: 3, Completed: 1},
+		{Date: "2024-05-03"},
+	}, series)
+
+	// 按周时从 from 所在那一周的周一开始
+	series = fillStatsSeries(map[string]models.StatsBucket{}, from, to.AddDate(0, 0, 7), models.StatsByWeek, time.Monday)
+	assert.Equal(t, []models.StatsBucket{{Date: "2024-04-29"}, {Date: "2024-05-06"}}, series)
+
+	// 每周从周日开始
+	series = fillStatsSeries(map[string]models.StatsBucket{}, from, to.AddDate(0, 0, 7), models.StatsByWeek, time.Sunday)
+	assert.Equal(t, []models.StatsBucket{{Date: "2024-04-28"}, {Date: "2024-05-05"}}, series)
+}
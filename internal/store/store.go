@@ -1,21 +1,124 @@
 package store
 
-import(
+import (
 	"context"
 	"errors"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/filter"
 	"github.com/HywlEch/Todo_list/internal/models"
 )
 
 var ErrNotFound = errors.New("requested resource not found")
 var ErrUserExists = errors.New("user already exists")
+var ErrVersionConflict = errors.New("resource version conflict")
+var ErrInvalidMove = errors.New("invalid move target")
+var ErrUnknownProject = errors.New("project does not exist")
+var ErrInvalidStatus = errors.New("status is not part of the workflow")
+var ErrTransitionNotAllowed = errors.New("status transition not allowed")
+var ErrWIPLimitExceeded = errors.New("column WIP limit exceeded")
+var ErrStatusInUse = errors.New("status still has tasks")
+var ErrDependencyCycle = errors.New("dependency would create a cycle")
+var ErrTimerRunning = errors.New("a timer is already running")
+
+// ListOptions 分页参数
+type ListOptions struct {
+	Limit  int
+	Offset int
+}
+
+// TaskQuery 任务查询条件
+type TaskQuery struct {
+	Filter filter.Node //为 nil 时不过滤
+	Sort   string      //SortOrders 中的一个，为空时按手动排序
+}
+
+// TimeEntryQuery 时间记录查询条件，只返回在 [From, To) 内开始的记录
+type TimeEntryQuery struct {
+	TaskID int //为0时不过滤
+	From   time.Time
+	To     time.Time
+}
+
+// StatsQuery 统计查询条件，日期按 Location 时区划分
+type StatsQuery struct {
+	From     time.Time
+	To       time.Time
+	Interval  string //models.StatsByDay 或 models.StatsByWeek
+	Location  *time.Location
+	WeekStart time.Weekday //按周统计时每周的第一天
+}
+
+// SortOrders 支持的排序方式
+var SortOrders = map[string]string{
+	"manual":         "position ASC NULLS LAST, created_at DESC",
+	"created_desc":   "created_at DESC",
+	"created_asc":    "created_at ASC",
+	"updated_desc":   "updated_at DESC",
+	"completed_desc": "completed_at DESC NULLS LAST",
+	"due_asc":        "due_at ASC NULLS LAST, created_at DESC",
+	"due_desc":       "due_at DESC NULLS LAST, created_at DESC",
+	"priority_desc":  "priority DESC, created_at DESC",
+	"title_asc":      "title ASC",
+}
+
 // Store 是我们数据存储层的接口
 type Store interface {
-	CreateUser(ctx context.Context,user *models.User) error
+	CreateUser(ctx context.Context, user *models.User) error
 	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	UpdateUserProfile(ctx context.Context, user *models.User) error
 
-	CreateTask(ctx context.Context,task *models.Task) error
+	CreateTask(ctx context.Context, task *models.Task) error
 	GetTasks(ctx context.Context, userId int) ([]models.Task, error)
+	QueryTasks(ctx context.Context, userID int, q TaskQuery) ([]models.Task, error)
 	GetTaskByID(ctx context.Context, id int, userId int) (*models.Task, error)
 	UpdateTask(ctx context.Context, task *models.Task) error
-	DeleteTask(ctx context.Context, id int, userId int) error
-}
\ No newline at end of file
+	PatchTask(ctx context.Context, task *models.Task, fields []string) error
+	DeleteTask(ctx context.Context, id int, userId int, version int) error
+	BulkApply(ctx context.Context, userID int, ops []models.BulkOperation, atomic bool) ([]models.BulkResult, error)
+	SearchTasks(ctx context.Context, userID int, q string, opts ListOptions) ([]models.SearchResult, int, error)
+	MoveTask(ctx context.Context, id int, userID int, version int, beforeID int, afterID int) (*models.Task, error)
+	RebalancePositions(ctx context.Context, maxKeyLength int) (map[int][]int, error)
+
+	AddDependency(ctx context.Context, userID int, blockerID int, blockedID int) (*models.Dependency, error)
+	RemoveDependency(ctx context.Context, userID int, taskID int, otherID int) error
+	GetDependencyGraph(ctx context.Context, taskID int, userID int) (*models.DependencyGraph, error)
+	GetDependents(ctx context.Context, taskID int, userID int) ([]int, error)
+
+	StartTimer(ctx context.Context, entry *models.TimeEntry) error
+	StopTimer(ctx context.Context, userID int, note *string) (*models.TimeEntry, error)
+	GetRunningTimer(ctx context.Context, userID int) (*models.TimeEntry, error)
+	GetTimeEntries(ctx context.Context, userID int, q TimeEntryQuery, opts ListOptions) ([]models.TimeEntry, int, error)
+	GetTimeReport(ctx context.Context, userID int, from, to time.Time, groupBy string, tz string) ([]models.TimeReportRow, error)
+
+	GetStats(ctx context.Context, userID int, q StatsQuery) (*models.Stats, error)
+
+	CreateProject(ctx context.Context, project *models.Project) error
+	GetProjects(ctx context.Context, userID int) ([]models.Project, error)
+	GetProject(ctx context.Context, id int, userID int) (*models.Project, error)
+	UpdateProject(ctx context.Context, project *models.Project) error
+	DeleteProject(ctx context.Context, id int, userID int) error
+	GetProjectTasks(ctx context.Context, projectID int, userID int) ([]models.Task, error)
+
+	CreateView(ctx context.Context, view *models.SavedView) error
+	GetViews(ctx context.Context, userID int) ([]models.SavedView, error)
+	GetView(ctx context.Context, id int, userID int) (*models.SavedView, error)
+	UpdateView(ctx context.Context, view *models.SavedView) error
+	DeleteView(ctx context.Context, id int, userID int) error
+
+	GetTrash(ctx context.Context, userID int) ([]models.Task, error)
+	RestoreTask(ctx context.Context, id int, userID int) error
+	PurgeTask(ctx context.Context, id int, userID int) error
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error)
+
+	CreateComment(ctx context.Context, comment *models.Comment) error
+	GetComments(ctx context.Context, taskID int, opts ListOptions) ([]models.Comment, int, error)
+	UpdateComment(ctx context.Context, comment *models.Comment) error
+	DeleteComment(ctx context.Context, id int, taskID int, userID int) error
+	GetActivityFeed(ctx context.Context, taskID int, opts ListOptions) ([]models.FeedItem, int, error)
+
+	GetTaskRevisions(ctx context.Context, taskID int, opts ListOptions) ([]models.TaskRevision, int, error)
+	GetTaskRevision(ctx context.Context, taskID int, revision int) (*models.TaskRevision, error)
+	RestoreTaskRevision(ctx context.Context, task *models.Task, revision int) error
+}
@@ -0,0 +1,101 @@
+package web
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/i18n"
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// page 模板的数据，Title 是 i18n 的 key，模板中用 {{.T "key"}} 翻译文本
+type page struct {
+	Prefix string
+	Locale string
+	CSRF   string
+	Query  string //当前的过滤条件，例如 ?filter=done%3Afalse，链接和表单都带上它
+	User   *models.User
+	Title  string
+	Error  string
+	Fields map[string]string //字段名 -> 校验错误
+	Data   any
+
+	loc *time.Location
+}
+
+type loginData struct {
+	Username string
+	Next     string
+	Prompt   bool //从需要登录的页面跳转过来
+}
+
+type tasksData struct {
+	Tasks  []models.Task
+	Filter string
+	Form   taskForm
+}
+
+type editData struct {
+	Task *models.Task
+	Form taskForm
+}
+
+// T 翻译文本，params 为成对的参数名和值
+func (p *page) T(key string, params ...string) string {
+	var values map[string]string
+	if len(params) > 0 {
+		values = make(map[string]string, len(params)/2)
+		for i := 0; i+1 < len(params); i += 2 {
+			values[params[i]] = params[i+1]
+		}
+	}
+	return i18n.Translate(p.Locale, key, values)
+}
+
+// Field 返回字段的校验错误
+func (p *page) Field(name string) string {
+	return p.Fields[name]
+}
+
+// Date 按用户的时区显示日期
+func (p *page) Date(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.In(p.loc).Format("2006-01-02")
+}
+
+// Overdue 未完成且截止日期早于今天
+func (p *page) Overdue(task models.Task) bool {
+	if task.Done || task.DueAt == nil {
+		return false
+	}
+	now := time.Now().In(p.loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, p.loc)
+	return task.DueAt.Before(today)
+}
+
+func (p *page) Priorities() []int {
+	return []int{models.PriorityNone, models.PriorityLow, models.PriorityMedium, models.PriorityHigh, models.PriorityUrgent}
+}
+
+func (p *page) Priority(n int) string {
+	return p.T("web.priority_" + strconv.Itoa(n))
+}
+
+// setFormError 把校验错误转换为页面顶部的提示和每个字段下的错误，与 API 的 problem+json 使用同样的消息
+func (p *page) setFormError(c *gin.Context, appErr *apperrors.AppError) {
+	problem := middleware.NewProblem(c, appErr)
+	p.Error = problem.Detail
+	p.Fields = make(map[string]string, len(problem.Errors))
+	for _, fe := range problem.Errors {
+		name := strings.TrimPrefix(fe.Pointer, "/")
+		if _, ok := p.Fields[name]; !ok {
+			p.Fields[name] = fe.Detail
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	sessionCookie = "todo_session"
+	csrfCookie    = "todo_csrf"
+	csrfField     = "csrf_token"
+	csrfHeader    = "X-CSRF-Token"
+)
+
+// 会话保存在签名的 cookie 中，格式为 <用户ID>.<过期时间>.<签名>，服务端不保存状态
+// 退出登录只删除浏览器中的 cookie，与 JWT 一样在过期前不能吊销
+
+// sign 用 purpose 区分不同用途的签名，会话的签名不能当作 CSRF 令牌使用
+func (h *Handler) sign(purpose, value string) string {
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write([]byte(purpose + ":" + value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (h *Handler) setSession(c *gin.Context, userID int) {
+	expires := time.Now().Add(h.sessionTTL)
+	payload := fmt.Sprintf("%d.%d", userID, expires.Unix())
+	h.setCookie(c, sessionCookie, payload+"."+h.sign("session", payload), expires)
+}
+
+// session 返回 cookie 中的用户ID，没有登录或会话已过期时返回 false
+func (h *Handler) session(c *gin.Context) (int, bool) {
+	value, err := c.Cookie(sessionCookie)
+	if err != nil {
+		return 0, false
+	}
+	i := strings.LastIndex(value, ".")
+	if i < 0 {
+		return 0, false
+	}
+	payload, sig := value[:i], value[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(h.sign("session", payload))) {
+		return 0, false
+	}
+	userPart, expPart, ok := strings.Cut(payload, ".")
+	if !ok {
+		return 0, false
+	}
+	userID, err1 := strconv.Atoi(userPart)
+	exp, err2 := strconv.ParseInt(expPart, 10, 64)
+	if err1 != nil || err2 != nil || time.Now().Unix() >= exp {
+		return 0, false
+	}
+	return userID, true
+}
+
+func (h *Handler) clearSession(c *gin.Context) {
+	h.setCookie(c, sessionCookie, "", time.Unix(0, 0))
+}
+
+// csrfToken 返回放在表单中的 CSRF 令牌
+// 浏览器中的 todo_csrf cookie 保存随机值，令牌是它的签名，其他站点既读不到 cookie 也算不出令牌
+func (h *Handler) csrfToken(c *gin.Context) string {
+	secret, err := c.Cookie(csrfCookie)
+	if err != nil || secret == "" {
+		secret = h.rotateCSRF(c)
+	}
+	return h.sign("csrf", secret)
+}
+
+// rotateCSRF 生成新的 CSRF 随机值，登录后调用，登录前拿到的令牌随之失效
+func (h *Handler) rotateCSRF(c *gin.Context) string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("web: read random bytes: %v", err))
+	}
+	secret := base64.RawURLEncoding.EncodeToString(b)
+	h.setCookie(c, csrfCookie, secret, time.Time{})
+	//同一个请求中稍后生成的令牌也要使用新的值
+	c.Request.Header.Set("Cookie", replaceCookie(c.Request.Header.Get("Cookie"), csrfCookie, secret))
+	return secret
+}
+
+// checkCSRF 校验表单或 X-CSRF-Token 头中的令牌，Origin 头存在时还要求与当前站点相同
+func (h *Handler) checkCSRF(c *gin.Context) bool {
+	if origin := c.GetHeader("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host != c.Request.Host {
+			return false
+		}
+	}
+	secret, err := c.Cookie(csrfCookie)
+	if err != nil || secret == "" {
+		return false
+	}
+	token := c.PostForm(csrfField)
+	if token == "" {
+		token = c.GetHeader(csrfHeader)
+	}
+	return hmac.Equal([]byte(token), []byte(h.sign("csrf", secret)))
+}
+
+// setCookie 设置只在 /app 下发送的 cookie，expires 为零时是会话 cookie
+func (h *Handler) setCookie(c *gin.Context, name, value string, expires time.Time) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     h.prefix,
+		HttpOnly: true,
+		Secure:   c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	}
+	if !expires.IsZero() {
+		cookie.Expires = expires
+		if expires.Before(time.Now()) {
+			cookie.MaxAge = -1
+		}
+	}
+	http.SetCookie(c.Writer, cookie)
+}
+
+func replaceCookie(header, name, value string) string {
+	var parts []string
+	for _, part := range strings.Split(header, ";") {
+		part = strings.TrimSpace(part)
+		if part != "" && !strings.HasPrefix(part, name+"=") {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(append(parts, name+"="+value), "; ")
+}
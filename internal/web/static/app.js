@@ -0,0 +1,49 @@
+// 渐进增强：切换完成状态和删除用 fetch 提交，失败时退回普通的表单提交
+(function () {
+  "use strict";
+
+  function submit(form) {
+    var body = new URLSearchParams(new FormData(form));
+    return fetch(form.action, {
+      method: "POST",
+      body: body,
+      credentials: "same-origin",
+      headers: { "Accept": "application/json", "X-CSRF-Token": body.get("csrf_token") || "" }
+    }).then(function (resp) {
+      if (!resp.ok) throw new Error("HTTP " + resp.status);
+      return resp.json();
+    });
+  }
+
+  function toggled(form, data) {
+    var item = form.closest(".task");
+    var label = data.done ? form.dataset.labelUndone : form.dataset.labelDone;
+    var button = form.querySelector("button");
+    item.classList.toggle("done", data.done);
+    if (data.done) item.classList.remove("overdue");
+    form.elements.done.value = String(!data.done);
+    button.setAttribute("aria-pressed", String(data.done));
+    button.title = label;
+    button.querySelector(".sr").textContent = label;
+  }
+
+  document.addEventListener("submit", function (event) {
+    var form = event.target;
+    var kind = form.dataset && form.dataset.enhance;
+    if (!kind || !window.fetch) return;
+    event.preventDefault();
+    if (kind === "delete" && !window.confirm(form.dataset.confirm)) return;
+    if (form.dataset.busy) return;
+    form.dataset.busy = "1";
+    submit(form).then(function (data) {
+      delete form.dataset.busy;
+      if (kind === "toggle") {
+        toggled(form, data);
+      } else {
+        form.closest(".task").remove();
+      }
+    }).catch(function () {
+      form.submit();
+    });
+  });
+})();
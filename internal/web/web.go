@@ -0,0 +1,508 @@
+// Package web 是挂载在 /app 下的网页界面，使用 html/template 在服务端渲染
+//
+// 页面只依赖二进制中嵌入的模板、CSS 和 JS，不使用外部 CDN。
+// 所有操作都是普通的表单提交，没有 JavaScript 时也能使用；
+// 有 JavaScript 时切换完成状态和删除通过 fetch 提交，不刷新整个页面。
+package web
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+	"github.com/HywlEch/Todo_list/internal/config"
+	"github.com/HywlEch/Todo_list/internal/filter"
+	"github.com/HywlEch/Todo_list/internal/i18n"
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"golang.org/x/crypto/bcrypt"
+)
+
+//go:embed templates/*.html static/*
+var assets embed.FS
+
+// pageNames 每个页面与 layout.html、form.html 一起解析
+var pageNames = []string{"login.html", "tasks.html", "edit.html", "error.html"}
+
+const userKey = "web_user"
+
+// Handler 网页界面的 handler
+type Handler struct {
+	Store      store.Store
+	secret     []byte //签名会话和 CSRF 令牌的密钥，由 JWT 密钥派生
+	sessionTTL time.Duration
+	prefix     string //挂载的路径，在 Register 时确定
+	pages      map[string]*template.Template
+}
+
+// NewHandler 创建网页界面的 handler，会话的有效期与 JWT 相同
+func NewHandler(s store.Store, jwtCfg config.JWTConfig) (*Handler, error) {
+	pages := make(map[string]*template.Template, len(pageNames))
+	for _, name := range pageNames {
+		tmpl, err := template.ParseFS(assets, "templates/layout.html", "templates/form.html", "templates/"+name)
+		if err != nil {
+			return nil, fmt.Errorf("web: parse %s: %w", name, err)
+		}
+		pages[name] = tmpl
+	}
+	//派生单独的密钥，会话 cookie 的签名不能当作 JWT 使用
+	mac := hmac.New(sha256.New, []byte(jwtCfg.Secret))
+	mac.Write([]byte("todo web session"))
+	ttl := time.Duration(jwtCfg.ExpiresInHours) * time.Hour
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &Handler{Store: s, secret: mac.Sum(nil), sessionTTL: ttl, pages: pages}, nil
+}
+
+// Register 在 r 下注册网页界面的路由
+func (h *Handler) Register(r *gin.RouterGroup) {
+	h.prefix = strings.TrimSuffix(r.BasePath(), "/")
+	r.Use(securityHeaders)
+
+	static, _ := fs.Sub(assets, "static")
+	files := http.StripPrefix(h.prefix+"/static/", http.FileServer(http.FS(static)))
+	r.GET("/static/*file", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=3600")
+		files.ServeHTTP(c.Writer, c.Request)
+	})
+
+	r.GET("/login", h.LoginPage)
+	r.POST("/login", h.requireCSRF, h.Login)
+
+	authed := r.Group("", h.requireSession)
+	authed.GET("", h.ListTasks)
+	authed.GET("/", h.ListTasks)
+	authed.POST("/logout", h.requireCSRF, h.Logout)
+	authed.POST("/tasks", h.requireCSRF, h.CreateTask)
+	authed.GET("/tasks/:id/edit", h.EditTask)
+	authed.POST("/tasks/:id", h.requireCSRF, h.UpdateTask)
+	authed.POST("/tasks/:id/toggle", h.requireCSRF, h.ToggleTask)
+	authed.POST("/tasks/:id/delete", h.requireCSRF, h.DeleteTask)
+}
+
+// securityHeaders 页面只加载本站的资源，也不允许被嵌入其他站点
+func securityHeaders(c *gin.Context) {
+	c.Header("Content-Security-Policy", "default-src 'self'; img-src 'self' data:; object-src 'none'; base-uri 'none'; form-action 'self'; frame-ancestors 'none'")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Referrer-Policy", "same-origin")
+	c.Next()
+}
+
+// requireSession 没有登录时跳转到登录页，登录后回到原来的页面
+func (h *Handler) requireSession(c *gin.Context) {
+	userID, ok := h.session(c)
+	var user *models.User
+	if ok {
+		var err error
+		user, err = h.Store.GetUserByID(c.Request.Context(), userID)
+		if errors.Is(err, store.ErrNotFound) {
+			h.clearSession(c)
+			ok = false
+		} else if err != nil {
+			h.renderError(c, err)
+			c.Abort()
+			return
+		}
+	}
+	if !ok {
+		if wantsJSON(c) {
+			h.renderError(c, apperrors.NewUnauthorizedError(apperrors.CodeAuthUnauthenticated, nil))
+			c.Abort()
+			return
+		}
+		next := h.prefix + "/"
+		if c.Request.Method == http.MethodGet {
+			next = c.Request.URL.RequestURI()
+		}
+		c.Redirect(http.StatusSeeOther, h.prefix+"/login?next="+url.QueryEscape(next))
+		c.Abort()
+		return
+	}
+	//Locale 按 user_id 读取用户资料中的语言
+	c.Set("user_id", userID)
+	c.Set(userKey, user)
+	c.Next()
+}
+
+func (h *Handler) requireCSRF(c *gin.Context) {
+	if !h.checkCSRF(c) {
+		h.renderError(c, apperrors.NewForbiddenError(apperrors.CodeAuthCSRFInvalid, nil))
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+func currentUser(c *gin.Context) *models.User {
+	user, _ := c.Value(userKey).(*models.User)
+	return user
+}
+
+// LoginPage 显示登录表单，已登录时直接跳转
+func (h *Handler) LoginPage(c *gin.Context) {
+	next := h.safeNext(c.Query("next"))
+	if _, ok := h.session(c); ok {
+		c.Redirect(http.StatusSeeOther, next)
+		return
+	}
+	h.render(c, http.StatusOK, "login.html", &page{Title: "web.login", Data: loginData{Next: next, Prompt: c.Query("next") != ""}})
+}
+
+// Login 校验用户名和密码，成功后设置会话 cookie
+func (h *Handler) Login(c *gin.Context) {
+	username, password := strings.TrimSpace(c.PostForm("username")), c.PostForm("password")
+	next := h.safeNext(c.PostForm("next"))
+	user, err := h.Store.GetUserByUsername(c.Request.Context(), username)
+	if err == nil {
+		err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	}
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) && !errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			h.renderError(c, err)
+			return
+		}
+		appErr := apperrors.NewUnauthorizedError(apperrors.CodeAuthInvalidCredentials, err)
+		h.render(c, http.StatusUnauthorized, "login.html", &page{
+			Title: "web.login",
+			Error: middleware.Message(c, appErr),
+			Data:  loginData{Username: username, Next: next},
+		})
+		return
+	}
+	h.setSession(c, user.ID)
+	h.rotateCSRF(c)
+	c.Redirect(http.StatusSeeOther, next)
+}
+
+// Logout 删除会话 cookie
+func (h *Handler) Logout(c *gin.Context) {
+	h.clearSession(c)
+	c.Redirect(http.StatusSeeOther, h.prefix+"/login")
+}
+
+// ListTasks 任务列表，?filter= 与 API 使用同样的过滤查询语言
+func (h *Handler) ListTasks(c *gin.Context) {
+	h.showList(c, http.StatusOK, taskForm{}, nil)
+}
+
+// showList 显示任务列表和新建任务的表单，formErr 不为空时表单中带有用户输入和错误信息
+func (h *Handler) showList(c *gin.Context, status int, form taskForm, formErr *apperrors.AppError) {
+	user := currentUser(c)
+	expr := c.Query("filter")
+	p := &page{Title: "web.tasks"}
+	q := store.TaskQuery{Sort: user.DefaultSort}
+	if _, ok := store.SortOrders[q.Sort]; !ok {
+		q.Sort = "manual"
+	}
+	if expr != "" {
+		node, err := filter.ParseWithWeekStart(expr, user.Now(), time.Weekday(user.WeekStart))
+		if err != nil {
+			appErr := filter.AppError(err)
+			p.Error = middleware.Message(c, appErr)
+			p.Data = tasksData{Filter: expr, Form: form}
+			h.render(c, http.StatusBadRequest, "tasks.html", p)
+			return
+		}
+		q.Filter = node
+	}
+	var tasks []models.Task
+	var err error
+	if q.Filter != nil || q.Sort != "manual" {
+		tasks, err = h.Store.QueryTasks(c.Request.Context(), user.ID, q)
+	} else {
+		tasks, err = h.Store.GetTasks(c.Request.Context(), user.ID)
+	}
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+	if formErr != nil {
+		p.setFormError(c, formErr)
+	}
+	p.Data = tasksData{Tasks: tasks, Filter: expr, Form: form}
+	h.render(c, status, "tasks.html", p)
+}
+
+// CreateTask 新建任务，表单有错误时重新显示列表和用户的输入
+func (h *Handler) CreateTask(c *gin.Context) {
+	user := currentUser(c)
+	form, task, appErr := bindTaskForm(c, user.Location())
+	if appErr != nil {
+		h.showList(c, http.StatusUnprocessableEntity, form, appErr)
+		return
+	}
+	task.UserID = user.ID
+	if err := h.Store.CreateTask(c.Request.Context(), &task); err != nil {
+		h.renderError(c, err)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, h.listURL(c))
+}
+
+// EditTask 显示编辑表单
+func (h *Handler) EditTask(c *gin.Context) {
+	user := currentUser(c)
+	task, ok := h.loadTask(c, user)
+	if !ok {
+		return
+	}
+	form := newTaskForm(task, user.Location())
+	h.render(c, http.StatusOK, "edit.html", &page{Title: "web.edit", Data: editData{Task: task, Form: form}})
+}
+
+// UpdateTask 保存编辑表单，表单中的版本号与当前版本不同时提示冲突，不覆盖别人的修改
+func (h *Handler) UpdateTask(c *gin.Context) {
+	user := currentUser(c)
+	current, ok := h.loadTask(c, user)
+	if !ok {
+		return
+	}
+	form, doc, appErr := bindTaskForm(c, user.Location())
+	if appErr != nil {
+		p := &page{Title: "web.edit", Data: editData{Task: current, Form: form}}
+		p.setFormError(c, appErr)
+		h.render(c, http.StatusUnprocessableEntity, "edit.html", p)
+		return
+	}
+
+	//表单只显示截止日期，日期没有改时保留原来的时间，不把它改成当天0点
+	if sameDay(doc.DueAt, current.DueAt, user.Location()) {
+		doc.DueAt = current.DueAt
+	}
+	task := *current
+	task.Title, task.Content, task.Done = doc.Title, doc.Content, doc.Done
+	task.DueAt, task.Priority, task.Tags = doc.DueAt, doc.Priority, doc.Tags
+	fields := models.ChangedFields(current, &task)
+	if len(fields) > 0 {
+		task.Version = form.Version
+		err := h.Store.PatchTask(c.Request.Context(), &task, fields)
+		if errors.Is(err, store.ErrVersionConflict) {
+			//显示用户的输入和最新的版本号，再次保存时覆盖
+			latest, ok := h.loadTask(c, user)
+			if !ok {
+				return
+			}
+			form.Version = latest.Version
+			p := &page{Title: "web.edit", Data: editData{Task: latest, Form: form}}
+			p.Error = i18n.Translate(middleware.Locale(c), "web.version_conflict", nil)
+			h.render(c, http.StatusConflict, "edit.html", p)
+			return
+		}
+		if err != nil {
+			h.renderError(c, err)
+			return
+		}
+	}
+	c.Redirect(http.StatusSeeOther, h.listURL(c))
+}
+
+// ToggleTask 把任务标记为完成或未完成，表单中的 done 是目标状态，重复提交结果相同
+func (h *Handler) ToggleTask(c *gin.Context) {
+	user := currentUser(c)
+	current, ok := h.loadTask(c, user)
+	if !ok {
+		return
+	}
+	task := *current
+	task.Done = c.PostForm("done") == "true"
+	if task.Done != current.Done {
+		if err := h.Store.PatchTask(c.Request.Context(), &task, []string{"done"}); err != nil {
+			h.renderError(c, err)
+			return
+		}
+	}
+	if wantsJSON(c) {
+		c.JSON(http.StatusOK, gin.H{"id": task.ID, "done": task.Done})
+		return
+	}
+	c.Redirect(http.StatusSeeOther, h.listURL(c))
+}
+
+// DeleteTask 把任务移到回收站
+func (h *Handler) DeleteTask(c *gin.Context) {
+	user := currentUser(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.renderError(c, apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
+		return
+	}
+	if err := h.Store.DeleteTask(c.Request.Context(), id, user.ID, 0); err != nil {
+		h.renderError(c, err)
+		return
+	}
+	if wantsJSON(c) {
+		c.JSON(http.StatusOK, gin.H{"id": id, "deleted": true})
+		return
+	}
+	c.Redirect(http.StatusSeeOther, h.listURL(c))
+}
+
+func (h *Handler) loadTask(c *gin.Context, user *models.User) (*models.Task, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.renderError(c, apperrors.NewBadRequestError(apperrors.CodeInvalidID, err))
+		return nil, false
+	}
+	task, err := h.Store.GetTaskByID(c.Request.Context(), id, user.ID)
+	if err != nil {
+		h.renderError(c, err)
+		return nil, false
+	}
+	return task, true
+}
+
+// listURL 返回任务列表的地址，保留当前的过滤条件
+func (h *Handler) listURL(c *gin.Context) string {
+	return h.prefix + "/" + filterQuery(c.Query("filter"))
+}
+
+func filterQuery(expr string) string {
+	if expr == "" {
+		return ""
+	}
+	return "?" + url.Values{"filter": {expr}}.Encode()
+}
+
+// safeNext 只允许跳转到本站 /app 下的页面，避免被用作开放重定向
+func (h *Handler) safeNext(next string) string {
+	if next == "" || strings.HasPrefix(next, "//") || strings.Contains(next, `\`) ||
+		(next != h.prefix && !strings.HasPrefix(next, h.prefix+"/") && !strings.HasPrefix(next, h.prefix+"?")) {
+		return h.prefix + "/"
+	}
+	return next
+}
+
+func wantsJSON(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), "application/json")
+}
+
+// renderError 显示错误页面，状态码和消息与 API 的错误响应相同，fetch 请求返回 problem+json
+func (h *Handler) renderError(c *gin.Context, err error) {
+	appErr := middleware.ToAppError(err)
+	if appErr.Code >= http.StatusInternalServerError {
+		log.Printf("Internal Server Error: %v", err)
+	}
+	if wantsJSON(c) {
+		middleware.AbortWithProblem(c, appErr)
+		return
+	}
+	h.render(c, appErr.Code, "error.html", &page{Title: "web.error", Error: middleware.Message(c, appErr)})
+}
+
+// render 渲染页面，先写到缓冲区，模板出错时不会输出半个页面
+func (h *Handler) render(c *gin.Context, status int, name string, p *page) {
+	p.Prefix = h.prefix
+	p.Locale = middleware.Locale(c)
+	p.User = currentUser(c)
+	p.CSRF = h.csrfToken(c)
+	p.Query = filterQuery(c.Query("filter"))
+	if p.User != nil {
+		p.loc = p.User.Location()
+	} else {
+		p.loc = time.Local
+	}
+	var buf bytes.Buffer
+	if err := h.pages[name].ExecuteTemplate(&buf, "layout", p); err != nil {
+		log.Printf("web: render %s: %v", name, err)
+		c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	//页面中有用户的数据，不能被缓存
+	c.Header("Cache-Control", "no-store")
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// taskForm 新建和编辑任务的表单，字段名与 API 的 TaskRequest 相同，校验错误显示在对应字段下
+type taskForm struct {
+	Title    string `form:"title" json:"title" binding:"required,max=200"`
+	Content  string `form:"content" json:"content" binding:"max=20000"`
+	Due      string `form:"due" json:"due"` //2006-01-02，按用户的时区解释
+	Priority int    `form:"priority" json:"priority" binding:"min=0,max=4"`
+	Tags     string `form:"tags" json:"tags"` //用逗号分隔
+	Done     bool   `form:"done" json:"done"`
+	Version  int    `form:"version" json:"-"`
+}
+
+func newTaskForm(task *models.Task, loc *time.Location) taskForm {
+	form := taskForm{
+		Title:    task.Title,
+		Content:  task.Content,
+		Priority: task.Priority,
+		Tags:     strings.Join(task.Tags, ", "),
+		Done:     task.Done,
+		Version:  task.Version,
+	}
+	if task.DueAt != nil {
+		form.Due = task.DueAt.In(loc).Format("2006-01-02")
+	}
+	return form
+}
+
+// bindTaskForm 解析并校验表单，返回用户的输入和对应的任务
+func bindTaskForm(c *gin.Context, loc *time.Location) (taskForm, models.Task, *apperrors.AppError) {
+	var form taskForm
+	if err := c.ShouldBindWith(&form, binding.Form); err != nil {
+		return form, models.Task{}, apperrors.NewBadRequestError(apperrors.CodeInvalidBody, err)
+	}
+	var violations apperrors.FieldViolations
+	task := models.Task{
+		Title:    strings.TrimSpace(form.Title),
+		Content:  form.Content,
+		Done:     form.Done,
+		Priority: form.Priority,
+	}
+	if task.Title == "" {
+		violations = append(violations, apperrors.FieldViolation{Field: "title", Rule: "notblank"})
+	}
+	if form.Due != "" {
+		due, err := time.ParseInLocation("2006-01-02", form.Due, loc)
+		if err != nil {
+			violations = append(violations, apperrors.FieldViolation{Field: "due", Rule: "invalid"})
+		}
+		task.DueAt = &due
+	}
+	for _, tag := range strings.Split(form.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" && !slices.Contains(task.Tags, tag) {
+			task.Tags = append(task.Tags, tag)
+		}
+	}
+	if len(task.Tags) > 20 {
+		violations = append(violations, apperrors.FieldViolation{Field: "tags", Rule: "max_length", Param: "20"})
+	}
+	for _, tag := range task.Tags {
+		if len([]rune(tag)) > 50 {
+			violations = append(violations, apperrors.FieldViolation{Field: "tags", Rule: "max_length", Param: "50"})
+			break
+		}
+	}
+	if len(violations) > 0 {
+		return form, task, apperrors.NewBadRequestError(apperrors.CodeInvalidBody, violations)
+	}
+	return form, task, nil
+}
+
+// sameDay 两个时间在 loc 中是否是同一天
+func sameDay(a, b *time.Time, loc *time.Location) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	return a.In(loc).Format("2006-01-02") == b.In(loc).Format("2006-01-02")
+}
@@ -0,0 +1,444 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/config"
+	"github.com/HywlEch/Todo_list/internal/middleware"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var csrfPattern = regexp.MustCompile(`name="csrf_token" value="([^"]+)"`)
+
+// browser 保存 cookie 的测试客户端
+type browser struct {
+	t       *testing.T
+	h       *Handler
+	router  *gin.Engine
+	cookies map[string]string
+}
+
+func newBrowser(t *testing.T, ms *store.MockStore) *browser {
+	gin.SetMode(gin.TestMode)
+	h, err := NewHandler(ms, config.JWTConfig{Secret: "test-secret", ExpiresInHours: 1})
+	require.NoError(t, err)
+	router := gin.New()
+	router.Use(middleware.LocaleMiddleware(ms))
+	h.Register(router.Group("/app"))
+	return &browser{t: t, h: h, router: router, cookies: map[string]string{}}
+}
+
+func (b *browser) do(method, target string, form url.Values, header http.Header) *httptest.ResponseRecorder {
+	var req *http.Request
+	if form != nil {
+		req = httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req = httptest.NewRequest(method, target, nil)
+	}
+	req.Header.Set("Accept-Language", "en")
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	for name, value := range b.cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	w := httptest.NewRecorder()
+	b.router.ServeHTTP(w, req)
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.MaxAge < 0 {
+			delete(b.cookies, cookie.Name)
+		} else {
+			b.cookies[cookie.Name] = cookie.Value
+		}
+	}
+	return w
+}
+
+// csrf 打开页面并取出表单中的 CSRF 令牌
+func (b *browser) csrf(target string) string {
+	w := b.do(http.MethodGet, target, nil, nil)
+	m := csrfPattern.FindStringSubmatch(w.Body.String())
+	require.NotNil(b.t, m, w.Body.String())
+	return m[1]
+}
+
+// login 以 alice 的身份登录
+func (b *browser) login(header http.Header) *httptest.ResponseRecorder {
+	w := b.do(http.MethodPost, "/app/login", url.Values{
+		"csrf_token": {b.csrf("/app/login")}, "username": {"alice"}, "password": {"secret123"},
+	}, header)
+	require.Equal(b.t, http.StatusSeeOther, w.Code)
+	return w
+}
+
+// newAliceStore 返回能以 alice 登录并打开空的任务列表的 MockStore
+func newAliceStore() *store.MockStore {
+	ms := store.NewMockStore()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	user := &models.User{ID: 1, Username: "alice", PasswordHash: string(hash), Timezone: "UTC", DefaultSort: "manual"}
+	ms.On("GetUserByUsername", mock.Anything, "alice").Return(user, nil)
+	ms.On("GetUserByID", mock.Anything, 1).Return(user, nil)
+	ms.On("GetTasks", mock.Anything, 1).Return([]models.Task{}, nil)
+	return ms
+}
+
+// assertProblem 检查 fetch 请求收到的是 problem+json
+func assertProblem(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	assert.Equal(t, status, w.Code)
+	assert.Equal(t, middleware.ProblemContentType, w.Header().Get("Content-Type"))
+	var problem middleware.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem), w.Body.String())
+	assert.Equal(t, "/problems/"+code, problem.Type)
+	assert.Equal(t, code, problem.Code)
+	assert.NotEmpty(t, problem.Detail)
+}
+
+func TestLoginAndTasks(t *testing.T) {
+	ms := store.NewMockStore()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	user := &models.User{ID: 1, Username: "alice", PasswordHash: string(hash), Timezone: "UTC", DefaultSort: "manual"}
+	ms.On("GetUserByUsername", mock.Anything, "alice").Return(user, nil)
+	ms.On("GetUserByID", mock.Anything, 1).Return(user, nil)
+	b := newBrowser(t, ms)
+
+	//静态文件不需要登录
+	w := b.do(http.MethodGet, "/app/static/app.js", nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "javascript")
+
+	//未登录时跳转到登录页
+	w = b.do(http.MethodGet, "/app/?filter=done%3Afalse", nil, nil)
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/app/login?next=%2Fapp%2F%3Ffilter%3Ddone%253Afalse", w.Header().Get("Location"))
+
+	//没有 CSRF 令牌时拒绝登录
+	form := url.Values{"username": {"alice"}, "password": {"secret123"}}
+	w = b.do(http.MethodPost, "/app/login", form, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	form.Set("csrf_token", b.csrf("/app/login"))
+	form.Set("password", "wrong")
+	w = b.do(http.MethodPost, "/app/login", form, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `value="alice"`, "重新显示用户名")
+
+	//next 不能跳转到其他站点
+	form.Set("password", "secret123")
+	form.Set("next", "//evil.example/")
+	w = b.do(http.MethodPost, "/app/login", form, nil)
+	require.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/app/", w.Header().Get("Location"))
+	assert.Contains(t, b.cookies, sessionCookie)
+
+	ms.On("GetTasks", mock.Anything, 1).Return([]models.Task{
+		{ID: 7, UserID: 1, Title: "<b>write</b> report", Tags: []string{"work"}, Version: 1},
+	}, nil)
+	w = b.do(http.MethodGet, "/app/", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, "&lt;b&gt;write&lt;/b&gt; report", "标题经过转义")
+	assert.Contains(t, body, "#work")
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	//登录前的令牌已失效
+	w = b.do(http.MethodPost, "/app/tasks", url.Values{"csrf_token": {form.Get("csrf_token")}, "title": {"x"}}, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	token := b.csrf("/app/")
+
+	//校验错误显示在字段下，保留用户的输入
+	w = b.do(http.MethodPost, "/app/tasks", url.Values{"csrf_token": {token}, "title": {"  "}, "content": {"notes"}}, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "must not be blank")
+	assert.Contains(t, w.Body.String(), ">notes</textarea>")
+
+	ms.On("CreateTask", mock.Anything, mock.MatchedBy(func(task *models.Task) bool {
+		return task.UserID == 1 && task.Title == "buy milk" && task.Priority == 3 &&
+			len(task.Tags) == 2 && task.DueAt != nil && task.DueAt.Format("2006-01-02") == "2026-03-01"
+	})).Return(nil).Once()
+	w = b.do(http.MethodPost, "/app/tasks?filter=tag%3Ahome", url.Values{
+		"csrf_token": {token}, "title": {"buy milk"}, "priority": {"3"}, "due": {"2026-03-01"}, "tags": {"home, errands, home"},
+	}, nil)
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/app/?filter=tag%3Ahome", w.Header().Get("Location"), "回到过滤后的列表")
+
+	//fetch 提交时返回 JSON
+	ms.On("GetTaskByID", mock.Anything, 7, 1).Return(&models.Task{ID: 7, UserID: 1, Title: "write report", Version: 1}, nil).Once()
+	ms.On("PatchTask", mock.Anything, mock.MatchedBy(func(task *models.Task) bool {
+		return task.ID == 7 && task.Done
+	}), []string{"done"}).Return(nil).Once()
+	w = b.do(http.MethodPost, "/app/tasks/7/toggle", url.Values{"done": {"true"}}, http.Header{
+		"Accept": {"application/json"}, "X-Csrf-Token": {token}, "Origin": {"http://example.com"},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"id":7,"done":true}`, w.Body.String())
+
+	//跨站的请求即使带着令牌也拒绝
+	w = b.do(http.MethodPost, "/app/tasks/7/delete", url.Values{"csrf_token": {token}}, http.Header{"Origin": {"https://evil.example"}})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = b.do(http.MethodPost, "/app/logout", url.Values{"csrf_token": {token}}, nil)
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.NotContains(t, b.cookies, sessionCookie)
+	ms.AssertExpectations(t)
+}
+
+func TestUpdateTask_VersionConflict(t *testing.T) {
+	ms := store.NewMockStore()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	user := &models.User{ID: 1, Username: "alice", PasswordHash: string(hash), DefaultSort: "manual"}
+	ms.On("GetUserByUsername", mock.Anything, "alice").Return(user, nil)
+	ms.On("GetUserByID", mock.Anything, 1).Return(user, nil)
+	b := newBrowser(t, ms)
+	w := b.do(http.MethodPost, "/app/login", url.Values{
+		"csrf_token": {b.csrf("/app/login")}, "username": {"alice"}, "password": {"secret123"},
+	}, nil)
+	require.Equal(t, http.StatusSeeOther, w.Code)
+
+	latest := &models.Task{ID: 3, UserID: 1, Title: "changed elsewhere", Version: 5}
+	ms.On("GetTaskByID", mock.Anything, 3, 1).Return(latest, nil)
+	token := b.csrf("/app/tasks/3/edit")
+	ms.On("PatchTask", mock.Anything, mock.MatchedBy(func(task *models.Task) bool {
+		return task.Version == 4 && task.Title == "my edit"
+	}), []string{"title"}).Return(store.ErrVersionConflict).Once()
+
+	w = b.do(http.MethodPost, "/app/tasks/3", url.Values{"csrf_token": {token}, "version": {"4"}, "title": {"my edit"}}, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, "changed while you were editing")
+	assert.Contains(t, body, `value="my edit"`, "保留用户的输入")
+	assert.Contains(t, body, `name="version" value="5"`, "再次保存时使用最新的版本")
+	ms.AssertExpectations(t)
+}
+
+// TestUpdateTask_KeepsDueTime 只修改标题时不把截止时间改成当天0点
+func TestUpdateTask_KeepsDueTime(t *testing.T) {
+	ms := newAliceStore()
+	b := newBrowser(t, ms)
+	b.login(nil)
+
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	due := time.Date(2026, 3, 1, 17, 0, 0, 0, loc).UTC()
+	ms.On("GetTaskByID", mock.Anything, 3, 1).Return(&models.Task{ID: 3, UserID: 1, Title: "report", DueAt: &due, Version: 2}, nil)
+	token := b.csrf("/app/tasks/3/edit")
+	ms.On("PatchTask", mock.Anything, mock.MatchedBy(func(task *models.Task) bool {
+		return task.DueAt != nil && task.DueAt.Equal(due)
+	}), []string{"title"}).Return(nil).Once()
+
+	w := b.do(http.MethodPost, "/app/tasks/3", url.Values{
+		"csrf_token": {token}, "version": {"2"}, "title": {"final report"}, "due": {"2026-03-01"},
+	}, nil)
+	assert.Equal(t, http.StatusSeeOther, w.Code, w.Body.String())
+
+	//改了日期时按新的日期保存
+	ms.On("PatchTask", mock.Anything, mock.MatchedBy(func(task *models.Task) bool {
+		return task.DueAt != nil && task.DueAt.Format("2006-01-02") == "2026-03-02"
+	}), []string{"due_at"}).Return(nil).Once()
+	w = b.do(http.MethodPost, "/app/tasks/3", url.Values{
+		"csrf_token": {token}, "version": {"2"}, "title": {"report"}, "due": {"2026-03-02"},
+	}, nil)
+	assert.Equal(t, http.StatusSeeOther, w.Code, w.Body.String())
+	ms.AssertNumberOfCalls(t, "PatchTask", 2)
+}
+
+func TestSafeNext(t *testing.T) {
+	h := &Handler{prefix: "/app"}
+	tests := []struct {
+		name string
+		next string
+		want string
+	}{
+		{"为空", "", "/app/"},
+		{"本站页面", "/app/tasks/3/edit", "/app/tasks/3/edit"},
+		{"带查询参数", "/app?filter=done%3Afalse", "/app?filter=done%3Afalse"},
+		{"挂载路径本身", "/app", "/app"},
+		{"协议相对地址", "//evil.example/app/", "/app/"},
+		{"反斜杠", `/\evil.example/app/`, "/app/"},
+		{"反斜杠在中间", `/app/\evil`, "/app/"},
+		{"绝对地址", "https://evil.example/app/", "/app/"},
+		{"前缀相同的其他路径", "/application", "/app/"},
+		{"挂载路径之外", "/api/v1/tasks", "/app/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, h.safeNext(tt.next))
+		})
+	}
+}
+
+// TestCheckCSRF_Origin Origin 头存在时必须与当前站点相同
+func TestCheckCSRF_Origin(t *testing.T) {
+	tests := []struct {
+		name   string
+		origin string
+		status int
+	}{
+		{"没有 Origin", "", http.StatusOK},
+		{"同一站点", "http://example.com", http.StatusOK},
+		{"其他站点", "https://evil.example", http.StatusForbidden},
+		{"后缀相同的其他站点", "http://example.com.evil.example", http.StatusForbidden},
+		{"端口不同", "http://example.com:8080", http.StatusForbidden},
+		{"null", "null", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// ARRANGE
+			ms := newAliceStore()
+			ms.On("GetTaskByID", mock.Anything, 7, 1).Return(&models.Task{ID: 7, UserID: 1, Title: "write report", Version: 1}, nil)
+			b := newBrowser(t, ms)
+			b.login(nil)
+			token := b.csrf("/app/")
+			header := http.Header{"Accept": {"application/json"}, "X-Csrf-Token": {token}}
+			if tt.origin != "" {
+				header.Set("Origin", tt.origin)
+			}
+
+			// ACT
+			w := b.do(http.MethodPost, "/app/tasks/7/toggle", url.Values{"done": {"false"}}, header)
+
+			// ASSERT
+			if tt.status == http.StatusOK {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.JSONEq(t, `{"id":7,"done":false}`, w.Body.String())
+			} else {
+				assertProblem(t, w, tt.status, "auth.csrf_invalid")
+			}
+		})
+	}
+}
+
+func TestSessionCookie_Flags(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		secure bool
+	}{
+		{"HTTP", nil, false},
+		{"HTTPS 反向代理", http.Header{"X-Forwarded-Proto": {"https"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// ARRANGE
+			b := newBrowser(t, newAliceStore())
+
+			// ACT
+			w := b.login(tt.header)
+
+			// ASSERT
+			var session *http.Cookie
+			for _, cookie := range w.Result().Cookies() {
+				if cookie.Name == sessionCookie {
+					session = cookie
+				}
+			}
+			require.NotNil(t, session)
+			assert.True(t, session.HttpOnly)
+			assert.Equal(t, http.SameSiteLaxMode, session.SameSite)
+			assert.Equal(t, tt.secure, session.Secure)
+			assert.Equal(t, "/app", session.Path)
+			assert.True(t, session.Expires.After(time.Now()))
+		})
+	}
+}
+
+// TestRequireSession_InvalidCookie 过期或被篡改的会话 cookie 与没有登录相同
+func TestRequireSession_InvalidCookie(t *testing.T) {
+	b := newBrowser(t, newAliceStore())
+	future := time.Now().Add(time.Hour).Unix()
+	expired := fmt.Sprintf("1.%d", time.Now().Add(-time.Minute).Unix())
+	valid := fmt.Sprintf("1.%d", future)
+	b.cookies = map[string]string{sessionCookie: valid + "." + b.h.sign("session", valid)}
+	w := b.do(http.MethodGet, "/app/", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code, "签名正确且未过期的会话有效")
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"已过期", expired + "." + b.h.sign("session", expired)},
+		{"修改了用户ID", fmt.Sprintf("2.%d.", future) + b.h.sign("session", valid)},
+		{"延长了过期时间", fmt.Sprintf("1.%d.", future+3600) + b.h.sign("session", valid)},
+		{"CSRF 令牌的签名", valid + "." + b.h.sign("csrf", valid)},
+		{"没有签名", valid},
+		{"格式错误", "garbage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b.cookies = map[string]string{sessionCookie: tt.value}
+
+			w := b.do(http.MethodGet, "/app/", nil, nil)
+			assert.Equal(t, http.StatusSeeOther, w.Code)
+			assert.Equal(t, "/app/login?next=%2Fapp%2F", w.Header().Get("Location"))
+
+			w = b.do(http.MethodGet, "/app/", nil, http.Header{"Accept": {"application/json"}})
+			assertProblem(t, w, http.StatusUnauthorized, "auth.unauthenticated")
+		})
+	}
+}
+
+// TestTaskActions_JSON fetch 提交的切换和删除返回 JSON，错误返回 problem+json
+func TestTaskActions_JSON(t *testing.T) {
+	ms := newAliceStore()
+	ms.On("GetTaskByID", mock.Anything, 7, 1).Return(&models.Task{ID: 7, UserID: 1, Title: "write report", Version: 1}, nil)
+	ms.On("GetTaskByID", mock.Anything, 9, 1).Return(nil, store.ErrNotFound)
+	ms.On("PatchTask", mock.Anything, mock.MatchedBy(func(task *models.Task) bool {
+		return task.ID == 7 && task.Done
+	}), []string{"done"}).Return(nil)
+	ms.On("DeleteTask", mock.Anything, 7, 1, 0).Return(nil)
+	ms.On("DeleteTask", mock.Anything, 9, 1, 0).Return(store.ErrNotFound)
+
+	tests := []struct {
+		name   string
+		login  bool
+		target string
+		form   url.Values
+		status int
+		body   string
+		code   string
+	}{
+		{"未登录时切换", false, "/app/tasks/7/toggle", url.Values{"done": {"true"}}, http.StatusUnauthorized, "", "auth.unauthenticated"},
+		{"未登录时删除", false, "/app/tasks/7/delete", url.Values{}, http.StatusUnauthorized, "", "auth.unauthenticated"},
+		{"切换", true, "/app/tasks/7/toggle", url.Values{"done": {"true"}}, http.StatusOK, `{"id":7,"done":true}`, ""},
+		{"删除", true, "/app/tasks/7/delete", url.Values{}, http.StatusOK, `{"id":7,"deleted":true}`, ""},
+		{"切换不存在的任务", true, "/app/tasks/9/toggle", url.Values{"done": {"true"}}, http.StatusNotFound, "", "resource.not_found"},
+		{"删除不存在的任务", true, "/app/tasks/9/delete", url.Values{}, http.StatusNotFound, "", "resource.not_found"},
+		{"无效的ID", true, "/app/tasks/abc/delete", url.Values{}, http.StatusBadRequest, "", "request.invalid_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// ARRANGE
+			b := newBrowser(t, ms)
+			header := http.Header{"Accept": {"application/json"}}
+			if tt.login {
+				b.login(nil)
+				header.Set("X-Csrf-Token", b.csrf("/app/"))
+			}
+
+			// ACT
+			w := b.do(http.MethodPost, tt.target, tt.form, header)
+
+			// ASSERT
+			if tt.code != "" {
+				assertProblem(t, w, tt.status, tt.code)
+				return
+			}
+			assert.Equal(t, tt.status, w.Code)
+			assert.JSONEq(t, tt.body, w.Body.String())
+		})
+	}
+}
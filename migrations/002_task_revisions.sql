@@ -0,0 +1,19 @@
+-- 任务的历史版本，每次修改保存一份完整快照
+CREATE TABLE IF NOT EXISTS task_revisions (
+    id             SERIAL PRIMARY KEY,
+    task_id        INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    revision       INTEGER NOT NULL,
+    user_id        INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title          TEXT NOT NULL,
+    content        TEXT NOT NULL,
+    done           BOOLEAN NOT NULL,
+    changed_fields TEXT[] NOT NULL DEFAULT '{}',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (task_id, revision)
+);
+
+-- 为已有任务补上第一个版本
+INSERT INTO task_revisions (task_id, revision, user_id, title, content, done, changed_fields, created_at)
+SELECT t.id, 1, t.user_id, t.title, t.content, t.done, ARRAY['title', 'content', 'done'], t.updated_at
+FROM tasks t
+WHERE NOT EXISTS (SELECT 1 FROM task_revisions r WHERE r.task_id = t.id);
@@ -0,0 +1,2 @@
+-- 乐观锁版本号，每次修改加一，对外作为 ETag
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
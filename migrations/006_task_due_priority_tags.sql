@@ -0,0 +1,6 @@
+-- 截止时间、优先级（0无 1低 2中 3高 4紧急）和标签
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS due_at TIMESTAMPTZ;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+CREATE INDEX IF NOT EXISTS idx_tasks_user_due ON tasks (user_id, due_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_tags ON tasks USING GIN (tags);
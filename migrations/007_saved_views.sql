@@ -0,0 +1,16 @@
+-- 完成时间，用于“本周完成”等视图
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ;
+UPDATE tasks SET completed_at = updated_at WHERE done AND completed_at IS NULL;
+
+-- 保存的搜索（智能列表）
+CREATE TABLE IF NOT EXISTS saved_views (
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name       VARCHAR(100) NOT NULL,
+    filter     TEXT NOT NULL,
+    sort       VARCHAR(32) NOT NULL DEFAULT 'created_desc',
+    pinned     BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_saved_views_user ON saved_views (user_id);
@@ -0,0 +1,4 @@
+-- 手动排序的分数索引键，必须按字节序比较
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS position TEXT COLLATE "C";
+CREATE INDEX IF NOT EXISTS idx_tasks_user_position ON tasks (user_id, position) WHERE deleted_at IS NULL;
+-- 已有任务的 position 为空，由后台重新平衡任务按原有顺序补齐
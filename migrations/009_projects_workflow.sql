@@ -0,0 +1,16 @@
+-- 项目及其工作流（看板的列、允许的状态转换和 WIP 限制）
+CREATE TABLE IF NOT EXISTS projects (
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name       VARCHAR(100) NOT NULL,
+    workflow   JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_projects_user ON projects (user_id);
+
+-- 任务的状态，done 类别的状态与 done = TRUE 保持一致
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS project_id INTEGER REFERENCES projects(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS status VARCHAR(32) NOT NULL DEFAULT 'todo';
+UPDATE tasks SET status = 'done' WHERE done AND status = 'todo';
+CREATE INDEX IF NOT EXISTS idx_tasks_project_status ON tasks (project_id, status) WHERE deleted_at IS NULL;
@@ -0,0 +1,17 @@
+-- 任务依赖：blocker 完成之前 blocked 不能开始
+CREATE TABLE IF NOT EXISTS task_dependencies (
+    blocker_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    blocked_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+CREATE INDEX IF NOT EXISTS idx_task_dependencies_blocked ON task_dependencies (blocked_id);
+
+-- 任务是否被未完成的（且不在回收站中的）任务阻塞，读取任务时计算
+CREATE OR REPLACE FUNCTION task_is_blocked(task_id INTEGER) RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM task_dependencies d JOIN tasks b ON b.id = d.blocker_id
+        WHERE d.blocked_id = task_id AND NOT b.done AND b.deleted_at IS NULL
+    );
+$$ LANGUAGE SQL STABLE;
@@ -0,0 +1,15 @@
+-- 时间记录，stopped_at 为空表示计时器仍在运行
+CREATE TABLE IF NOT EXISTS time_entries (
+    id         SERIAL PRIMARY KEY,
+    task_id    INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id    INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    stopped_at TIMESTAMPTZ,
+    note       TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (stopped_at IS NULL OR stopped_at >= started_at)
+);
+CREATE INDEX IF NOT EXISTS idx_time_entries_user_started ON time_entries (user_id, started_at);
+CREATE INDEX IF NOT EXISTS idx_time_entries_task ON time_entries (task_id);
+-- 每个用户最多一个正在运行的计时器
+CREATE UNIQUE INDEX IF NOT EXISTS idx_time_entries_running ON time_entries (user_id) WHERE stopped_at IS NULL;
@@ -0,0 +1,16 @@
+-- 历史版本保存任务所有可修改的字段，恢复时不再遗漏截止时间、优先级、标签、项目和状态
+ALTER TABLE task_revisions ADD COLUMN IF NOT EXISTS due_at TIMESTAMPTZ;
+ALTER TABLE task_revisions ADD COLUMN IF NOT EXISTS priority SMALLINT;
+ALTER TABLE task_revisions ADD COLUMN IF NOT EXISTS tags TEXT[];
+ALTER TABLE task_revisions ADD COLUMN IF NOT EXISTS project_id INTEGER;
+ALTER TABLE task_revisions ADD COLUMN IF NOT EXISTS status VARCHAR(32);
+
+-- 已有的版本没有这些字段的快照，使用任务当前的值（与之前恢复时保留当前值的行为一致）
+UPDATE task_revisions r
+SET due_at = t.due_at, priority = t.priority, tags = t.tags, project_id = t.project_id, status = t.status
+FROM tasks t
+WHERE t.id = r.task_id AND r.status IS NULL;
+
+ALTER TABLE task_revisions ALTER COLUMN priority SET DEFAULT 0, ALTER COLUMN priority SET NOT NULL;
+ALTER TABLE task_revisions ALTER COLUMN tags SET DEFAULT '{}', ALTER COLUMN tags SET NOT NULL;
+ALTER TABLE task_revisions ALTER COLUMN status SET DEFAULT 'todo', ALTER COLUMN status SET NOT NULL;
@@ -0,0 +1,391 @@
+// Package client 是 Todo API 的 Go 客户端
+//
+// 客户端在 token 过期或被拒绝时用保存的用户名和密码重新登录，
+// 对 429 和 5xx 按 Retry-After 或带抖动的指数退避重试，修改类请求自动带上 Idempotency-Key，重试不会重复执行。
+// API 返回的错误为 *Error，可以用 errors.Is(err, client.CodeNotFound) 按错误码判断。
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mathrand "math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries    = 3
+	defaultMinBackoff    = 200 * time.Millisecond
+	defaultMaxBackoff    = 5 * time.Second
+	defaultMaxRetryAfter = time.Minute
+	//token 在过期前这么久就重新登录
+	refreshSkew = 30 * time.Second
+
+	mergePatchContentType = "application/merge-patch+json"
+)
+
+// Client Todo API 的客户端，可以被多个 goroutine 同时使用
+type Client struct {
+	baseURL       *url.URL
+	httpClient    *http.Client
+	userAgent     string
+	locale        string
+	maxRetries    int
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+	maxRetryAfter time.Duration //Retry-After 超过该时间时不再重试，直接返回错误
+
+	mu       sync.Mutex
+	token    string
+	expiry   time.Time //为零表示不知道过期时间
+	username string
+	password string
+
+	refreshMu sync.Mutex //同一时间只有一个请求去重新登录
+}
+
+// Option 创建 Client 时的选项
+type Option func(*Client)
+
+// WithHTTPClient 使用自定义的 http.Client，例如设置超时或代理
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithToken 使用已有的 token
+func WithToken(token string) Option {
+	return func(c *Client) { c.setToken(token) }
+}
+
+// WithCredentials 保存用户名和密码，没有 token 或 token 过期时自动登录
+func WithCredentials(username, password string) Option {
+	return func(c *Client) { c.username, c.password = username, password }
+}
+
+// WithRetry 设置最大重试次数和退避时间的范围，maxRetries 为0表示不重试
+func WithRetry(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) { c.maxRetries, c.minBackoff, c.maxBackoff = maxRetries, minBackoff, maxBackoff }
+}
+
+// WithMaxRetryAfter 服务器要求等待的时间超过 d 时不再重试
+func WithMaxRetryAfter(d time.Duration) Option {
+	return func(c *Client) { c.maxRetryAfter = d }
+}
+
+// WithLocale 设置 Accept-Language，错误信息使用该语言
+func WithLocale(locale string) Option {
+	return func(c *Client) { c.locale = locale }
+}
+
+// WithUserAgent 设置 User-Agent
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// New 创建客户端，baseURL 为 API 的根地址，例如 https://todo.example.com/api/v1
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("client: base URL must be http or https: %q", baseURL)
+	}
+	c := &Client{
+		baseURL:       u,
+		httpClient:    http.DefaultClient,
+		userAgent:     "todo-go-client",
+		maxRetries:    defaultMaxRetries,
+		minBackoff:    defaultMinBackoff,
+		maxBackoff:    defaultMaxBackoff,
+		maxRetryAfter: defaultMaxRetryAfter,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Token 返回当前使用的 token
+func (c *Client) Token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+// setToken 保存 token，并从中读取过期时间（不校验签名）
+func (c *Client) setToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token, c.expiry = token, tokenExpiry(token)
+}
+
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if json.Unmarshal(payload, &claims) != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
+}
+
+// authToken 返回可用的 token，快过期时先重新登录；没有 token 也不能登录时返回空字符串
+func (c *Client) authToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	token, expiry, canLogin := c.token, c.expiry, c.username != ""
+	c.mu.Unlock()
+	if token != "" && (expiry.IsZero() || time.Until(expiry) > refreshSkew) {
+		return token, nil
+	}
+	if !canLogin {
+		return token, nil
+	}
+	return c.refresh(ctx, token)
+}
+
+// refresh 用保存的用户名和密码重新登录，stale 是调用方认为已失效的 token
+// 其他请求已经换了新 token 时直接使用新的
+func (c *Client) refresh(ctx context.Context, stale string) (string, error) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	c.mu.Lock()
+	token, expiry, username, password := c.token, c.expiry, c.username, c.password
+	c.mu.Unlock()
+	if token != "" && token != stale && (expiry.IsZero() || time.Until(expiry) > refreshSkew) {
+		return token, nil
+	}
+	return c.Login(ctx, username, password)
+}
+
+func (c *Client) canRefresh() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.username != ""
+}
+
+// request 一次 API 调用
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        any    //为空表示没有请求体
+	contentType string //默认为 application/json
+	header      http.Header
+	public      bool //不需要认证
+}
+
+// do 发送请求并把成功的响应解码到 out 中，out 为空时丢弃响应体
+func (c *Client) do(ctx context.Context, r request, out any) error {
+	var body []byte
+	if r.body != nil {
+		var err error
+		if body, err = json.Marshal(r.body); err != nil {
+			return fmt.Errorf("client: encode request: %w", err)
+		}
+	}
+	//修改类请求带上幂等键，重试时服务端直接返回第一次的结果
+	idempotencyKey := ""
+	if r.method != http.MethodGet {
+		idempotencyKey = newIdempotencyKey()
+	}
+
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		token := ""
+		if !r.public {
+			var err error
+			if token, err = c.authToken(ctx); err != nil {
+				return err
+			}
+		}
+		req, err := c.newRequest(ctx, r, body, token, idempotencyKey)
+		if err != nil {
+			return err
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if attempt < c.maxRetries {
+				if err := sleep(ctx, c.backoff(attempt)); err != nil {
+					return err
+				}
+				continue
+			}
+			return fmt.Errorf("client: %s %s: %w", r.method, r.path, err)
+		}
+		if resp.StatusCode < http.StatusBadRequest {
+			return decodeResponse(resp, out)
+		}
+
+		apiErr := parseError(resp)
+		//token 被拒绝时重新登录一次，不计入重试次数
+		if resp.StatusCode == http.StatusUnauthorized && !r.public && !refreshed && c.canRefresh() {
+			refreshed = true
+			attempt--
+			if _, err := c.refresh(ctx, token); err != nil {
+				return err
+			}
+			continue
+		}
+		if !(retryable(resp.StatusCode) || apiErr.Code == string(CodeIdempotencyInProgress)) || attempt >= c.maxRetries {
+			return apiErr
+		}
+		delay := c.backoff(attempt)
+		if apiErr.RetryAfter > 0 {
+			if apiErr.RetryAfter > c.maxRetryAfter {
+				return apiErr
+			}
+			delay = apiErr.RetryAfter
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) newRequest(ctx context.Context, r request, body []byte, token, idempotencyKey string) (*http.Request, error) {
+	u := *c.baseURL
+	u.Path = c.baseURL.Path + r.path
+	u.RawQuery = r.query.Encode()
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, u.String(), reader)
+	if err != nil {
+		return nil, fmt.Errorf("client: %w", err)
+	}
+	for name, values := range r.header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		contentType := r.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.locale != "" {
+		req.Header.Set("Accept-Language", c.locale)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	return req, nil
+}
+
+func decodeResponse(resp *http.Response, out any) error {
+	defer resp.Body.Close()
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, err := io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("client: decode response: %w", err)
+	}
+	return nil
+}
+
+// retryable 429 和服务端的临时错误可以重试，501 表示不支持，重试也没有用
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || (status >= 500 && status != http.StatusNotImplemented)
+}
+
+// backoff 第 attempt 次重试前等待的时间：指数增长，并在后一半范围内随机，避免客户端同时重试
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.maxBackoff
+	if attempt < 30 && c.minBackoff<<attempt < c.maxBackoff {
+		d = c.minBackoff << attempt
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(mathrand.Int64N(int64(d-half)+1))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// parseRetryAfter 解析秒数或 HTTP 日期格式的 Retry-After
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// ifMatch version 大于0时生成 If-Match 头，否则不做版本检查
+func ifMatch(version int) http.Header {
+	if version <= 0 {
+		return nil
+	}
+	return http.Header{"If-Match": {`"` + strconv.Itoa(version) + `"`}}
+}
+
+var errNoID = errors.New("client: id must be positive")
+
+func taskPath(id int, elems ...string) (string, error) {
+	if id <= 0 {
+		return "", errNoID
+	}
+	path := "/tasks/" + strconv.Itoa(id)
+	for _, e := range elems {
+		path += "/" + url.PathEscape(e)
+	}
+	return path, nil
+}
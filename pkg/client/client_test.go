@@ -0,0 +1,229 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/config"
+	"github.com/HywlEch/Todo_list/internal/models"
+	"github.com/HywlEch/Todo_list/internal/password"
+	"github.com/HywlEch/Todo_list/internal/router"
+	"github.com/HywlEch/Todo_list/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	testUsername = "alice"
+	testPassword = "correct-horse-1"
+)
+
+// newTestServer 用真实的路由和 MockStore 启动服务，wrap 不为空时包在路由外面，用来模拟网关的错误
+func newTestServer(t *testing.T, wrap func(http.Handler) http.Handler) (*httptest.Server, *store.MockStore) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	ms := store.NewMockStore()
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	require.NoError(t, err)
+	ms.On("GetUserByUsername", mock.Anything, testUsername).
+		Return(&models.User{ID: 1, Username: testUsername, PasswordHash: string(hash)}, nil).Maybe()
+	ms.On("GetUserByUsername", mock.Anything, mock.Anything).Return(nil, store.ErrNotFound).Maybe()
+
+	policy, err := password.NewPolicy(config.PasswordConfig{})
+	require.NoError(t, err)
+	engine, err := router.New(router.Deps{
+		Store:          ms,
+		JWT:            config.JWTConfig{Secret: "test-secret", ExpiresInHours: 1},
+		PasswordPolicy: policy,
+	})
+	require.NoError(t, err)
+
+	var handler http.Handler = engine
+	if wrap != nil {
+		handler = wrap(engine)
+	}
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv, ms
+}
+
+func newTestClient(t *testing.T, srv *httptest.Server, opts ...Option) *Client {
+	t.Helper()
+	opts = append([]Option{WithLocale("en"), WithRetry(3, time.Millisecond, 10*time.Millisecond)}, opts...)
+	c, err := New(srv.URL+"/api/v1", opts...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestClient_Tasks(t *testing.T) {
+	srv, ms := newTestServer(t, nil)
+	c := newTestClient(t, srv, WithCredentials(testUsername, testPassword))
+	ctx := context.Background()
+
+	ms.On("CreateTask", mock.Anything, mock.MatchedBy(func(task *models.Task) bool {
+		return task.Title == "Write SDK" && task.UserID == 1
+	})).Run(func(args mock.Arguments) {
+		task := args.Get(1).(*models.Task)
+		task.ID, task.Version = 7, 1
+	}).Return(nil).Once()
+	task, err := c.CreateTask(ctx, TaskInput{Title: "Write SDK", Tags: []string{"go"}})
+	require.NoError(t, err)
+	assert.Equal(t, 7, task.ID)
+	assert.Equal(t, []string{"go"}, task.Tags)
+	assert.NotEmpty(t, c.Token(), "没有 token 时应该先登录")
+
+	ms.On("GetTaskByID", mock.Anything, 7, 1).Return(&models.Task{ID: 7, UserID: 1, Title: "Write SDK", Version: 2}, nil)
+	task, err = c.GetTask(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, 2, task.Version)
+
+	//版本冲突时错误中带有任务的当前内容
+	ms.On("UpdateTask", mock.Anything, mock.Anything).Return(store.ErrVersionConflict).Once()
+	_, err = c.UpdateTask(ctx, 7, TaskInput{Title: "Write SDK v2"}, 1)
+	assert.True(t, errors.Is(err, CodeTaskVersionConflict), err)
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusPreconditionFailed, apiErr.StatusCode)
+	require.NotNil(t, apiErr.CurrentTask())
+	assert.Equal(t, 2, apiErr.CurrentTask().Version)
+
+	ms.On("GetTaskByID", mock.Anything, 8, 1).Return(nil, store.ErrNotFound)
+	_, err = c.GetTask(ctx, 8)
+	assert.True(t, errors.Is(err, CodeNotFound), err)
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.NotEmpty(t, apiErr.Instance)
+
+	//校验错误带有字段
+	_, err = c.CreateTask(ctx, TaskInput{Title: " "})
+	assert.True(t, errors.Is(err, CodeInvalidBody), err)
+	require.True(t, errors.As(err, &apiErr))
+	require.NotEmpty(t, apiErr.Fields)
+	assert.Equal(t, "/title", apiErr.Fields[0].Pointer)
+}
+
+func TestClient_Login(t *testing.T) {
+	srv, _ := newTestServer(t, nil)
+	c := newTestClient(t, srv)
+
+	_, err := c.Login(context.Background(), testUsername, "wrong-password")
+	assert.True(t, errors.Is(err, CodeAuthInvalidCredentials), err)
+
+	token, err := c.Login(context.Background(), testUsername, testPassword)
+	require.NoError(t, err)
+	assert.Equal(t, token, c.Token())
+	assert.WithinDuration(t, time.Now().Add(time.Hour), tokenExpiry(token), time.Minute)
+}
+
+func TestClient_RefreshesRejectedToken(t *testing.T) {
+	srv, ms := newTestServer(t, nil)
+	ms.On("GetTaskByID", mock.Anything, 7, 1).Return(&models.Task{ID: 7, UserID: 1, Version: 1}, nil)
+
+	//没有用户名和密码时直接返回错误
+	c := newTestClient(t, srv, WithToken("expired"))
+	_, err := c.GetTask(context.Background(), 7)
+	assert.True(t, errors.Is(err, CodeAuthInvalidToken), err)
+
+	c = newTestClient(t, srv, WithToken("expired"), WithCredentials(testUsername, testPassword))
+	task, err := c.GetTask(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, 7, task.ID)
+	assert.NotEqual(t, "expired", c.Token())
+}
+
+// flaky 对 path 的前 failures 个请求返回 status，并记录每个请求的 Idempotency-Key
+type flaky struct {
+	mu         sync.Mutex
+	path       string
+	failures   int
+	status     int
+	retryAfter string
+	calls      int
+	keys       []string
+}
+
+func (f *flaky) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != f.path {
+			next.ServeHTTP(w, r)
+			return
+		}
+		f.mu.Lock()
+		f.calls++
+		f.keys = append(f.keys, r.Header.Get("Idempotency-Key"))
+		fail := f.calls <= f.failures
+		f.mu.Unlock()
+		if !fail {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if f.retryAfter != "" {
+			w.Header().Set("Retry-After", f.retryAfter)
+		}
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(f.status)
+		w.Write([]byte(`{"type":"/problems/retry_later","title":"Unavailable","status":503,"code":"retry_later"}`))
+	})
+}
+
+func TestClient_RetriesWithRetryAfter(t *testing.T) {
+	f := &flaky{path: "/api/v1/tasks", failures: 1, status: http.StatusServiceUnavailable, retryAfter: "1"}
+	srv, ms := newTestServer(t, f.wrap)
+	ms.On("CreateTask", mock.Anything, mock.Anything).Return(nil).Once()
+	c := newTestClient(t, srv, WithCredentials(testUsername, testPassword))
+
+	start := time.Now()
+	_, err := c.CreateTask(context.Background(), TaskInput{Title: "retry me"})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second, "应该按 Retry-After 等待")
+	assert.Equal(t, 2, f.calls)
+	//重试使用同一个幂等键
+	require.Len(t, f.keys, 2)
+	assert.NotEmpty(t, f.keys[0])
+	assert.Equal(t, f.keys[0], f.keys[1])
+}
+
+func TestClient_GivesUpAfterMaxRetries(t *testing.T) {
+	f := &flaky{path: "/api/v1/tasks/7", failures: 100, status: http.StatusBadGateway}
+	srv, _ := newTestServer(t, f.wrap)
+	c := newTestClient(t, srv, WithCredentials(testUsername, testPassword), WithRetry(2, time.Millisecond, 5*time.Millisecond))
+
+	_, err := c.GetTask(context.Background(), 7)
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr), err)
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	assert.Equal(t, 3, f.calls)
+}
+
+func TestClient_ContextCancelsRetry(t *testing.T) {
+	f := &flaky{path: "/api/v1/tasks/7", failures: 100, status: http.StatusTooManyRequests, retryAfter: "30"}
+	srv, _ := newTestServer(t, f.wrap)
+	c := newTestClient(t, srv, WithCredentials(testUsername, testPassword))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.GetTask(ctx, 7)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, 1, f.calls)
+}
+
+func TestBackoff(t *testing.T) {
+	c := &Client{minBackoff: 100 * time.Millisecond, maxBackoff: time.Second}
+	for attempt, max := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		d := c.backoff(attempt)
+		assert.GreaterOrEqual(t, d, max/2, "attempt %d", attempt)
+		assert.LessOrEqual(t, d, max, "attempt %d", attempt)
+	}
+	assert.Equal(t, 2*time.Second, parseRetryAfter("2"))
+	assert.InDelta(t, float64(10*time.Second), float64(parseRetryAfter(time.Now().Add(10*time.Second).UTC().Format(http.TimeFormat))), float64(time.Second))
+}
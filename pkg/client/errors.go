@@ -0,0 +1,136 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/HywlEch/Todo_list/internal/apperrors"
+)
+
+// Code 服务端返回的稳定错误码，与 apperrors 中的错误码相同
+// *Error 会 unwrap 为 Code，因此可以用 errors.Is(err, client.CodeNotFound) 判断
+type Code string
+
+func (c Code) Error() string { return string(c) }
+
+// 常用的错误码，其余错误码见 internal/apperrors/codes.go
+const (
+	CodeInternal               Code = apperrors.CodeInternal
+	CodeInvalidBody            Code = apperrors.CodeInvalidBody
+	CodeNotFound               Code = apperrors.CodeNotFound
+	CodeRateLimited            Code = apperrors.CodeRateLimited
+	CodeTimeout                Code = apperrors.CodeTimeout
+	CodeAPISunset              Code = apperrors.CodeAPISunset
+	CodeAuthMissingHeader      Code = apperrors.CodeAuthMissingHeader
+	CodeAuthInvalidToken       Code = apperrors.CodeAuthInvalidToken
+	CodeAuthInvalidCredentials Code = apperrors.CodeAuthInvalidCredentials
+	CodeUserExists             Code = apperrors.CodeUserExists
+	CodeWeakPassword           Code = apperrors.CodeWeakPassword
+	CodeIdempotencyInProgress  Code = apperrors.CodeIdempotencyInProgress
+	CodeIdempotencyKeyReused   Code = apperrors.CodeIdempotencyKeyReused
+	CodeTaskVersionConflict    Code = apperrors.CodeTaskVersionConflict
+	CodeTaskInvalidMove        Code = apperrors.CodeTaskInvalidMove
+)
+
+// Error API 返回的错误，对应服务端的 RFC 9457 problem+json
+type Error struct {
+	StatusCode int
+	Code       string //稳定的错误码，响应不是 problem+json 时为空
+	Title      string
+	Detail     string //按 Accept-Language 翻译的提示信息
+	Instance   string //请求ID，反馈问题时附上
+	Fields     []FieldError
+	RetryAfter time.Duration //响应中的 Retry-After，没有时为0
+
+	extensions map[string]json.RawMessage
+}
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Pointer string `json:"pointer"` //JSON Pointer，例如 /title
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Detail  string `json:"detail"`
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "todo api: %d", e.StatusCode)
+	if e.Code != "" {
+		b.WriteString(" " + e.Code)
+	}
+	if e.Detail != "" {
+		b.WriteString(": " + e.Detail)
+	}
+	for _, f := range e.Fields {
+		fmt.Fprintf(&b, "; %s: %s", f.Pointer, f.Detail)
+	}
+	return b.String()
+}
+
+// Unwrap 返回错误码
+func (e *Error) Unwrap() error {
+	if e.Code == "" {
+		return nil
+	}
+	return Code(e.Code)
+}
+
+// Extension 把 problem 中的扩展成员解码到 v 中，例如版本冲突时的 current，成员不存在时返回 false
+func (e *Error) Extension(name string, v any) (bool, error) {
+	raw, ok := e.extensions[name]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// CurrentTask 版本冲突（412）时服务端返回的任务当前内容
+func (e *Error) CurrentTask() *Task {
+	var task Task
+	if ok, err := e.Extension("current", &task); !ok || err != nil {
+		return nil
+	}
+	return &task
+}
+
+// parseError 读取错误响应，响应不是 problem+json 时把响应体作为 Detail
+func parseError(resp *http.Response) *Error {
+	defer resp.Body.Close()
+	e := &Error{
+		StatusCode: resp.StatusCode,
+		Title:      http.StatusText(resp.StatusCode),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return e
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "application/problem+json" && mediaType != "application/json" {
+		e.Detail = strings.TrimSpace(string(data))
+		return e
+	}
+	var problem struct {
+		Title    string       `json:"title"`
+		Detail   string       `json:"detail"`
+		Instance string       `json:"instance"`
+		Code     string       `json:"code"`
+		Errors   []FieldError `json:"errors"`
+	}
+	if json.Unmarshal(data, &problem) != nil {
+		e.Detail = strings.TrimSpace(string(data))
+		return e
+	}
+	json.Unmarshal(data, &e.extensions)
+	if problem.Title != "" {
+		e.Title = problem.Title
+	}
+	e.Code, e.Detail, e.Instance, e.Fields = problem.Code, problem.Detail, problem.Instance, problem.Errors
+	return e
+}
@@ -0,0 +1,237 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// CreateTask 创建任务
+func (c *Client) CreateTask(ctx context.Context, input TaskInput) (*Task, error) {
+	return c.doTask(ctx, request{method: http.MethodPost, path: "/tasks", body: input})
+}
+
+// ListTasks 返回任务列表，opts 可以为空
+func (c *Client) ListTasks(ctx context.Context, opts *ListTasksOptions) ([]Task, error) {
+	query := url.Values{}
+	if opts != nil {
+		setQuery(query, "filter", opts.Filter)
+		setQuery(query, "sort", opts.Sort)
+	}
+	var tasks []Task
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/tasks", query: query}, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// GetTask 获取任务
+func (c *Client) GetTask(ctx context.Context, id int) (*Task, error) {
+	path, err := taskPath(id)
+	if err != nil {
+		return nil, err
+	}
+	return c.doTask(ctx, request{method: http.MethodGet, path: path})
+}
+
+// UpdateTask 整体修改任务，version 大于0时只在任务仍是该版本时修改，否则返回 CodeTaskVersionConflict
+func (c *Client) UpdateTask(ctx context.Context, id int, input TaskInput, version int) (*Task, error) {
+	path, err := taskPath(id)
+	if err != nil {
+		return nil, err
+	}
+	return c.doTask(ctx, request{method: http.MethodPut, path: path, body: input, header: ifMatch(version)})
+}
+
+// PatchTask 用 JSON Merge Patch 修改部分字段，值为 nil 的字段会被清空
+func (c *Client) PatchTask(ctx context.Context, id int, patch map[string]any, version int) (*Task, error) {
+	path, err := taskPath(id)
+	if err != nil {
+		return nil, err
+	}
+	return c.doTask(ctx, request{
+		method:      http.MethodPatch,
+		path:        path,
+		body:        patch,
+		contentType: mergePatchContentType,
+		header:      ifMatch(version),
+	})
+}
+
+// DeleteTask 把任务移到回收站
+func (c *Client) DeleteTask(ctx context.Context, id int, version int) error {
+	path, err := taskPath(id)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, request{method: http.MethodDelete, path: path, header: ifMatch(version)}, nil)
+}
+
+// CompleteTask 把任务标记为完成或未完成
+func (c *Client) CompleteTask(ctx context.Context, id int, done bool, version int) (*Task, error) {
+	return c.PatchTask(ctx, id, map[string]any{"done": done}, version)
+}
+
+// MoveTask 手动排序
+func (c *Client) MoveTask(ctx context.Context, id int, input MoveInput, version int) (*Task, error) {
+	path, err := taskPath(id, "move")
+	if err != nil {
+		return nil, err
+	}
+	return c.doTask(ctx, request{method: http.MethodPost, path: path, body: input, header: ifMatch(version)})
+}
+
+// BulkTasks 批量操作任务
+func (c *Client) BulkTasks(ctx context.Context, req BulkRequest) (*BulkResponse, error) {
+	var resp BulkResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/tasks/bulk", body: req}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SearchTasks 全文搜索标题、内容和评论
+func (c *Client) SearchTasks(ctx context.Context, q string, opts PageOptions) (*Page[SearchResult], error) {
+	query := opts.values()
+	query.Set("q", q)
+	var page Page[SearchResult]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/tasks/search", query: query}, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// GetDependencies 返回任务的依赖图
+func (c *Client) GetDependencies(ctx context.Context, id int) (*DependencyGraph, error) {
+	path, err := taskPath(id, "dependencies")
+	if err != nil {
+		return nil, err
+	}
+	var graph DependencyGraph
+	if err := c.do(ctx, request{method: http.MethodGet, path: path}, &graph); err != nil {
+		return nil, err
+	}
+	return &graph, nil
+}
+
+// AddDependency 为任务添加依赖
+func (c *Client) AddDependency(ctx context.Context, id int, input DependencyInput) (*Dependency, error) {
+	path, err := taskPath(id, "dependencies")
+	if err != nil {
+		return nil, err
+	}
+	var dep Dependency
+	if err := c.do(ctx, request{method: http.MethodPost, path: path, body: input}, &dep); err != nil {
+		return nil, err
+	}
+	return &dep, nil
+}
+
+// RemoveDependency 删除两个任务之间的依赖
+func (c *Client) RemoveDependency(ctx context.Context, id int, otherID int) error {
+	path, err := taskPath(id, "dependencies", strconv.Itoa(otherID))
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, request{method: http.MethodDelete, path: path}, nil)
+}
+
+// ListRevisions 返回任务的历史版本
+func (c *Client) ListRevisions(ctx context.Context, id int, opts PageOptions) (*Page[Revision], error) {
+	path, err := taskPath(id, "revisions")
+	if err != nil {
+		return nil, err
+	}
+	var page Page[Revision]
+	if err := c.do(ctx, request{method: http.MethodGet, path: path, query: opts.values()}, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// GetRevision 返回任务的一个历史版本
+func (c *Client) GetRevision(ctx context.Context, id int, rev int) (*Revision, error) {
+	path, err := taskPath(id, "revisions", strconv.Itoa(rev))
+	if err != nil {
+		return nil, err
+	}
+	var revision Revision
+	if err := c.do(ctx, request{method: http.MethodGet, path: path}, &revision); err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// DiffRevisions 比较两个历史版本
+func (c *Client) DiffRevisions(ctx context.Context, id int, from, to int) (*RevisionDiff, error) {
+	path, err := taskPath(id, "revisions", "diff")
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{"from": {strconv.Itoa(from)}, "to": {strconv.Itoa(to)}}
+	var diff RevisionDiff
+	if err := c.do(ctx, request{method: http.MethodGet, path: path, query: query}, &diff); err != nil {
+		return nil, err
+	}
+	return &diff, nil
+}
+
+// RestoreRevision 把任务恢复到历史版本
+func (c *Client) RestoreRevision(ctx context.Context, id int, rev int) (*Task, error) {
+	path, err := taskPath(id, "revisions", strconv.Itoa(rev), "restore")
+	if err != nil {
+		return nil, err
+	}
+	return c.doTask(ctx, request{method: http.MethodPost, path: path})
+}
+
+// ListTrash 返回回收站中的任务
+func (c *Client) ListTrash(ctx context.Context) ([]Task, error) {
+	var tasks []Task
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/trash"}, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// RestoreTask 从回收站恢复任务
+func (c *Client) RestoreTask(ctx context.Context, id int) (*Task, error) {
+	if id <= 0 {
+		return nil, errNoID
+	}
+	return c.doTask(ctx, request{method: http.MethodPost, path: "/trash/" + strconv.Itoa(id) + "/restore"})
+}
+
+// PurgeTask 永久删除回收站中的任务
+func (c *Client) PurgeTask(ctx context.Context, id int) error {
+	if id <= 0 {
+		return errNoID
+	}
+	return c.do(ctx, request{method: http.MethodDelete, path: "/trash/" + strconv.Itoa(id)}, nil)
+}
+
+func (c *Client) doTask(ctx context.Context, r request) (*Task, error) {
+	var task Task
+	if err := c.do(ctx, r, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (o PageOptions) values() url.Values {
+	query := url.Values{}
+	if o.Page > 0 {
+		query.Set("page", strconv.Itoa(o.Page))
+	}
+	if o.PageSize > 0 {
+		query.Set("page_size", strconv.Itoa(o.PageSize))
+	}
+	return query
+}
+
+func setQuery(query url.Values, name, value string) {
+	if value != "" {
+		query.Set(name, value)
+	}
+}
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Task 与服务端的 TaskResponse 相同
+type Task struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Content     string     `json:"content"`
+	Done        bool       `json:"done"`
+	ProjectID   *int       `json:"project_id"`
+	Status      string     `json:"status"`
+	Priority    int        `json:"priority"`
+	Tags        []string   `json:"tags"`
+	DueAt       *time.Time `json:"due_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	Position    *string    `json:"position"`
+	IsBlocked   bool       `json:"is_blocked"`
+	Unblocked   []int      `json:"unblocked,omitempty"`
+	Version     int        `json:"version"` //修改时作为 If-Match 传回
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// TaskInput 创建或整体修改任务时的字段，与服务端的 TaskRequest 相同
+type TaskInput struct {
+	Title     string     `json:"title"`
+	Content   string     `json:"content,omitempty"`
+	Done      bool       `json:"done"`
+	DueAt     *time.Time `json:"due_at,omitempty"`
+	Priority  int        `json:"priority"`
+	Tags      []string   `json:"tags,omitempty"`
+	ProjectID *int       `json:"project_id,omitempty"`
+	Status    string     `json:"status,omitempty"`
+}
+
+// ListTasksOptions GET /tasks 的查询参数
+type ListTasksOptions struct {
+	Filter string //过滤表达式，例如 done:false AND due<today+7d
+	Sort   string //为空时使用用户资料中的默认排序
+}
+
+// PageOptions 分页参数，为0时使用服务端的默认值
+type PageOptions struct {
+	Page     int
+	PageSize int
+}
+
+// Page 分页的结果
+type Page[T any] struct {
+	Items    []T `json:"items"`
+	Total    int `json:"total"`
+	Page     int `json:"page"`
+	PageSize int `json:"page_size"`
+}
+
+// MoveInput 手动排序，移动到 BeforeID 之前或 AfterID 之后
+type MoveInput struct {
+	BeforeID int `json:"before_id,omitempty"`
+	AfterID  int `json:"after_id,omitempty"`
+}
+
+// BulkOperation 批量操作中的一项，Op 为 create、update、delete、complete 或 move
+type BulkOperation struct {
+	Op       string     `json:"op"`
+	ID       int        `json:"id,omitempty"`
+	Version  int        `json:"version,omitempty"` //期望的版本号，0表示不检查
+	Title    *string    `json:"title,omitempty"`
+	Content  *string    `json:"content,omitempty"`
+	Done     *bool      `json:"done,omitempty"`
+	DueAt    *time.Time `json:"due_at,omitempty"`
+	Priority *int       `json:"priority,omitempty"`
+	Tags     *[]string  `json:"tags,omitempty"`
+	Status   *string    `json:"status,omitempty"`
+	BeforeID int        `json:"before_id,omitempty"`
+	AfterID  int        `json:"after_id,omitempty"`
+}
+
+// BulkRequest 批量操作，Atomic 为 true 时任何一项失败都会回滚全部操作
+type BulkRequest struct {
+	Atomic     bool            `json:"atomic"`
+	Operations []BulkOperation `json:"operations"`
+}
+
+// BulkResult 单个操作的结果，失败时 Code 和 Error 不为空
+type BulkResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	ID     int    `json:"id,omitempty"`
+	Status int    `json:"status"`
+	Task   *Task  `json:"task,omitempty"`
+	Code   string `json:"code,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BulkResponse struct {
+	Atomic  bool         `json:"atomic"`
+	Results []BulkResult `json:"results"`
+}
+
+// SearchResult 全文搜索的一条结果，片段中匹配的词用 <mark></mark> 包裹
+type SearchResult struct {
+	Task           Task    `json:"task"`
+	Rank           float64 `json:"rank"`
+	TitleSnippet   string  `json:"title_snippet"`
+	ContentSnippet string  `json:"content_snippet"`
+	CommentSnippet *string `json:"comment_snippet,omitempty"`
+}
+
+// Dependency BlockerID 完成之前 BlockedID 处于阻塞状态
+type Dependency struct {
+	BlockerID int       `json:"blocker_id"`
+	BlockedID int       `json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DependencyInput BlockedBy 和 Blocks 二选一
+type DependencyInput struct {
+	BlockedBy int `json:"blocked_by,omitempty"`
+	Blocks    int `json:"blocks,omitempty"`
+}
+
+type DependencyGraph struct {
+	TaskID int          `json:"task_id"`
+	Nodes  []Task       `json:"nodes"`
+	Edges  []Dependency `json:"edges"`
+}
+
+// Revision 任务的一个历史版本
+type Revision struct {
+	TaskID        int        `json:"task_id"`
+	Revision      int        `json:"revision"`
+	Title         string     `json:"title"`
+	Content       string     `json:"content"`
+	Done          bool       `json:"done"`
+	DueAt         *time.Time `json:"due_at"`
+	Priority      int        `json:"priority"`
+	Tags          []string   `json:"tags"`
+	ProjectID     *int       `json:"project_id"`
+	Status        string     `json:"status"`
+	ChangedFields []string   `json:"changed_fields"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type FieldChange struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from"`
+	To    json.RawMessage `json:"to"`
+}
+
+type RevisionDiff struct {
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// User 与服务端的 UserResponse 相同
+type User struct {
+	ID          int       `json:"id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	Timezone    string    `json:"timezone"`
+	Locale      string    `json:"locale"`
+	WeekStart   int       `json:"week_start"`
+	DefaultSort string    `json:"default_sort"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ProfileUpdate 修改用户资料，只发送不为空的字段
+type ProfileUpdate struct {
+	DisplayName *string `json:"display_name,omitempty"`
+	Timezone    *string `json:"timezone,omitempty"`
+	Locale      *string `json:"locale,omitempty"`
+	WeekStart   *int    `json:"week_start,omitempty"`
+	DefaultSort *string `json:"default_sort,omitempty"`
+}
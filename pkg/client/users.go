@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Register 注册用户，返回用户ID
+func (c *Client) Register(ctx context.Context, username, password string) (int, error) {
+	var resp struct {
+		UserID int `json:"userid"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/regist",
+		body:   map[string]string{"username": username, "password": password},
+		public: true,
+	}, &resp)
+	return resp.UserID, err
+}
+
+// Login 登录并保存 token，之后的请求都使用它
+func (c *Client) Login(ctx context.Context, username, password string) (string, error) {
+	var resp struct {
+		Token string `json:"token"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/login",
+		body:   map[string]string{"username": username, "password": password},
+		public: true,
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+	c.setToken(resp.Token)
+	return resp.Token, nil
+}
+
+// GetMe 返回当前用户的资料
+func (c *Client) GetMe(ctx context.Context) (*User, error) {
+	var user User
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/me"}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateMe 修改当前用户的资料
+func (c *Client) UpdateMe(ctx context.Context, update ProfileUpdate) (*User, error) {
+	var user User
+	if err := c.do(ctx, request{method: http.MethodPatch, path: "/me", body: update}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}